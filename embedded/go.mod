@@ -7,3 +7,5 @@ require (
 	github.com/skx/critical v0.0.0-20220702064054-3fb19195fca5
 
 )
+
+replace github.com/skx/critical => ../
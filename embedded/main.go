@@ -71,12 +71,21 @@ func main() {
 		return
 	}
 
-	e.RegisterBuiltin("direction", direction)
-	e.RegisterBuiltin("forwards", forwards)
-	e.RegisterBuiltin("move", move)
-	e.RegisterBuiltin("pen", pen)
-	e.RegisterBuiltin("save", save)
-	e.RegisterBuiltin("turn", turn)
+	words := map[string]any{
+		"direction": direction,
+		"forwards":  forwards,
+		"move":      move,
+		"pen":       pen,
+		"save":      save,
+		"turn":      turn,
+	}
+	for name, fn := range words {
+		err = e.RegisterFunc(name, fn)
+		if err != nil {
+			fmt.Printf("Error registering %s: %s\n", name, err)
+			return
+		}
+	}
 
 	out, err := e.Evaluate()
 	if err == interpreter.ErrExit || err == interpreter.ErrReturn {
@@ -1,4 +1,4 @@
-// Draw a simple square
+# Draw a simple square
 proc draw_square {x y length} {
     move $x $y
     forwards $length
@@ -11,25 +11,25 @@ proc draw_square {x y length} {
 }
 
 
-// Pen down, so we draw.  (Default behaviour)
+# Pen down, so we draw.  (Default behaviour)
 pen 1
 
 
-// Draw a series of squares, rotating around in a circle
+# Draw a series of squares, rotating around in a circle
 set count 25
 
-// Loop $count times
+# Loop $count times
 for { set i 0 } { <= [set i] [set count] } { incr i } {
 
-    // Draw a square in the middle, of width/size 65
+    # Draw a square in the middle, of width/size 65
     draw_square 150 150 65
 
-    // Turn so that we complete a circle, or an approximation of one
+    # Turn so that we complete a circle, or an approximation of one
     turn [/ 360 [set count]]
 }
 
-// Save the `.PNG` and `.GIF` files
+# Save the `.PNG` and `.GIF` files
 save
 
-// All done
+# All done
 exit 0
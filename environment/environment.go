@@ -72,3 +72,33 @@ func (e *Environment) Set(name string, value string) {
 	// Create it as local.
 	e.vars[name] = value
 }
+
+// SetLocal stores a variable in this scope, even if a variable with the
+// same name exists within a parent-scope.
+//
+// This is used for the parameters of procedures, which must not update
+// the variables of their caller.
+func (e *Environment) SetLocal(name string, value string) {
+	e.vars[name] = value
+}
+
+// Variables returns a copy of the variables defined in this scope.
+//
+// Variables which are defined in any parent-scope are not included.
+func (e *Environment) Variables() map[string]string {
+	out := make(map[string]string, len(e.vars))
+	for k, v := range e.vars {
+		out[k] = v
+	}
+	return out
+}
+
+// Count returns the number of variables defined in this scope, and in
+// any parent-scopes.
+func (e *Environment) Count() int {
+	n := len(e.vars)
+	if e.parent != nil {
+		n += e.parent.Count()
+	}
+	return n
+}
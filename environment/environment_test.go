@@ -85,3 +85,79 @@ func TestScopedSet(t *testing.T) {
 		t.Fatalf("parent-child set failed")
 	}
 }
+
+func TestSetLocal(t *testing.T) {
+
+	// parent
+	p := New()
+	p.Set("FOO", "BAR")
+
+	// child
+	c := NewEnclosedEnvironment(p)
+	c.SetLocal("FOO", "BAZ")
+
+	// Child sees the local value
+	val, ok := c.Get("FOO")
+	if !ok || val != "BAZ" {
+		t.Fatalf("child has the wrong value: %s", val)
+	}
+
+	// Parent is unchanged
+	val, ok = p.Get("FOO")
+	if !ok || val != "BAR" {
+		t.Fatalf("parent value was modified: %s", val)
+	}
+
+	// Further updates in the child stay local
+	c.Set("FOO", "STEVE")
+	val, _ = p.Get("FOO")
+	if val != "BAR" {
+		t.Fatalf("parent value was modified: %s", val)
+	}
+}
+
+func TestVariables(t *testing.T) {
+
+	// parent
+	p := New()
+	p.Set("FOO", "BAR")
+
+	// child
+	c := NewEnclosedEnvironment(p)
+	c.SetLocal("STEVE", "KEMP")
+
+	vars := c.Variables()
+	if len(vars) != 1 || vars["STEVE"] != "KEMP" {
+		t.Fatalf("unexpected variables: %v", vars)
+	}
+
+	// Updating the copy doesn't change the environment
+	vars["STEVE"] = "BOB"
+	val, _ := c.Get("STEVE")
+	if val != "KEMP" {
+		t.Fatalf("environment was modified")
+	}
+}
+
+// TestCount tests counting variables in nested scopes.
+func TestCount(t *testing.T) {
+
+	e := New()
+	if e.Count() != 0 {
+		t.Fatalf("new environment isn't empty")
+	}
+
+	e.Set("a", "1")
+	e.Set("b", "2")
+
+	child := NewEnclosedEnvironment(e)
+	child.SetLocal("a", "3")
+	child.Set("c", "4")
+
+	if e.Count() != 2 {
+		t.Fatalf("wrong count for parent, got %d", e.Count())
+	}
+	if child.Count() != 4 {
+		t.Fatalf("wrong count for child, got %d", child.Count())
+	}
+}
@@ -1,16 +1,16 @@
-//
-// This demonstrates the use of the two words:
-//
-//  assert => Ensure that expr-results work as expected
-//
-//  assert_equal => Ensure that two things are identical.
-//
-// These are both implemented in our "standard library".
+#
+# This demonstrates the use of the two words:
+#
+#  assert => Ensure that expr-results work as expected
+#
+#  assert_equal => Ensure that two things are identical.
+#
+# These are both implemented in our "standard library".
 
 
-//
-// Simple operations
-//
+#
+# Simple operations
+#
 assert 1        == 1
 assert [+ 1 2]  == 3
 assert [* 3 5]  == 15
@@ -23,13 +23,13 @@ assert 32       ne 121
 assert [/ 21 3] == 7
 
 
-//
-// String equality
-//
+#
+# String equality
+#
 set name "Steve"
 assert_equal "Steve" "${name}"
 
-//
-// Numerical equality
-//
+#
+# Numerical equality
+#
 assert_equal 343 [+ 340 3]
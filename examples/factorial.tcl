@@ -1,10 +1,10 @@
-//
-// Demonstrate using recursion to calculate factorials.
-//
+#
+# Demonstrate using recursion to calculate factorials.
+#
 
-//
-// Define a command to calculate a factorial, recursively.
-//
+#
+# Define a command to calculate a factorial, recursively.
+#
 proc fact {n} {
     if  {<= $n 1} {
         return 1
@@ -13,7 +13,7 @@ proc fact {n} {
     }
 }
 
-//
-// Run that in a loop to show some examples
-//
+#
+# Run that in a loop to show some examples
+#
 loop cur 1 10 { puts "\t$cur! -> [fact $cur]" }
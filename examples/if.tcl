@@ -1,7 +1,7 @@
-//
-// Test that we can return from functions, and that later
-// expressions aren't executed
-//
+#
+# Test that we can return from functions, and that later
+# expressions aren't executed
+#
 proc foo {a} {
 
     if {== 1 $a } {
@@ -14,8 +14,8 @@ proc foo {a} {
     return 3
 }
 
-//
-// Test both the expected return-values
-//
+#
+# Test both the expected return-values
+#
 assert [foo 1] == 1
 assert [foo 3] == 2
@@ -6,21 +6,50 @@ import "testing"
 func TestArity(t *testing.T) {
 
 	tests := []string{
+		`after`,
+		`after idle`,
+		`after info a b`,
+
 		`append`,
 
 		`break "one"`,
 
+		`catch`,
+		`catch a b c`,
+
+		`clock`,
+		`clock seconds 1`,
+		`clock format`,
+		`clock add 0 1`,
+
+		`close`,
+		`close stdout stderr`,
+
 		`continue "one" "two"`,
 
+		`csv::join`,
+		`csv::read`,
+		`csv::split`,
+		`csv::split a b c`,
+		`csv::write stdout`,
+
+		`dict`,
+		`dict get`,
+		`dict keys a b`,
+
 		`decr`,
 		`decr "one" "two" "three"`,
 
-		`exit`,
+		`exec`,
+
 		`exit "one" "two"`,
 
 		`env`,
 		`env "one" "two"`,
 
+		`eof`,
+		`eof stdin stdout`,
+
 		`expr 1`,
 		`expr 1 + `,
 		`expr 1 + 2 + 3`,
@@ -28,28 +57,99 @@ func TestArity(t *testing.T) {
 		`eval`,
 		`eval "one" 3`,
 
+		`fconfigure`,
+		`fconfigure stdout -buffering line -encoding utf-8 -translation`,
+
+		`file`,
+		`file exists`,
+		`file size a b`,
+
+		`fileevent`,
+		`fileevent stdin`,
+		`fileevent stdin readable a b`,
+
+		`flush`,
+		`flush stdout stderr`,
+
 		`for "one"`,
 
+		`gets`,
+		`gets stdin var extra`,
+
+		`glob`,
+		`glob -directory`,
+
+		`http::formatQuery a`,
+		`http::geturl`,
+		`http::geturl a -method`,
+		`http::route a b`,
+		`http::serve a b`,
+
 		`if { 1 } `,
 		`if { 1 } { 2 } else { 3 } or { 4}`,
 
 		`incr`,
 		`incr "one" 2 3`,
 
+		`interp`,
+		`interp eval`,
+		`interp alias a b c`,
+		`interp share a b`,
+		`interp exists`,
+		`interp children a b`,
+		`interp expose a`,
+		`interp create a b`,
+
+		`json::decode`,
+		`json::encode`,
+		`json::string a b`,
+
+		`lindex`,
+
+		`llength`,
+		`llength a b`,
+
+		`open`,
+		`open "one" "r" 0644 "four"`,
+
+		`package`,
+		`package provide`,
+
 		`proc "one"`,
-		`proc "one", "two", "three", "four"`,
+		`proc "one" "two" "three" "four"`,
 
 		`puts "One" "Two"`,
+		`puts -nonewline stdout "One" "Two"`,
 		`puts`,
 
+		`read`,
+		`read stdin extra`,
+
 		`regexp`,
 		`regexp "one" "two" "three"`,
 
 		`return`,
 		`return "one" "two"`,
+		`seek stdin`,
+		`seek stdin 0 start extra`,
+
 		`set`,
 		`set 1 2 3`,
 
+		`socket`,
+		`socket a b c`,
+
+		`source`,
+		`source a b`,
+
+		`tell`,
+		`tell stdin stdout`,
+
+		`update a b`,
+
+		`vwait`,
+		`vwait a b`,
+
 		`while { 1 } `,
 		`while { 1 } { 2 } { 3  }`,
 	}
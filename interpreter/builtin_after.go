@@ -0,0 +1,88 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// after is the golang implementation of the TCL `after` function, which
+// schedules scripts to be run by the event loop.
+//
+//	after ms
+//	after ms script ?script ...?
+//	after idle script ?script ...?
+//	after cancel id
+//	after cancel script ?script ...?
+//	after info ?id?
+//
+// Given only a delay we sleep for that many milliseconds.  Otherwise the
+// scripts are joined and scheduled to run once the delay has passed, or
+// once the event loop is idle, and an identifier is returned which may be
+// given to `after cancel`.
+func after(i *Interpreter, args []string) (string, error) {
+
+	if len(args) < 1 {
+		return "", fmt.Errorf("wrong # args: should be \"after option ?arg ...?\"")
+	}
+
+	switch args[0] {
+	case "idle":
+		if len(args) < 2 {
+			return "", fmt.Errorf("wrong # args: should be \"after idle script ?script ...?\"")
+		}
+		return i.afterIdle(strings.Join(args[1:], " ")), nil
+
+	case "cancel":
+		if len(args) < 2 {
+			return "", fmt.Errorf("wrong # args: should be \"after cancel id|command\"")
+		}
+		i.cancelEvent(strings.Join(args[1:], " "))
+		return "", nil
+
+	case "info":
+		if len(args) > 2 {
+			return "", fmt.Errorf("wrong # args: should be \"after info ?id?\"")
+		}
+		if len(args) == 1 {
+			ids := []string{}
+			for _, ev := range i.events.timers {
+				ids = append(ids, ev.id)
+			}
+			for _, ev := range i.events.idle {
+				ids = append(ids, ev.id)
+			}
+			return formatList(ids), nil
+		}
+		ev, idle := i.findEvent(args[1])
+		if ev == nil {
+			return "", fmt.Errorf("event \"%s\" doesn't exist", args[1])
+		}
+		kind := "timer"
+		if idle {
+			kind = "idle"
+		}
+		return formatList([]string{ev.script, kind}), nil
+	}
+
+	ms, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "", fmt.Errorf("bad argument \"%s\": must be cancel, idle, info, or an integer", args[0])
+	}
+	if ms < 0 {
+		ms = 0
+	}
+	delay := time.Duration(ms) * time.Millisecond
+
+	if len(args) > 1 {
+		return i.after(delay, strings.Join(args[1:], " ")), nil
+	}
+
+	// Sleep, unless we're cancelled first.
+	select {
+	case <-i.clock.After(delay):
+	case <-i.ctx.Done():
+	}
+	return "", i.cancelled()
+}
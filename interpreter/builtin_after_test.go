@@ -0,0 +1,160 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAfter(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `after 10 {set a 1}`, Output: "after#0"},
+		{Input: `after 0 {set a 1}; update; set a`, Output: "1"},
+		{Input: `set a 0; after 10 {set a 1}; update; set a`, Output: "0"},
+		{Input: `set a 0; after -5 set a 1; update; set a`, Output: "1"},
+		{Input: `after idle {set a 2}; update; set a`, Output: "2"},
+		{Input: `set a 0; after 0 {set a 1}; after idle {set a 2}; update idletasks; set a`, Output: "2"},
+		{Input: `set a {}; after 0 {append a x}; after 0 {append a y}; update; set a`, Output: "xy"},
+		{Input: `set a {}; after 0 {append a 1; after 0 {append a 3}}; after 0 {append a 2}; update; set a`, Output: "123"},
+		{Input: `set a {}; after idle {append a 2}; after 0 {append a 1}; update; set a`, Output: "12"},
+		{Input: `set a 0; set x [after 0 {set a 1}]; after cancel $x; update; set a`, Output: "0"},
+		{Input: `set a 0; after 0 {set a 1}; after cancel {set a 1}; update; set a`, Output: "0"},
+		{Input: `set a 0; after idle {set a 1}; after cancel after#0; update; set a`, Output: "0"},
+		{Input: `after cancel unknown`, Output: ""},
+		{Input: `after 0 {set a 1}; after idle {set a 2}; after info`, Output: "after#0 after#1"},
+		{Input: `after info [after 5 {set a 1}]`, Output: "{set a 1} timer"},
+		{Input: `after info [after idle set a 1]`, Output: "{set a 1} idle"},
+		{Input: `after info`, Output: ""},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input, WithClock(NewFakeClock(time.Now())))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+}
+
+func TestAfterErrors(t *testing.T) {
+
+	tests := []string{
+		`after`,
+		`after bogus`,
+		`after idle`,
+		`after cancel`,
+		`after info a b`,
+		`after info after#99`,
+		`update bogus`,
+		`update a b`,
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", test)
+		}
+	}
+}
+
+// TestAfterClock tests that timers run once the clock reaches them.
+func TestAfterClock(t *testing.T) {
+
+	clock := NewFakeClock(time.Now())
+
+	e, er := New(`set a 0; after 100 {set a 1}`, WithClock(clock))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	_, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, step := range []struct {
+		advance time.Duration
+		value   string
+	}{
+		{advance: 50 * time.Millisecond, value: "0"},
+		{advance: 49 * time.Millisecond, value: "0"},
+		{advance: 1 * time.Millisecond, value: "1"},
+	} {
+		clock.Advance(step.advance)
+
+		out, err := e.Eval(`update; set a`)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if out != step.value {
+			t.Fatalf("unexpected value after %s: %s", step.advance, out)
+		}
+	}
+}
+
+// TestAfterSleep tests that `after ms` waits for the clock, or for
+// cancellation.
+func TestAfterSleep(t *testing.T) {
+
+	clock := NewFakeClock(time.Now())
+
+	e, er := New(``, WithClock(clock))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := e.Eval(`after 50`)
+		done <- err
+	}()
+
+	advance(t, clock, done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := e.EvalContext(ctx, `after 1000`)
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
+
+// advance moves the clock forward until the result is received, since
+// we can't know when the interpreter has started to wait for it.
+func advance(t *testing.T, clock *FakeClock, done chan error) {
+	t.Helper()
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			return
+		default:
+			clock.Advance(10 * time.Millisecond)
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
@@ -22,6 +22,9 @@ func appendFn(i *Interpreter, args []string) (string, error) {
 	}
 
 	// Update the value, and also return it
-	i.environment.Set(args[0], val)
+	err := i.setVar(args[0], val)
+	if err != nil {
+		return "", err
+	}
 	return val, nil
 }
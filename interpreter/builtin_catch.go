@@ -0,0 +1,74 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+)
+
+// errorCoder is implemented by errors which describe themselves with a
+// TCL error-code, such as "CHILDSTATUS 1234 1".
+type errorCoder interface {
+	ErrorCode() string
+}
+
+// catch is the golang implementation of the TCL `catch` function, which
+// evaluates a script and returns a code describing how it finished.
+//
+//	catch script ?resultVar?
+//
+// The code is 0 if the script succeeded, 1 if it raised an error, 2 if it
+// called `return`, 3 if it called `break`, and 4 if it called `continue`.
+// The result of the script, or the error message, is stored in resultVar.
+//
+// When an error is caught the global variable `errorCode` is set to
+// describe it, which is "NONE" unless the error has a more specific code.
+// Calls to `exit`, cancellation, and exceeded limits cannot be caught.
+func catch(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return "", fmt.Errorf("wrong # args: should be \"catch script ?resultVar?\"")
+	}
+
+	out, err := i.Eval(args[0])
+
+	code := "0"
+	switch {
+	case err == nil:
+	case err == ErrReturn:
+		code = "2"
+	case err == errBreak:
+		code = "3"
+	case err == errContinue:
+		code = "4"
+	case err == ErrExit || isFatal(err):
+		return out, err
+	default:
+		code = "1"
+		out = errorMessage(err)
+
+		errorCode := "NONE"
+		var coder errorCoder
+		if errors.As(err, &coder) {
+			errorCode = coder.ErrorCode()
+		}
+		i.globals.SetLocal("errorCode", errorCode)
+	}
+
+	if len(args) == 2 {
+		if err := i.setVar(args[1], out); err != nil {
+			return "", err
+		}
+	}
+	return code, nil
+}
+
+// errorMessage returns the message of an error which was raised by a
+// command, without the names of the commands it passed through.
+func errorMessage(err error) string {
+	for {
+		ie, ok := err.(*invokeError)
+		if !ok {
+			return err.Error()
+		}
+		err = ie.err
+	}
+}
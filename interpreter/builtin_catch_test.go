@@ -0,0 +1,85 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCatch(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `catch { set a 3 }`, Output: "0"},
+		{Input: `catch { set a 3 } result; set result`, Output: "3"},
+		{Input: `catch { unknown }`, Output: "1"},
+		{Input: `catch { unknown } result; set result`, Output: "unknown command 'unknown':unknown"},
+		{Input: `catch { set } result; set result`, Output: "set accepts one or two arguments, got 0"},
+		{Input: `catch { set }; set errorCode`, Output: "NONE"},
+		{Input: `proc p {} { incr } ; catch { p } result; set result`, Output: "incr takes one or two arguments"},
+		{Input: `catch { return 4 } result; set result`, Output: "4"},
+		{Input: `catch { return 4 }`, Output: "2"},
+		{Input: `catch { break }`, Output: "3"},
+		{Input: `catch { continue }`, Output: "4"},
+		{Input: `set a 0; while { expr $a < 3 } { incr a; catch { break } }; set a`, Output: "3"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+}
+
+// TestCatchUncatchable tests that some errors cannot be caught.
+func TestCatchUncatchable(t *testing.T) {
+
+	// Exit
+	e, _ := New(`catch { exit 3 }`)
+	out, err := e.Evaluate()
+	if err != ErrExit || out != "3" {
+		t.Fatalf("expected exit, got %s %v", out, err)
+	}
+
+	// Limits
+	e, _ = New(`catch { while { 1 } { } }`, WithMaxCommands(100))
+	_, err = e.Evaluate()
+	if !errors.Is(err, ErrMaxCommands) {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+
+	// Cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e, _ = New(`catch { set a 1 }`)
+	_, err = e.EvaluateContext(ctx)
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+
+	err := &invokeError{name: "outer", err: &invokeError{name: "inner", err: errors.New("failed")}}
+	if !strings.Contains(err.Error(), "error invoking outer: error invoking inner: failed") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if errorMessage(err) != "failed" {
+		t.Fatalf("unexpected message: %s", errorMessage(err))
+	}
+}
@@ -0,0 +1,591 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultClockFormat is the format `clock format` uses unless another is
+// given.
+const defaultClockFormat = "%a %b %d %H:%M:%S %Z %Y"
+
+// scanFormats are the formats `clock scan` tries, in order, if no format
+// is given.
+var scanFormats = []string{
+	defaultClockFormat,
+	"%Y-%m-%dT%H:%M:%S%z",
+	"%Y-%m-%dT%H:%M:%S",
+	"%Y-%m-%d %H:%M:%S",
+	"%Y-%m-%d %H:%M",
+	"%Y-%m-%d",
+	"%m/%d/%Y %H:%M:%S",
+	"%m/%d/%Y",
+	"%d %b %Y %H:%M:%S",
+	"%d %b %Y",
+	"%b %d %Y",
+	"%H:%M:%S",
+	"%H:%M",
+}
+
+// clock is the golang implementation of the TCL `clock` function, which
+// returns, formats, and parses, times.
+//
+//	clock seconds
+//	clock milliseconds
+//	clock microseconds
+//	clock clicks ?-milliseconds|-microseconds?
+//	clock format timestamp ?-format format? ?-timezone zone? ?-gmt boolean?
+//	clock scan string ?-format format? ?-timezone zone? ?-gmt boolean?
+//	clock add timestamp ?count unit ...? ?-timezone zone? ?-gmt boolean?
+//
+// Timestamps are the number of seconds since the epoch, and the current
+// time comes from the interpreter's Clock.  Formats use the specifiers
+// of strftime, such as "%Y-%m-%d %H:%M:%S".  Times are in the local time
+// zone, unless -gmt is true, or a -timezone is given such as ":UTC",
+// "Europe/London", or "+0100".
+func clock(i *Interpreter, args []string) (string, error) {
+
+	if len(args) < 1 {
+		return "", fmt.Errorf("wrong # args: should be \"clock subcommand ?arg ...?\"")
+	}
+
+	sub := args[0]
+	args = args[1:]
+	now := i.clock.Now()
+
+	switch sub {
+	case "seconds", "milliseconds", "microseconds":
+		if len(args) != 0 {
+			return "", fmt.Errorf("wrong # args: should be \"clock %s\"", sub)
+		}
+		switch sub {
+		case "seconds":
+			return strconv.FormatInt(now.Unix(), 10), nil
+		case "milliseconds":
+			return strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10), nil
+		}
+		return strconv.FormatInt(now.UnixNano()/int64(time.Microsecond), 10), nil
+
+	case "clicks":
+		if len(args) > 1 {
+			return "", fmt.Errorf("wrong # args: should be \"clock clicks ?-switch?\"")
+		}
+		unit := time.Nanosecond
+		if len(args) == 1 {
+			switch args[0] {
+			case "-milliseconds":
+				unit = time.Millisecond
+			case "-microseconds":
+				unit = time.Microsecond
+			default:
+				return "", fmt.Errorf("bad switch \"%s\": must be -milliseconds or -microseconds", args[0])
+			}
+		}
+		return strconv.FormatInt(now.UnixNano()/int64(unit), 10), nil
+
+	case "format", "scan", "add":
+		if len(args) < 1 {
+			return "", fmt.Errorf("wrong # args: should be \"clock %s %s ?-option value ...?\"", sub, map[string]string{"format": "timestamp", "scan": "string", "add": "timestamp"}[sub])
+		}
+		value := args[0]
+		args = args[1:]
+
+		// The units given to `clock add` come before the options.
+		var units []string
+		if sub == "add" {
+			for len(args) > 0 && args[0] != "-format" && args[0] != "-gmt" && args[0] != "-timezone" {
+				units = append(units, args[0])
+				args = args[1:]
+			}
+			if len(units)%2 != 0 {
+				return "", fmt.Errorf("wrong # args: should be \"clock add timestamp ?count unit ...? ?-option value ...?\"")
+			}
+		}
+
+		format := ""
+		loc := time.Local
+		for len(args) > 0 {
+			if len(args) < 2 {
+				return "", fmt.Errorf("missing value for option \"%s\"", args[0])
+			}
+
+			var err error
+			switch {
+			case args[0] == "-format" && sub != "add":
+				format = args[1]
+			case args[0] == "-timezone":
+				loc, err = clockLocation(args[1])
+			case args[0] == "-gmt":
+				var gmt bool
+				gmt, err = parseBool(args[1])
+				if gmt {
+					loc = time.UTC
+				}
+			default:
+				err = fmt.Errorf("bad option \"%s\": must be -format, -gmt, or -timezone", args[0])
+			}
+			if err != nil {
+				return "", err
+			}
+			args = args[2:]
+		}
+
+		if sub == "scan" {
+			secs, err := scanClockDefault(value, format, loc, now)
+			if err != nil {
+				return "", err
+			}
+			return strconv.FormatInt(secs, 10), nil
+		}
+
+		secs, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("expected integer but got \"%s\"", value)
+		}
+		t := time.Unix(secs, 0).In(loc)
+
+		if sub == "format" {
+			if format == "" {
+				format = defaultClockFormat
+			}
+			return formatClock(t, format), nil
+		}
+
+		for n := 0; n < len(units); n += 2 {
+			t, err = addClock(t, units[n], units[n+1])
+			if err != nil {
+				return "", err
+			}
+		}
+		return strconv.FormatInt(t.Unix(), 10), nil
+	}
+
+	return "", fmt.Errorf("unknown or ambiguous subcommand \"%s\": must be add, clicks, format, microseconds, milliseconds, scan, or seconds", sub)
+}
+
+// clockLocation returns the time zone with the given name, which may be
+// the name of a location such as "America/New_York", optionally prefixed
+// with ":", or an offset such as "+0530" or "-08:00".
+func clockLocation(name string) (*time.Location, error) {
+
+	switch name {
+	case "":
+		return time.Local, nil
+	case ":localtime":
+		return time.Local, nil
+	case "UTC", ":UTC", "GMT", ":GMT", "Z":
+		return time.UTC, nil
+	}
+
+	if offset, ok := parseOffset(name); ok {
+		return time.FixedZone(name, offset), nil
+	}
+
+	loc, err := time.LoadLocation(strings.TrimPrefix(name, ":"))
+	if err != nil {
+		return nil, fmt.Errorf("time zone \"%s\" not found", name)
+	}
+	return loc, nil
+}
+
+// parseOffset parses a time zone offset such as "+0530" or "-08:00", and
+// returns it in seconds east of UTC.
+func parseOffset(str string) (int, bool) {
+	if len(str) < 3 || (str[0] != '+' && str[0] != '-') {
+		return 0, false
+	}
+
+	digits := strings.Replace(str[1:], ":", "", 1)
+	if len(digits) != 2 && len(digits) != 4 {
+		return 0, false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+
+	hours, _ := strconv.Atoi(digits[:2])
+	mins := 0
+	if len(digits) == 4 {
+		mins, _ = strconv.Atoi(digits[2:])
+	}
+	if hours > 23 || mins > 59 {
+		return 0, false
+	}
+
+	offset := hours*3600 + mins*60
+	if str[0] == '-' {
+		offset = -offset
+	}
+	return offset, true
+}
+
+// expandClockFormat replaces the specifiers which are shorthand for
+// others, such as "%T" for "%H:%M:%S".
+func expandClockFormat(format string) string {
+	var out strings.Builder
+	for n := 0; n < len(format); n++ {
+		if format[n] != '%' || n == len(format)-1 {
+			out.WriteByte(format[n])
+			continue
+		}
+		n++
+		switch format[n] {
+		case 'D':
+			out.WriteString("%m/%d/%y")
+		case 'F':
+			out.WriteString("%Y-%m-%d")
+		case 'r':
+			out.WriteString("%I:%M:%S %p")
+		case 'R':
+			out.WriteString("%H:%M")
+		case 'T':
+			out.WriteString("%H:%M:%S")
+		default:
+			out.WriteByte('%')
+			out.WriteByte(format[n])
+		}
+	}
+	return out.String()
+}
+
+// formatClock formats a time, using the strftime specifiers.
+func formatClock(t time.Time, format string) string {
+
+	format = expandClockFormat(format)
+
+	var out strings.Builder
+	for n := 0; n < len(format); n++ {
+		if format[n] != '%' || n == len(format)-1 {
+			out.WriteByte(format[n])
+			continue
+		}
+		n++
+
+		hour12 := t.Hour() % 12
+		if hour12 == 0 {
+			hour12 = 12
+		}
+		year, week := t.ISOWeek()
+
+		switch format[n] {
+		case 'a':
+			out.WriteString(t.Format("Mon"))
+		case 'A':
+			out.WriteString(t.Format("Monday"))
+		case 'b', 'h':
+			out.WriteString(t.Format("Jan"))
+		case 'B':
+			out.WriteString(t.Format("January"))
+		case 'c':
+			out.WriteString(t.Format("Mon Jan _2 15:04:05 2006"))
+		case 'C':
+			fmt.Fprintf(&out, "%02d", t.Year()/100)
+		case 'd':
+			fmt.Fprintf(&out, "%02d", t.Day())
+		case 'e':
+			fmt.Fprintf(&out, "%2d", t.Day())
+		case 'g':
+			fmt.Fprintf(&out, "%02d", year%100)
+		case 'G':
+			fmt.Fprintf(&out, "%04d", year)
+		case 'H':
+			fmt.Fprintf(&out, "%02d", t.Hour())
+		case 'I':
+			fmt.Fprintf(&out, "%02d", hour12)
+		case 'j':
+			fmt.Fprintf(&out, "%03d", t.YearDay())
+		case 'k':
+			fmt.Fprintf(&out, "%2d", t.Hour())
+		case 'l':
+			fmt.Fprintf(&out, "%2d", hour12)
+		case 'm':
+			fmt.Fprintf(&out, "%02d", int(t.Month()))
+		case 'M':
+			fmt.Fprintf(&out, "%02d", t.Minute())
+		case 'n':
+			out.WriteByte('\n')
+		case 'p':
+			out.WriteString(t.Format("PM"))
+		case 's':
+			out.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'S':
+			fmt.Fprintf(&out, "%02d", t.Second())
+		case 't':
+			out.WriteByte('\t')
+		case 'u':
+			day := int(t.Weekday())
+			if day == 0 {
+				day = 7
+			}
+			out.WriteString(strconv.Itoa(day))
+		case 'V':
+			fmt.Fprintf(&out, "%02d", week)
+		case 'w':
+			out.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'y':
+			fmt.Fprintf(&out, "%02d", t.Year()%100)
+		case 'Y':
+			fmt.Fprintf(&out, "%04d", t.Year())
+		case 'z':
+			out.WriteString(t.Format("-0700"))
+		case 'Z':
+			out.WriteString(t.Format("MST"))
+		case '%':
+			out.WriteByte('%')
+		default:
+			out.WriteByte('%')
+			out.WriteByte(format[n])
+		}
+	}
+	return out.String()
+}
+
+// scanClockDefault parses a time in the given format, or if there is none
+// in each of the formats we recognize.
+func scanClockDefault(str string, format string, loc *time.Location, now time.Time) (int64, error) {
+	if format != "" {
+		return scanClock(str, format, loc, now)
+	}
+
+	for _, format := range scanFormats {
+		if secs, err := scanClock(str, format, loc, now); err == nil {
+			return secs, nil
+		}
+	}
+	return 0, fmt.Errorf("unable to convert date-time string \"%s\"", str)
+}
+
+// scanClock parses a time in the given format.
+//
+// If the format doesn't contain the date then it is taken from the
+// current time, and if it doesn't contain the time then it is midnight.
+func scanClock(str string, format string, loc *time.Location, now time.Time) (int64, error) {
+
+	fail := fmt.Errorf("input string \"%s\" does not match supplied format \"%s\"", str, format)
+	format = expandClockFormat(format)
+
+	year, month, day := now.In(loc).Date()
+	hour, min, sec := 0, 0, 0
+	pm := -1
+	var epoch *int64
+
+	pos := 0
+	skipSpace := func() {
+		for pos < len(str) && isListSpace(rune(str[pos])) {
+			pos++
+		}
+	}
+	number := func(width int) (int, bool) {
+		start := pos
+		for pos < len(str) && pos-start < width && str[pos] >= '0' && str[pos] <= '9' {
+			pos++
+		}
+		if pos == start {
+			return 0, false
+		}
+		n, _ := strconv.Atoi(str[start:pos])
+		return n, true
+	}
+	name := func(names []string) (int, bool) {
+		for n, full := range names {
+			candidates := []string{full}
+			if len(full) > 3 {
+				candidates = append(candidates, full[:3])
+			}
+			for _, candidate := range candidates {
+				if len(str)-pos >= len(candidate) && strings.EqualFold(str[pos:pos+len(candidate)], candidate) {
+					pos += len(candidate)
+					return n, true
+				}
+			}
+		}
+		return 0, false
+	}
+
+	for n := 0; n < len(format); n++ {
+		c := format[n]
+
+		if isListSpace(rune(c)) {
+			skipSpace()
+			continue
+		}
+		if c != '%' || n == len(format)-1 {
+			if pos >= len(str) || str[pos] != c {
+				return 0, fail
+			}
+			pos++
+			continue
+		}
+
+		n++
+		ok := true
+		switch format[n] {
+		case 'Y':
+			year, ok = number(4)
+		case 'y':
+			year, ok = number(2)
+			if year < 69 {
+				year += 2000
+			} else {
+				year += 1900
+			}
+		case 'm':
+			var m int
+			m, ok = number(2)
+			month = time.Month(m)
+		case 'd', 'e':
+			skipSpace()
+			day, ok = number(2)
+		case 'H', 'k':
+			skipSpace()
+			hour, ok = number(2)
+		case 'I', 'l':
+			skipSpace()
+			hour, ok = number(2)
+			if pm < 0 {
+				pm = 0
+			}
+		case 'M':
+			min, ok = number(2)
+		case 'S':
+			sec, ok = number(2)
+		case 'p':
+			var n int
+			n, ok = name([]string{"AM", "PM"})
+			pm = n
+		case 'b', 'h', 'B':
+			var m int
+			m, ok = name(monthNames)
+			month = time.Month(m + 1)
+		case 'a', 'A':
+			_, ok = name(dayNames)
+		case 's':
+			start := pos
+			if pos < len(str) && str[pos] == '-' {
+				pos++
+			}
+			_, ok = number(19)
+			if ok {
+				secs, err := strconv.ParseInt(str[start:pos], 10, 64)
+				ok = err == nil
+				epoch = &secs
+			}
+		case 'z', 'Z':
+			// Zones may be given as offsets, or by name.
+			end := pos
+			if pos < len(str) && (str[pos] == '+' || str[pos] == '-') {
+				end++
+				for end < len(str) && (str[end] == ':' || (str[end] >= '0' && str[end] <= '9')) {
+					end++
+				}
+				var offset int
+				offset, ok = parseOffset(str[pos:end])
+				if ok {
+					loc = time.FixedZone(str[pos:end], offset)
+				}
+				pos = end
+				break
+			}
+			for end < len(str) && ((str[end] >= 'A' && str[end] <= 'Z') || (str[end] >= 'a' && str[end] <= 'z')) {
+				end++
+			}
+			zone := str[pos:end]
+			abbrev, _ := now.In(loc).Zone()
+			switch {
+			case zone == "UTC" || zone == "GMT" || zone == "Z":
+				loc = time.UTC
+			case zone != abbrev:
+				ok = false
+			}
+			pos = end
+		case '%':
+			ok = pos < len(str) && str[pos] == '%'
+			pos++
+		default:
+			return 0, fmt.Errorf("unsupported format specifier \"%%%c\"", format[n])
+		}
+		if !ok {
+			return 0, fail
+		}
+	}
+
+	skipSpace()
+	if pos != len(str) {
+		return 0, fail
+	}
+
+	if epoch != nil {
+		return *epoch, nil
+	}
+
+	if pm >= 0 {
+		if hour < 1 || hour > 12 {
+			return 0, fail
+		}
+		hour = hour % 12
+		if pm == 1 {
+			hour += 12
+		}
+	}
+
+	t := time.Date(year, month, day, hour, min, sec, 0, loc)
+	if t.Month() != month || t.Day() != day || hour > 23 || min > 59 || sec > 60 {
+		return 0, fail
+	}
+	return t.Unix(), nil
+}
+
+// monthNames, and dayNames, are recognized by `clock scan`.
+var monthNames = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+var dayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+// addClock adds a number of units to a time, for `clock add`.
+//
+// Days, and longer units, are added in the time's location, so they're
+// not affected by daylight-saving time.  Adding months, or years, gives
+// the last day of the month if the day doesn't exist, so one month after
+// January 31st is the last day of February.
+func addClock(t time.Time, count string, unit string) (time.Time, error) {
+
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return t, fmt.Errorf("expected integer but got \"%s\"", count)
+	}
+
+	switch strings.TrimSuffix(unit, "s") {
+	case "second":
+		return t.Add(time.Duration(n) * time.Second), nil
+	case "minute":
+		return t.Add(time.Duration(n) * time.Minute), nil
+	case "hour":
+		return t.Add(time.Duration(n) * time.Hour), nil
+	case "day":
+		return t.AddDate(0, 0, n), nil
+	case "week":
+		return t.AddDate(0, 0, 7*n), nil
+	case "month", "year":
+		if unit[0] == 'y' {
+			n *= 12
+		}
+		months := int(t.Month()) - 1 + n
+		year := t.Year() + months/12
+		months %= 12
+		if months < 0 {
+			months += 12
+			year--
+		}
+
+		// Clamp the day to the last of the month.
+		day := t.Day()
+		last := time.Date(year, time.Month(months+2), 0, 0, 0, 0, 0, t.Location()).Day()
+		if day > last {
+			day = last
+		}
+		return time.Date(year, time.Month(months+1), day, t.Hour(), t.Minute(), t.Second(), 0, t.Location()), nil
+	}
+	return t, fmt.Errorf("unknown unit \"%s\": must be seconds, minutes, hours, days, weeks, months, or years", unit)
+}
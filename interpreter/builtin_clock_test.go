@@ -0,0 +1,105 @@
+package interpreter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `clock seconds`, Output: "1700000000"},
+		{Input: `clock milliseconds`, Output: "1700000000123"},
+		{Input: `clock microseconds`, Output: "1700000000123456"},
+		{Input: `clock clicks`, Output: "1700000000123456789"},
+		{Input: `clock clicks -milliseconds`, Output: "1700000000123"},
+		{Input: `clock clicks -microseconds`, Output: "1700000000123456"},
+
+		{Input: `clock format 0 -gmt 1`, Output: "Thu Jan 01 00:00:00 UTC 1970"},
+		{Input: `clock format 0 -gmt On`, Output: "Thu Jan 01 00:00:00 UTC 1970"},
+		{Input: `clock format [clock seconds] -format {%Y-%m-%d %H:%M:%S} -gmt true`, Output: "2023-11-14 22:13:20"},
+		{Input: `clock format 1700000000 -format {%F %T %z} -timezone :UTC`, Output: "2023-11-14 22:13:20 +0000"},
+		{Input: `clock format 1700000000 -format {%a %A %b %B %e %j %u %w} -gmt 1`, Output: "Tue Tuesday Nov November 14 318 2 2"},
+		{Input: `clock format 1700000000 -format {%I:%M %p %y %C %s %%} -gmt 1`, Output: "10:13 PM 23 20 1700000000 %"},
+		{Input: `clock format 1700000000 -format {%D %R} -gmt 1`, Output: "11/14/23 22:13"},
+		{Input: `clock format 1700000000 -format %H:%M -timezone +0530`, Output: "03:43"},
+		{Input: `clock format 1700000000 -format {%H:%M %Z} -timezone America/New_York`, Output: "17:13 EST"},
+		{Input: `clock format 1700000000 -format {%G-W%V} -gmt 1`, Output: "2023-W46"},
+
+		{Input: `clock scan {2023-11-14 22:13:20} -format {%Y-%m-%d %H:%M:%S} -gmt 1`, Output: "1700000000"},
+		{Input: `clock scan {2023-11-14 22:13:20} -gmt 1`, Output: "1700000000"},
+		{Input: `clock scan {2023-11-14T23:13:20+0100}`, Output: "1700000000"},
+		{Input: `clock scan {Tue Nov 14 22:13:20 UTC 2023}`, Output: "1700000000"},
+		{Input: `clock scan {14 November 2023} -timezone :UTC`, Output: "1699920000"},
+		{Input: `clock scan {10:13:20 pm} -format {%I:%M:%S %p} -gmt 1`, Output: "1700000000"},
+		{Input: `clock scan 12:00 -gmt 1`, Output: "1699963200"},
+		{Input: `clock scan 1700000000 -format %s`, Output: "1700000000"},
+		{Input: `clock scan {11/14/23} -format %D -gmt 1`, Output: "1699920000"},
+		{Input: `clock scan [clock format 1700000000 -timezone +0200] -timezone +0200`, Output: "1700000000"},
+
+		{Input: `clock add 1700000000 10 seconds 1 minute`, Output: "1700000070"},
+		{Input: `clock add 1700000000 -2 hours`, Output: "1699992800"},
+		{Input: `clock add 1700000000 1 day 1 week -gmt 1`, Output: "1700691200"},
+		{Input: `clock format [clock add [clock scan {2024-01-31} -gmt 1] 1 month -gmt 1] -format %F -gmt 1`, Output: "2024-02-29"},
+		{Input: `clock format [clock add [clock scan {2024-02-29} -gmt 1] 1 year -gmt 1] -format %F -gmt 1`, Output: "2025-02-28"},
+		{Input: `clock format [clock add [clock scan {2024-03-15} -gmt 1] -3 months -gmt 1] -format %F -gmt 1`, Output: "2023-12-15"},
+		{Input: `clock add 1700000000`, Output: "1700000000"},
+	}
+
+	now := time.Unix(1700000000, 123456789)
+
+	for _, test := range tests {
+
+		e, er := New(test.Input, WithClock(NewFakeClock(now)))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+}
+
+func TestClockErrors(t *testing.T) {
+
+	tests := []string{
+		`clock bogus`,
+		`clock clicks -bogus`,
+		`clock format bogus`,
+		`clock format 0 -bogus 1`,
+		`clock format 0 -format`,
+		`clock format 0 -gmt bogus`,
+		`clock format 0 -timezone Bogus/Zone`,
+		`clock scan bogus`,
+		`clock scan {2023-13-01} -format %Y-%m-%d`,
+		`clock scan {2023-02-30} -format %Y-%m-%d`,
+		`clock scan {2023-01-01x} -format %Y-%m-%d`,
+		`clock scan 2023 -format %Q`,
+		`clock add 0 1 fortnight`,
+		`clock add 0 one day`,
+		`clock add 0 1 day -format %Y`,
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", test)
+		}
+	}
+}
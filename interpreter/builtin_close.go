@@ -0,0 +1,14 @@
+package interpreter
+
+import "fmt"
+
+// closeFn is the golang implementation of the TCL `close` function.
+//
+//	close channel
+func closeFn(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("close only accepts one argument, got %d", len(args))
+	}
+
+	return "", i.closeChannel(args[0])
+}
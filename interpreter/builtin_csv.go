@@ -0,0 +1,301 @@
+package interpreter
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// csvSplitFn is the golang implementation of the `csv::split` function,
+// which splits a CSV record into a list of its fields.
+//
+//	csv::split line ?separator?
+//
+// The separator defaults to a comma.  Quoted fields may contain the
+// separator, quotes, and newlines, but the line must contain a single
+// record.
+func csvSplitFn(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return "", fmt.Errorf("wrong # args: should be \"csv::split line ?separator?\"")
+	}
+
+	sep, err := csvSeparator(args[1:])
+	if err != nil {
+		return "", err
+	}
+
+	r := newCSVReader(strings.NewReader(args[0]), sep)
+	record, err := r.Read()
+	if err == io.EOF {
+		return "", nil
+	}
+	if err != nil {
+		return "", csvError(err)
+	}
+
+	if _, err := r.Read(); err != io.EOF {
+		return "", fmt.Errorf("invalid CSV: expected a single record")
+	}
+	return formatList(record), nil
+}
+
+// csvJoinFn is the golang implementation of the `csv::join` function,
+// which joins a list of fields into a CSV record, quoting them as
+// required.
+//
+//	csv::join list ?separator?
+func csvJoinFn(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return "", fmt.Errorf("wrong # args: should be \"csv::join list ?separator?\"")
+	}
+
+	sep, err := csvSeparator(args[1:])
+	if err != nil {
+		return "", err
+	}
+	fields, err := parseList(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	out, err := formatCSV([][]string{fields}, sep)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+// csvReadFn is the golang implementation of the `csv::read` function,
+// which reads a single record from a channel.
+//
+//	csv::read ?-separator char? ?-header list? channel ?varName?
+//
+// Like `gets` the record is returned, as a list of its fields, unless a
+// variable is given, in which case it is stored in the variable and the
+// number of fields is returned, or -1 at the end of the input.
+//
+// Given a header, typically the first record which was read, the record
+// is returned as a dictionary whose keys are the names in the header.
+//
+// The input is decoded, and its line-endings translated, as configured
+// via `fconfigure`, just as it is for `gets`.
+func csvReadFn(i *Interpreter, args []string) (string, error) {
+
+	sep := ','
+	var header []string
+
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if len(args) < 2 {
+			return "", fmt.Errorf("wrong # args: should be \"csv::read ?-separator char? ?-header list? channel ?varName?\"")
+		}
+
+		var err error
+		switch args[0] {
+		case "-separator":
+			sep, err = csvSeparator(args[1:2])
+		case "-header":
+			header, err = parseList(args[1])
+		default:
+			err = fmt.Errorf("bad option \"%s\": must be -header, or -separator", args[0])
+		}
+		if err != nil {
+			return "", err
+		}
+		args = args[2:]
+	}
+
+	if len(args) != 1 && len(args) != 2 {
+		return "", fmt.Errorf("wrong # args: should be \"csv::read ?-separator char? ?-header list? channel ?varName?\"")
+	}
+
+	ch, err := i.readChannel(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	ch.readMutex.Lock()
+	record, err := newCSVReader(&lineReader{ch: ch}, sep).Read()
+	ch.readMutex.Unlock()
+	found := true
+	if err == io.EOF {
+		ch.eof = true
+		found = false
+		err = nil
+	}
+	if err != nil {
+		return "", csvError(err)
+	}
+
+	out := formatList(record)
+	if found && header != nil {
+		d := &dictionary{}
+		for n, name := range header {
+			val := ""
+			if n < len(record) {
+				val = record[n]
+			}
+			d.set(name, val)
+		}
+		out = d.String()
+	}
+
+	if len(args) == 1 {
+		return out, nil
+	}
+
+	if err := i.setVar(args[1], out); err != nil {
+		return "", err
+	}
+	if !found {
+		return "-1", nil
+	}
+	return strconv.Itoa(len(record)), nil
+}
+
+// csvWriteFn is the golang implementation of the `csv::write` function,
+// which writes records to a channel.
+//
+//	csv::write ?-separator char? ?-header list? channel rows
+//
+// Each row is a list of fields.  Given a header it is written first, and
+// each row is a dictionary whose values are written in the order of the
+// names in the header.
+func csvWriteFn(i *Interpreter, args []string) (string, error) {
+
+	sep := ','
+	var header []string
+
+	for len(args) > 2 && strings.HasPrefix(args[0], "-") {
+		var err error
+		switch args[0] {
+		case "-separator":
+			sep, err = csvSeparator(args[1:2])
+		case "-header":
+			header, err = parseList(args[1])
+		default:
+			err = fmt.Errorf("bad option \"%s\": must be -header, or -separator", args[0])
+		}
+		if err != nil {
+			return "", err
+		}
+		args = args[2:]
+	}
+
+	if len(args) != 2 {
+		return "", fmt.Errorf("wrong # args: should be \"csv::write ?-separator char? ?-header list? channel rows\"")
+	}
+
+	ch, err := i.writeChannel(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := parseList(args[1])
+	if err != nil {
+		return "", err
+	}
+
+	records := make([][]string, 0, len(rows)+1)
+	if header != nil {
+		records = append(records, header)
+	}
+	for _, row := range rows {
+		if header == nil {
+			fields, err := parseList(row)
+			if err != nil {
+				return "", err
+			}
+			records = append(records, fields)
+			continue
+		}
+
+		d, err := parseDict(row)
+		if err != nil {
+			return "", err
+		}
+		fields := make([]string, len(header))
+		for n, name := range header {
+			fields[n], _ = d.get(name)
+		}
+		records = append(records, fields)
+	}
+
+	out, err := formatCSV(records, sep)
+	if err != nil {
+		return "", err
+	}
+	return "", ch.write(out)
+}
+
+// csvSeparator returns the separator given to a CSV command, which must
+// be a single character, or a comma if none was given.
+func csvSeparator(args []string) (rune, error) {
+	if len(args) == 0 {
+		return ',', nil
+	}
+
+	sep, size := utf8.DecodeRuneInString(args[0])
+	if size == 0 || size != len(args[0]) || sep == '"' || sep == '\r' || sep == '\n' || sep == utf8.RuneError {
+		return 0, fmt.Errorf("invalid separator \"%s\": must be a single character, other than a quote or newline", args[0])
+	}
+	return sep, nil
+}
+
+// newCSVReader creates a reader which accepts records with any number of
+// fields.
+func newCSVReader(r io.Reader, sep rune) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.Comma = sep
+	reader.FieldsPerRecord = -1
+	return reader
+}
+
+// lineReader supplies the input of a channel one line at a time, decoded
+// and translated as it is for `gets`, which ensures that a CSV reader
+// never reads beyond the end of the record it returns.
+//
+// The read-lock of the channel must be held while it is used.
+type lineReader struct {
+	ch      *channel
+	pending []byte
+}
+
+// Read reads the next line of input, or what remains of the current one.
+func (l *lineReader) Read(p []byte) (int, error) {
+	if len(l.pending) == 0 {
+		data, err := l.ch.readLineBytes()
+		if len(data) == 0 {
+			return 0, err
+		}
+		l.pending = []byte(l.ch.translateInput(l.ch.decode(data)))
+	}
+
+	n := copy(p, l.pending)
+	l.pending = l.pending[n:]
+	return n, nil
+}
+
+// formatCSV converts the records into CSV, each ending with a newline.
+func formatCSV(records [][]string, sep rune) (string, error) {
+	var out strings.Builder
+
+	w := csv.NewWriter(&out)
+	w.Comma = sep
+	if err := w.WriteAll(records); err != nil {
+		return "", csvError(err)
+	}
+	return out.String(), nil
+}
+
+// csvError describes a failure to parse, or generate, CSV.
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("invalid CSV on line %d: %s", parseErr.Line, parseErr.Err)
+	}
+	return fmt.Errorf("invalid CSV: %s", err)
+}
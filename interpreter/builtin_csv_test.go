@@ -0,0 +1,205 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCSV(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `csv::split {a,b,c}`, Output: "a b c"},
+		{Input: `csv::split {}`, Output: ""},
+		{Input: `csv::split {a,,c}`, Output: "a {} c"},
+		{Input: `csv::split {"a,b",c}`, Output: "a,b c"},
+		{Input: `csv::split {"say ""hi""",x}`, Output: `{say "hi"} x`},
+		{Input: "csv::split \"\\\"one\ntwo\\\",three\"", Output: "{one\ntwo} three"},
+		{Input: `csv::split {a;b,c} {;}`, Output: "a b,c"},
+		{Input: "csv::split \"a\\tb\" \"\\t\"", Output: "a b"},
+		{Input: `llength [csv::split {"a b",c}]`, Output: "2"},
+		{Input: `csv::join {a b c}`, Output: "a,b,c"},
+		{Input: `csv::join {}`, Output: ""},
+		{Input: `csv::join {{a,b} c}`, Output: `"a,b",c`},
+		{Input: `csv::join {{say "hi"} x}`, Output: `"say ""hi""",x`},
+		{Input: `csv::join {a b;c} {;}`, Output: `a;"b;c"`},
+		{Input: `csv::split [csv::join [list "one\ntwo" {"q"} a,b {}]]`, Output: "{one\ntwo} {\"q\"} a,b {}"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+}
+
+func TestCSVRead(t *testing.T) {
+
+	input := "name,age,notes\nBob,42,\"likes\ncheese\"\nAlice,37\n"
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `csv::read stdin`, Output: "name age notes"},
+		{Input: `csv::read stdin; csv::read stdin`, Output: "Bob 42 {likes\ncheese}"},
+		{Input: `csv::read stdin; csv::read stdin; csv::read stdin; csv::read stdin`, Output: ""},
+		{Input: `csv::read stdin; csv::read stdin; csv::read stdin; csv::read stdin; eof stdin`, Output: "1"},
+		{Input: `csv::read stdin row`, Output: "3"},
+		{Input: `csv::read stdin row; set row`, Output: "name age notes"},
+		{Input: `set n 0; while { expr [csv::read stdin row] >= 0 } { incr n }; set n`, Output: "3"},
+		{Input: `set h [csv::read stdin]; csv::read -header $h stdin`, Output: "name Bob age 42 notes {likes\ncheese}"},
+		{Input: `set h [csv::read stdin]; csv::read stdin; dict get [csv::read -header $h stdin] notes`, Output: ""},
+		{Input: `set h [csv::read stdin]; csv::read -header $h stdin row; dict get $row age`, Output: "42"},
+		{Input: `csv::read stdin; gets stdin`, Output: `Bob,42,"likes`},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input, WithStdin(strings.NewReader(input)))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+
+	// Separators
+	e, _ := New(`csv::read -separator {;} stdin`, WithStdin(strings.NewReader("a;b,c\n")))
+	out, err := e.Evaluate()
+	if err != nil || out != "a b,c" {
+		t.Fatalf("unexpected result %q %v", out, err)
+	}
+
+	// The channel's encoding and translation are honoured.
+	e, _ = New(`fconfigure stdin -encoding iso8859-1 -translation cr; list [csv::read stdin] [csv::read stdin]`,
+		WithStdin(strings.NewReader("caf\xe9,\"a\rb\"\rx,y\r")))
+	out, err = e.Evaluate()
+	if err != nil || out != "{caf\u00e9 {a\nb}} {x y}" {
+		t.Fatalf("unexpected result %q %v", out, err)
+	}
+}
+
+func TestCSVWrite(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `csv::write stdout {{a b c} {1 2 3}}`, Output: "a,b,c\n1,2,3\n"},
+		{Input: `csv::write stdout {}`, Output: ""},
+		{Input: `csv::write stdout [list [list "one\ntwo" {x,y}]]`, Output: "\"one\ntwo\",\"x,y\"\n"},
+		{Input: `csv::write -separator "\t" stdout {{a b} {c d}}`, Output: "a\tb\nc\td\n"},
+		{Input: `csv::write -header {name age} stdout [list [dict create age 42 name Bob] [dict create name Alice]]`, Output: "name,age\nBob,42\nAlice,\n"},
+	}
+
+	for _, test := range tests {
+
+		stdout := &strings.Builder{}
+		e, er := New(test.Input, WithStdout(stdout))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if stdout.String() != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, stdout.String(), test.Output)
+		}
+	}
+}
+
+// TestCSVFile tests writing, and then reading, a file.
+func TestCSVFile(t *testing.T) {
+
+	fsys := NewMemoryFileSystem()
+	e, er := New(`
+set f [open /report.csv w]
+csv::write -header {id title} $f [list [dict create id 1 title "First, and best"] [dict create id 2 title {Say "hi"}]]
+close $f
+
+set f [open /report.csv]
+set header [csv::read $f]
+set titles {}
+while { expr [csv::read -header $header $f row] >= 0 } {
+  append titles [dict get $row title] |
+}
+close $f
+set titles
+`, WithFileSystem(fsys))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	out, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != `First, and best|Say "hi"|` {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestCSVErrors(t *testing.T) {
+
+	tests := []string{
+		`csv::split`,
+		`csv::split a , b`,
+		`csv::split a {}`,
+		`csv::split a ,,`,
+		`csv::split a {"}`,
+		`csv::split {"unterminated}`,
+		`csv::split {a "b" c}`,
+		"csv::split \"a,b\\nc,d\"",
+		`csv::join`,
+		`csv::join "a {b"`,
+		`csv::read`,
+		`csv::read -separator`,
+		`csv::read -bogus x stdin`,
+		`csv::read stdout`,
+		`csv::read stdin a b`,
+		`csv::write stdout`,
+		`csv::write -bogus x stdout {}`,
+		`csv::write stdin {{a}}`,
+		`csv::write -header {a} stdout {{a}}`,
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test, WithStdin(strings.NewReader("")))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", test)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package interpreter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// dict is the golang implementation of the TCL `dict` function, which
+// manipulates dictionaries.
+//
+//	dict create ?key value ...?
+//	dict exists dictionary key ?key ...?
+//	dict get dictionary ?key ...?
+//	dict keys dictionary
+//	dict values dictionary
+//	dict size dictionary
+//	dict set varName key ?key ...? value
+//	dict unset varName key ?key ...?
+//
+// A dictionary is a list of alternating keys and values, and the keys
+// retain the order in which they were added.  Given several keys each
+// refers to a dictionary nested within the value of the previous one.
+func dict(i *Interpreter, args []string) (string, error) {
+
+	if len(args) < 1 {
+		return "", fmt.Errorf("wrong # args: should be \"dict subcommand ?arg ...?\"")
+	}
+
+	sub := args[0]
+	args = args[1:]
+
+	switch sub {
+	case "create":
+		d, err := parseDict(formatList(args))
+		if err != nil {
+			return "", err
+		}
+		return d.String(), nil
+
+	case "exists":
+		if len(args) < 2 {
+			return "", fmt.Errorf("wrong # args: should be \"dict exists dictionary key ?key ...?\"")
+		}
+		out := args[0]
+		for _, key := range args[1:] {
+			d, err := parseDict(out)
+			if err != nil {
+				return "0", nil
+			}
+			val, ok := d.get(key)
+			if !ok {
+				return "0", nil
+			}
+			out = val
+		}
+		return "1", nil
+
+	case "get":
+		if len(args) < 1 {
+			return "", fmt.Errorf("wrong # args: should be \"dict get dictionary ?key ...?\"")
+		}
+		out := args[0]
+		for _, key := range args[1:] {
+			d, err := parseDict(out)
+			if err != nil {
+				return "", err
+			}
+			val, ok := d.get(key)
+			if !ok {
+				return "", fmt.Errorf("key \"%s\" not known in dictionary", key)
+			}
+			out = val
+		}
+		if len(args) == 1 {
+			d, err := parseDict(out)
+			if err != nil {
+				return "", err
+			}
+			return d.String(), nil
+		}
+		return out, nil
+
+	case "keys", "values", "size":
+		if len(args) != 1 {
+			return "", fmt.Errorf("wrong # args: should be \"dict %s dictionary\"", sub)
+		}
+		d, err := parseDict(args[0])
+		if err != nil {
+			return "", err
+		}
+		switch sub {
+		case "keys":
+			return formatList(d.keys), nil
+		case "values":
+			return formatList(d.values), nil
+		}
+		return strconv.Itoa(len(d.keys)), nil
+
+	case "set", "unset":
+		min := 3
+		usage := "dict set varName key ?key ...? value"
+		if sub == "unset" {
+			min = 2
+			usage = "dict unset varName key ?key ...?"
+		}
+		if len(args) < min {
+			return "", fmt.Errorf("wrong # args: should be \"%s\"", usage)
+		}
+
+		cur, _ := i.environment.Get(args[0])
+		var out string
+		var err error
+		if sub == "set" {
+			out, err = dictSet(cur, args[1:len(args)-1], args[len(args)-1])
+		} else {
+			out, err = dictUnset(cur, args[1:])
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := i.setVar(args[0], out); err != nil {
+			return "", err
+		}
+		return out, nil
+	}
+
+	return "", fmt.Errorf("unknown or ambiguous subcommand \"%s\": must be create, exists, get, keys, set, size, unset, or values", sub)
+}
+
+// dictionary is a parsed TCL dictionary.
+type dictionary struct {
+	keys   []string
+	values []string
+}
+
+// parseDict parses a TCL dictionary.  If a key is repeated the last value
+// is used, but the key retains its first position.
+func parseDict(str string) (*dictionary, error) {
+	elements, err := parseList(str)
+	if err != nil {
+		return nil, err
+	}
+	if len(elements)%2 != 0 {
+		return nil, fmt.Errorf("missing value to go with key")
+	}
+
+	d := &dictionary{}
+	for n := 0; n < len(elements); n += 2 {
+		d.set(elements[n], elements[n+1])
+	}
+	return d, nil
+}
+
+// get returns the value of the given key.
+func (d *dictionary) get(key string) (string, bool) {
+	for n, k := range d.keys {
+		if k == key {
+			return d.values[n], true
+		}
+	}
+	return "", false
+}
+
+// set changes the value of the given key, adding it if it is missing.
+func (d *dictionary) set(key string, value string) {
+	for n, k := range d.keys {
+		if k == key {
+			d.values[n] = value
+			return
+		}
+	}
+	d.keys = append(d.keys, key)
+	d.values = append(d.values, value)
+}
+
+// remove removes the given key, if it is present.
+func (d *dictionary) remove(key string) {
+	for n, k := range d.keys {
+		if k == key {
+			d.keys = append(d.keys[:n], d.keys[n+1:]...)
+			d.values = append(d.values[:n], d.values[n+1:]...)
+			return
+		}
+	}
+}
+
+// String returns the dictionary as a TCL list.
+func (d *dictionary) String() string {
+	elements := make([]string, 0, len(d.keys)*2)
+	for n, k := range d.keys {
+		elements = append(elements, k, d.values[n])
+	}
+	return formatList(elements)
+}
+
+// dictSet sets the value of a key, which may be within nested
+// dictionaries, and returns the updated dictionary.
+func dictSet(str string, keys []string, value string) (string, error) {
+	d, err := parseDict(str)
+	if err != nil {
+		return "", err
+	}
+
+	if len(keys) > 1 {
+		inner, _ := d.get(keys[0])
+		value, err = dictSet(inner, keys[1:], value)
+		if err != nil {
+			return "", err
+		}
+	}
+	d.set(keys[0], value)
+	return d.String(), nil
+}
+
+// dictUnset removes a key, which may be within nested dictionaries, and
+// returns the updated dictionary.
+func dictUnset(str string, keys []string) (string, error) {
+	d, err := parseDict(str)
+	if err != nil {
+		return "", err
+	}
+
+	if len(keys) == 1 {
+		d.remove(keys[0])
+		return d.String(), nil
+	}
+
+	inner, ok := d.get(keys[0])
+	if !ok {
+		return "", fmt.Errorf("key \"%s\" not known in dictionary", keys[0])
+	}
+	inner, err = dictUnset(inner, keys[1:])
+	if err != nil {
+		return "", err
+	}
+	d.set(keys[0], inner)
+	return d.String(), nil
+}
+
+// sorted returns a copy of the dictionary, with the keys sorted.
+func (d *dictionary) sorted() *dictionary {
+	keys := append([]string{}, d.keys...)
+	sort.Strings(keys)
+
+	out := &dictionary{}
+	for _, key := range keys {
+		val, _ := d.get(key)
+		out.set(key, val)
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package interpreter
+
+import "testing"
+
+func TestDict(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `dict create`, Output: ""},
+		{Input: `dict create b 2 a 1`, Output: "b 2 a 1"},
+		{Input: `dict create a 1 a 2`, Output: "a 2"},
+		{Input: `dict get {a 1 b 2} b`, Output: "2"},
+		{Input: `dict get {a 1 b {c 3}} b c`, Output: "3"},
+		{Input: `dict get {a 1 a 2}`, Output: "a 2"},
+		{Input: `dict exists {a 1} a`, Output: "1"},
+		{Input: `dict exists {a 1} b`, Output: "0"},
+		{Input: `dict exists {a {b 2}} a b`, Output: "1"},
+		{Input: `dict exists {a 1} a b`, Output: "0"},
+		{Input: `dict keys {a 1 b 2}`, Output: "a b"},
+		{Input: `dict values {a 1 b {2 3}}`, Output: "1 {2 3}"},
+		{Input: `dict size {a 1 b 2}`, Output: "2"},
+		{Input: `dict set d a 1; dict set d b 2; set d`, Output: "a 1 b 2"},
+		{Input: `set d {a 1}; dict set d a 3`, Output: "a 3"},
+		{Input: `dict set d a b c 1; dict get $d a b c`, Output: "1"},
+		{Input: `set d {a 1 b 2}; dict unset d a`, Output: "b 2"},
+		{Input: `set d {a {b 1 c 2}}; dict unset d a b`, Output: "a {c 2}"},
+		{Input: `set d {a 1}; dict unset d z`, Output: "a 1"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+}
+
+func TestDictErrors(t *testing.T) {
+
+	tests := []string{
+		`dict`,
+		`dict bogus`,
+		`dict create a`,
+		`dict get {a 1 b}`,
+		`dict get {a 1} b`,
+		`dict get {a 1} a b`,
+		`dict exists {a 1}`,
+		`dict keys`,
+		`dict size {a}`,
+		`dict set d a`,
+		`dict unset d`,
+		`set d {a 1}; dict unset d b c`,
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", test)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package interpreter
+
+import "fmt"
+
+// eof is the golang implementation of the TCL `eof` function, which
+// returns 1 if the last read from a channel reached the end of the input.
+//
+//	eof channel
+func eof(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("eof only accepts one argument, got %d", len(args))
+	}
+
+	ch, err := i.channel(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	if ch.eof {
+		return "1", nil
+	}
+	return "0", nil
+}
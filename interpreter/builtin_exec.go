@@ -0,0 +1,480 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ChildStatusError is returned by `exec` when a child process exits with
+// a non-zero status.  Scripts which catch the error find the status in
+// the `errorCode` variable, as "CHILDSTATUS pid code".
+type ChildStatusError struct {
+
+	// Pid is the process ID of the child.
+	Pid int
+
+	// Code is the status the child exited with, or -1 if it was
+	// terminated by a signal.
+	Code int
+
+	// Message holds the output of the pipeline, followed by a
+	// description of the failure.
+	Message string
+}
+
+// Error returns the output of the pipeline, and a description of the
+// failure.
+func (e *ChildStatusError) Error() string {
+	return e.Message
+}
+
+// ErrorCode returns the TCL error-code describing the failure.
+func (e *ChildStatusError) ErrorCode() string {
+	return fmt.Sprintf("CHILDSTATUS %d %d", e.Pid, e.Code)
+}
+
+// redirections are the operators which redirect the input or output of
+// a pipeline, longest first so that they're matched correctly when the
+// file name is attached to them.
+var redirections = []string{"2>@1", "2>>", ">>&", "<<", ">>", "2>", ">&", "<", ">"}
+
+// pipeline is a parsed `exec` command.
+type pipeline struct {
+
+	// commands holds the arguments of each command in the pipeline.
+	commands [][]string
+
+	// stderrPiped records, for each command, whether its stderr is
+	// piped to the next command along with its stdout.
+	stderrPiped []bool
+
+	// input is the input given via "<<", and inputFile the file
+	// named via "<".
+	input     *string
+	inputFile string
+
+	// outputFile, and errorFile, are the files named via ">",
+	// and "2>", along with whether they should be appended to.
+	outputFile   string
+	outputAppend bool
+	errorFile    string
+	errorAppend  bool
+
+	// errorToOutput is set by "2>@1", and by ">&".
+	errorToOutput bool
+
+	// background is set if the pipeline ended with "&".
+	background bool
+}
+
+// execFn is the golang implementation of the TCL `exec` function, which
+// runs a pipeline of subprocesses.
+//
+//	exec ?-ignorestderr? ?-keepnewline? ?--? arg ?arg ...?
+//
+// Commands are separated by "|", or by "|&" to pipe both stdout and
+// stderr to the next.  Input may be redirected from a file with "< file",
+// or given as a value via "<< value".  Output may be redirected via
+// "> file", ">> file", "2> file", "2>> file", "2>@1", and ">& file".
+//
+// The output of the pipeline is returned, without its trailing newline
+// unless -keepnewline is given.  It is an error if any process exits with
+// a non-zero status, or if anything is written to stderr and
+// -ignorestderr isn't given.
+//
+// If the last argument is "&" the pipeline runs in the background, with
+// its output going to our stdout and stderr, and the process IDs are
+// returned.  Background processes are not stopped if the evaluation is
+// cancelled, but any which are still running are killed by Close.
+func execFn(i *Interpreter, args []string) (string, error) {
+
+	ignoreStderr := false
+	keepNewline := false
+
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		opt := args[0]
+		args = args[1:]
+
+		if opt == "--" {
+			break
+		}
+		switch opt {
+		case "-ignorestderr":
+			ignoreStderr = true
+		case "-keepnewline":
+			keepNewline = true
+		default:
+			return "", fmt.Errorf("bad option \"%s\": must be -ignorestderr, -keepnewline, or --", opt)
+		}
+	}
+
+	if len(args) < 1 {
+		return "", fmt.Errorf("wrong # args: should be \"exec ?-option ...? arg ?arg ...?\"")
+	}
+
+	p, err := parsePipeline(args)
+	if err != nil {
+		return "", err
+	}
+
+	return i.runPipeline(p, ignoreStderr, keepNewline)
+}
+
+// parsePipeline splits the arguments given to `exec` into commands and
+// redirections.
+func parsePipeline(args []string) (*pipeline, error) {
+
+	p := &pipeline{}
+
+	if args[len(args)-1] == "&" {
+		p.background = true
+		args = args[:len(args)-1]
+	}
+
+	current := []string{}
+	for n := 0; n < len(args); n++ {
+		arg := args[n]
+
+		if arg == "|" || arg == "|&" {
+			if len(current) == 0 {
+				return nil, fmt.Errorf("illegal use of | or |& in command")
+			}
+			p.commands = append(p.commands, current)
+			p.stderrPiped = append(p.stderrPiped, arg == "|&")
+			current = []string{}
+			continue
+		}
+
+		// Is this a redirection?
+		op := ""
+		for _, r := range redirections {
+			if strings.HasPrefix(arg, r) {
+				op = r
+				break
+			}
+		}
+		if op == "" {
+			current = append(current, arg)
+			continue
+		}
+
+		if op == "2>@1" {
+			if arg != op {
+				return nil, fmt.Errorf("can't specify \"%s\" as last word in command", arg)
+			}
+			p.errorToOutput = true
+			continue
+		}
+
+		// The target may be attached to the operator, or be the
+		// next argument.
+		target := strings.TrimPrefix(arg, op)
+		if target == "" {
+			if n+1 >= len(args) {
+				return nil, fmt.Errorf("can't specify \"%s\" as last word in command", op)
+			}
+			n++
+			target = args[n]
+		}
+
+		switch op {
+		case "<":
+			p.inputFile = target
+		case "<<":
+			value := target
+			p.input = &value
+		case ">", ">>":
+			p.outputFile = target
+			p.outputAppend = op == ">>"
+		case "2>", "2>>":
+			p.errorFile = target
+			p.errorAppend = op == "2>>"
+		case ">&", ">>&":
+			p.outputFile = target
+			p.outputAppend = op == ">>&"
+			p.errorToOutput = true
+		}
+	}
+
+	if len(current) == 0 {
+		return nil, fmt.Errorf("illegal use of | or |& in command")
+	}
+	p.commands = append(p.commands, current)
+	p.stderrPiped = append(p.stderrPiped, false)
+	return p, nil
+}
+
+// runPipeline executes a parsed pipeline.
+func (i *Interpreter) runPipeline(p *pipeline, ignoreStderr bool, keepNewline bool) (string, error) {
+
+	// The pipes which connect the commands are closed once they've
+	// started, and any files we open once they've finished.
+	var pipes, files []io.Closer
+	closeAll := func(closers []io.Closer) {
+		for _, c := range closers {
+			c.Close()
+		}
+	}
+	defer func() {
+		closeAll(pipes)
+		closeAll(files)
+	}()
+
+	openFile := func(name string, flag int) (File, error) {
+		f, err := i.filesystem.OpenFile(name, flag, 0666)
+		if err != nil {
+			return nil, fileError("couldn't open", name, err)
+		}
+		files = append(files, f)
+		return f, nil
+	}
+
+	// Create the commands.
+	cmds := make([]*exec.Cmd, len(p.commands))
+	for n, args := range p.commands {
+		if p.background {
+			cmds[n] = exec.Command(args[0], args[1:]...)
+		} else {
+			cmds[n] = exec.CommandContext(i.ctx, args[0], args[1:]...)
+		}
+	}
+	first := cmds[0]
+	last := cmds[len(cmds)-1]
+
+	// Where does the input come from?
+	if p.input != nil {
+		first.Stdin = strings.NewReader(*p.input)
+	} else if p.inputFile != "" {
+		f, err := openFile(p.inputFile, os.O_RDONLY)
+		if err != nil {
+			return "", err
+		}
+		first.Stdin = f
+	}
+
+	// Where does the output go?
+	var stdout, stderr bytes.Buffer
+	var output io.Writer = &stdout
+	if p.background {
+		output = i.stdout
+	}
+	if p.outputFile != "" {
+		flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if p.outputAppend {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		f, err := openFile(p.outputFile, flag)
+		if err != nil {
+			return "", err
+		}
+		output = f
+	}
+	last.Stdout = output
+
+	// Where do errors go?  Unless they're redirected they are
+	// collected from every command in the pipeline.
+	var errorOutput io.Writer = &lockedWriter{w: &stderr}
+	if p.background || ignoreStderr {
+		errorOutput = i.stderr
+	}
+	if p.errorFile != "" {
+		flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if p.errorAppend {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		f, err := openFile(p.errorFile, flag)
+		if err != nil {
+			return "", err
+		}
+		errorOutput = f
+	}
+	for _, cmd := range cmds {
+		cmd.Stderr = errorOutput
+	}
+	if p.errorToOutput {
+		last.Stderr = output
+	}
+
+	// Connect the commands to each other.
+	for n := 0; n < len(cmds)-1; n++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return "", err
+		}
+		pipes = append(pipes, r, w)
+		cmds[n].Stdout = w
+		if p.stderrPiped[n] {
+			cmds[n].Stderr = w
+		}
+		cmds[n+1].Stdin = r
+	}
+
+	// Start them all.
+	for n, cmd := range cmds {
+		if err := cmd.Start(); err != nil {
+			for _, started := range cmds[:n] {
+				started.Process.Kill()
+				started.Wait()
+			}
+			return "", fmt.Errorf("couldn't execute \"%s\": %s", p.commands[n][0], execError(err))
+		}
+	}
+
+	// Our copies of the pipes must be closed so that the commands
+	// see the end of their input.
+	closeAll(pipes)
+	pipes = nil
+
+	pids := make([]string, len(cmds))
+	for n, cmd := range cmds {
+		pids[n] = strconv.Itoa(cmd.Process.Pid)
+	}
+
+	// Background commands are waited for, so that they don't become
+	// zombies, and their files closed, when they finish.
+	if p.background {
+		if i.jobs == nil {
+			i.jobs = &backgroundJobs{cmds: make(map[*exec.Cmd]bool)}
+		}
+		jobs := i.jobs
+		jobs.add(cmds)
+
+		go func(files []io.Closer) {
+			for _, cmd := range cmds {
+				cmd.Wait()
+				jobs.done(cmd)
+			}
+			closeAll(files)
+		}(files)
+		files = nil
+		return formatList(pids), nil
+	}
+
+	// Wait for them all to finish, remembering the last failure.
+	var failed *exec.Cmd
+	var waitErr error
+	for _, cmd := range cmds {
+		err := cmd.Wait()
+
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) && waitErr == nil {
+			waitErr = err
+		}
+		if !cmd.ProcessState.Success() {
+			failed = cmd
+		}
+	}
+
+	// The commands are killed if we're cancelled.
+	if err := i.cancelled(); err != nil {
+		return "", err
+	}
+	if waitErr != nil {
+		return "", waitErr
+	}
+
+	out := stdout.String()
+	if !keepNewline {
+		out = strings.TrimSuffix(out, "\n")
+	}
+
+	// Anything written to stderr is an error.
+	msg := out
+	if stderr.Len() > 0 {
+		if msg != "" {
+			msg += "\n"
+		}
+		msg += strings.TrimSuffix(stderr.String(), "\n")
+	}
+
+	if failed != nil {
+		if stderr.Len() == 0 {
+			if msg != "" {
+				msg += "\n"
+			}
+			msg += "child process exited abnormally"
+		}
+		return "", &ChildStatusError{
+			Pid:     failed.Process.Pid,
+			Code:    failed.ProcessState.ExitCode(),
+			Message: msg,
+		}
+	}
+	if stderr.Len() > 0 {
+		return "", fmt.Errorf("%s", msg)
+	}
+	return out, nil
+}
+
+// execError describes the reason a command couldn't be executed.
+func execError(err error) string {
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+		return "no such file or directory"
+	}
+	if errors.Is(err, os.ErrPermission) {
+		return "permission denied"
+	}
+	return err.Error()
+}
+
+// backgroundJobs records the processes which are running in the
+// background, so that they may be killed by Close.
+type backgroundJobs struct {
+	mutex sync.Mutex
+	cmds  map[*exec.Cmd]bool
+}
+
+// add records that the given processes have started.
+func (b *backgroundJobs) add(cmds []*exec.Cmd) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, cmd := range cmds {
+		b.cmds[cmd] = true
+	}
+}
+
+// done records that the given process has finished.
+func (b *backgroundJobs) done(cmd *exec.Cmd) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.cmds, cmd)
+}
+
+// kill kills each of the processes which are still running.
+func (b *backgroundJobs) kill() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for cmd := range b.cmds {
+		cmd.Process.Kill()
+	}
+}
+
+// lockedWriter serializes writes, since the commands of a pipeline may
+// write to it at the same time.
+type lockedWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+// lockWriter wraps a writer so that its writes are serialized, unless it
+// has been already.
+func lockWriter(w io.Writer) io.Writer {
+	if _, ok := w.(*lockedWriter); ok || w == nil {
+		return w
+	}
+	return &lockedWriter{w: w}
+}
+
+// Write writes to the underlying writer.
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,293 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// requireShell skips the test if the standard tools we run are missing.
+func requireShell(t *testing.T) {
+	for _, name := range []string{"sh", "cat", "tr", "echo"} {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%s is not available", name)
+		}
+	}
+}
+
+func TestExec(t *testing.T) {
+
+	requireShell(t)
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(input, []byte("from a file\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `exec echo hello world`, Output: "hello world"},
+		{Input: `exec -keepnewline echo hello`, Output: "hello\n"},
+		{Input: `exec -- echo -n hello`, Output: "hello"},
+		{Input: `exec echo hello | tr a-z A-Z`, Output: "HELLO"},
+		{Input: `exec echo one | cat | cat | tr o 0`, Output: "0ne"},
+		{Input: `exec cat << "some input"`, Output: "some input"},
+		{Input: `exec cat <<input`, Output: "input"},
+		{Input: `exec cat < {` + input + `}`, Output: "from a file"},
+		{Input: `exec tr a-z A-Z <` + input, Output: "FROM A FILE"},
+		{Input: `exec echo saved > {` + filepath.Join(dir, "out.txt") + `}; exec cat {` + filepath.Join(dir, "out.txt") + `}`, Output: "saved"},
+		{Input: `exec echo one > {` + filepath.Join(dir, "app.txt") + `}; exec echo two >> {` + filepath.Join(dir, "app.txt") + `}; exec cat {` + filepath.Join(dir, "app.txt") + `}`, Output: "one\ntwo"},
+		{Input: `exec sh -c "echo oops >&2" 2> {` + filepath.Join(dir, "err.txt") + `}; exec cat {` + filepath.Join(dir, "err.txt") + `}`, Output: "oops"},
+		{Input: `exec sh -c "echo out; echo err >&2" 2>@1`, Output: "out\nerr"},
+		{Input: `exec sh -c "echo err >&2" |& tr a-z A-Z`, Output: "ERR"},
+		{Input: `exec -ignorestderr sh -c "echo out; echo err >&2"`, Output: "out"},
+
+		// Errors may be caught
+		{Input: `catch { exec sh -c "exit 3" }`, Output: "1"},
+		{Input: `catch { exec sh -c "exit 3" } msg; set msg`, Output: "child process exited abnormally"},
+		{Input: `catch { exec sh -c "echo output; exit 3" } msg; set msg`, Output: "output\nchild process exited abnormally"},
+		{Input: `catch { exec sh -c "echo failed >&2; exit 3" } msg; set msg`, Output: "failed"},
+		{Input: `catch { exec sh -c "echo warning >&2" } msg; set msg`, Output: "warning"},
+		{Input: `catch { exec sh -c "echo warning >&2" }; set errorCode`, Output: "NONE"},
+		{Input: `catch { exec sh -c "exit 3" | cat }`, Output: "1"},
+		{Input: `catch { exec sh -c "exit 0" }`, Output: "0"},
+	}
+
+	for _, test := range tests {
+
+		var stderr bytes.Buffer
+		e, er := New(test.Input, WithStderr(&stderr))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+
+	// Errors
+	errs := map[string]string{
+		`exec`:                            `wrong # args`,
+		`exec -bogus echo`:                `bad option "-bogus"`,
+		`exec echo |`:                     `illegal use of | or |& in command`,
+		`exec | echo`:                     `illegal use of | or |& in command`,
+		`exec echo >`:                     `can't specify ">" as last word in command`,
+		`exec echo 2>@1x`:                 `can't specify "2>@1x"`,
+		`exec /no/such/command`:           `couldn't execute "/no/such/command": no such file or directory`,
+		`exec echo hi | /no/such/command`: `couldn't execute "/no/such/command"`,
+		`exec cat < /no/such/file`:        `couldn't open "/no/such/file": no such file or directory`,
+	}
+	for input, expected := range errs {
+		e, er := New(input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("wrong error running %s: %s", input, err)
+		}
+	}
+}
+
+// TestExecStatus tests the status of a failed process is available.
+func TestExecStatus(t *testing.T) {
+
+	requireShell(t)
+
+	e, _ := New(`catch { exec sh -c "exit 7" }; set errorCode`)
+	out, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 3 || fields[0] != "CHILDSTATUS" || fields[2] != "7" {
+		t.Fatalf("unexpected error code: %s", out)
+	}
+
+	// The host sees the same.
+	e, _ = New(`exec sh -c "exit 7"`)
+	_, err = e.Evaluate()
+	var status *ChildStatusError
+	if !errors.As(err, &status) {
+		t.Fatalf("expected status error, got %v", err)
+	}
+	if status.Code != 7 || status.Pid == 0 || !strings.HasSuffix(status.ErrorCode(), " 7") {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
+
+// TestExecBackground tests running a pipeline in the background.
+func TestExecBackground(t *testing.T) {
+
+	requireShell(t)
+
+	stdout := &syncBuffer{}
+	e, _ := New(`exec echo one | tr a-z A-Z &`, WithStdout(stdout))
+	out, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	pids, err := parseList(out)
+	if err != nil || len(pids) != 2 {
+		t.Fatalf("expected two process IDs, got %s", out)
+	}
+
+	// Wait for the output.
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if strings.Contains(stdout.String(), "ONE") {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("background output never arrived")
+}
+
+// waitForJobs waits for the processes an interpreter started in the
+// background to finish.
+func waitForJobs(t *testing.T, jobs *backgroundJobs) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		jobs.mutex.Lock()
+		running := len(jobs.cmds)
+		jobs.mutex.Unlock()
+
+		if running == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("background processes are still running")
+}
+
+// TestExecBackgroundOutput tests that the output of background processes
+// doesn't race with our own.
+func TestExecBackgroundOutput(t *testing.T) {
+
+	requireShell(t)
+
+	stdout := &bytes.Buffer{}
+	e, _ := New(`exec sh -c {for n in 1 2 3 4 5; do echo bg; done} &
+for {set n 0} { expr $n < 100 } {incr n} { puts fg }`, WithStdout(stdout))
+	_, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	waitForJobs(t, e.jobs)
+
+	out := stdout.String()
+	if strings.Count(out, "bg\n") != 5 || strings.Count(out, "fg\n") != 100 {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
+
+// TestExecBackgroundClose tests that Close kills background processes.
+func TestExecBackgroundClose(t *testing.T) {
+
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep is not available")
+	}
+
+	e, _ := New(`exec sleep 30 &`)
+	_, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	e.Close()
+	waitForJobs(t, e.jobs)
+}
+
+// TestExecBackgroundPool tests that returning an interpreter to a pool
+// kills the processes it started in the background.
+func TestExecBackgroundPool(t *testing.T) {
+
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep is not available")
+	}
+
+	template, _ := New(``)
+	pool := NewPool(template)
+
+	e := pool.Get()
+	_, err := e.Eval(`exec sleep 30 &`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	jobs := e.jobs
+	pool.Put(e)
+	waitForJobs(t, jobs)
+}
+
+// TestExecCancelled tests a timeout kills the processes we've started.
+func TestExecCancelled(t *testing.T) {
+
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep is not available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	e, _ := New(`catch { exec sleep 10 }`)
+	start := time.Now()
+	_, err := e.EvaluateContext(ctx)
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("process wasn't killed")
+	}
+}
+
+func TestExecSafe(t *testing.T) {
+
+	e, _ := New(`exec echo hello`, WithSafe())
+	_, err := e.Evaluate()
+	if !errors.Is(err, ErrHidden) {
+		t.Fatalf("expected hidden error, got %v", err)
+	}
+}
+
+// syncBuffer is a buffer which may be written to by several goroutines.
+type syncBuffer struct {
+	mutex sync.Mutex
+	b     bytes.Buffer
+}
+
+// Write writes to the buffer.
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.b.Write(p)
+}
+
+// String returns the contents of the buffer.
+func (s *syncBuffer) String() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.b.String()
+}
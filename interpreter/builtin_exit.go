@@ -3,6 +3,7 @@ package interpreter
 import (
 	"errors"
 	"fmt"
+	"strconv"
 )
 
 var (
@@ -12,11 +13,24 @@ var (
 )
 
 // exitFn is the golang implementation of the TCL `exit` function.
+//
+// The code defaults to zero, and must be one a process may exit with,
+// between 0 and 255, since larger codes would be truncated.
 func exitFn(i *Interpreter, args []string) (string, error) {
 
-	// Ensure we have only one argument
-	if len(args) != 1 {
-		return "", fmt.Errorf("exit only accepts one argument, got %d", len(args))
+	if len(args) > 1 {
+		return "", fmt.Errorf("wrong # args: should be \"exit ?returnCode?\"")
+	}
+	if len(args) == 0 {
+		return "0", ErrExit
+	}
+
+	code, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "", fmt.Errorf("expected integer but got \"%s\"", args[0])
+	}
+	if code < 0 || code > 255 {
+		return "", fmt.Errorf("exit code %d is out of range, it must be between 0 and 255", code)
 	}
 
 	return args[0], ErrExit
@@ -1,6 +1,7 @@
 package interpreter
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -16,7 +17,7 @@ set i 0
 
 while { expr $i < 10  } {
    set sum [incr sum $i ]
-   exit 321
+   exit 121
    incr i
 }
 puts $sum
@@ -64,7 +65,7 @@ foo 17
 	if err != ErrExit {
 		t.Fatalf("unexpected error running code:%s", err)
 	}
-	if out != "321" {
+	if out != "121" {
 		t.Fatalf("exit value didn't match - got '%s'", out)
 	}
 
@@ -86,4 +87,43 @@ foo 17
 	if out != "43" {
 		t.Fatalf("exit value didn't match - got '%s'", out)
 	}
+
+	// exit without a code
+	e, er = New(`exit`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	out, err = e.Evaluate()
+	if err != ErrExit {
+		t.Fatalf("unexpected error running code:%s", err)
+	}
+	if out != "0" {
+		t.Fatalf("exit value didn't match - got '%s'", out)
+	}
+}
+
+func TestExitInvalid(t *testing.T) {
+
+	tests := []struct {
+		input string
+		error string
+	}{
+		{`exit 256`, `exit code 256 is out of range`},
+		{`exit -256`, `exit code -256 is out of range`},
+		{`exit -1`, `exit code -1 is out of range`},
+		{`exit steve`, `expected integer but got "steve"`},
+	}
+
+	for _, test := range tests {
+		e, er := New(test.input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil || !strings.Contains(err.Error(), test.error) {
+			t.Fatalf("expected error '%s' for %s, got %v", test.error, test.input, err)
+		}
+	}
 }
@@ -0,0 +1,145 @@
+package interpreter
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// fconfigureOptions are the options `fconfigure` supports, and the values
+// each may be set to.
+var fconfigureOptions = map[string][]string{
+	"-buffering":   {"full", "line", "none"},
+	"-encoding":    {"utf-8", "binary", "iso8859-1"},
+	"-translation": {"auto", "binary", "cr", "crlf", "lf"},
+}
+
+// fconfigure is the golang implementation of the TCL `fconfigure` function,
+// which queries or changes the options of a channel.
+//
+//	fconfigure channel
+//	fconfigure channel name
+//	fconfigure channel name value ?name value ...?
+//
+// Sockets also have the read-only options -peername and -sockname.
+func fconfigure(i *Interpreter, args []string) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("wrong # args: should be \"fconfigure channel ?-option value ...?\"")
+	}
+
+	ch, err := i.channel(args[0])
+	if err != nil {
+		return "", err
+	}
+	args = args[1:]
+
+	// Return all the options
+	if len(args) == 0 {
+		all := []string{
+			"-buffering", ch.buffering,
+			"-encoding", ch.encoding,
+			"-translation", ch.translation,
+		}
+		if ch.remote != nil {
+			all = append(all, "-peername", addrList(ch.remote))
+		}
+		if ch.local != nil {
+			all = append(all, "-sockname", addrList(ch.local))
+		}
+		return formatList(all), nil
+	}
+
+	// Return a single option
+	if len(args) == 1 {
+		val, err := ch.option(args[0])
+		if err != nil {
+			return "", err
+		}
+		return val, nil
+	}
+
+	if len(args)%2 != 0 {
+		return "", fmt.Errorf("missing value for option \"%s\"", args[len(args)-1])
+	}
+
+	for n := 0; n < len(args); n += 2 {
+		err := ch.setOption(args[n], args[n+1])
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// option returns the value of the given option.
+func (c *channel) option(name string) (string, error) {
+	switch name {
+	case "-buffering":
+		return c.buffering, nil
+	case "-encoding":
+		return c.encoding, nil
+	case "-translation":
+		return c.translation, nil
+	case "-peername":
+		if c.remote != nil {
+			return addrList(c.remote), nil
+		}
+	case "-sockname":
+		if c.local != nil {
+			return addrList(c.local), nil
+		}
+	}
+	return "", fmt.Errorf("bad option \"%s\": should be one of -buffering, -encoding, or -translation", name)
+}
+
+// addrList returns the address of a socket as a list of its address, host
+// name, and port, as reported by `fconfigure`.  We don't look up the name
+// of the host, so the address is used for both.
+func addrList(addr net.Addr) string {
+	host, port := splitAddr(addr)
+	return formatList([]string{host, host, port})
+}
+
+// setOption changes the value of the given option.
+func (c *channel) setOption(name string, value string) error {
+
+	valid, ok := fconfigureOptions[name]
+	if !ok {
+		if _, err := c.option(name); err != nil {
+			return err
+		}
+		return fmt.Errorf("option \"%s\" is read-only", name)
+	}
+
+	found := false
+	for _, v := range valid {
+		if v == value {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("bad value for %s: must be one of %s", name, strings.Join(valid, ", "))
+	}
+
+	switch name {
+	case "-buffering":
+		c.buffering = value
+		if value == "none" {
+			return c.flush()
+		}
+	case "-encoding":
+		c.encoding = value
+		if value == "iso8859-1" {
+			c.encoding = "binary"
+		}
+	case "-translation":
+		c.translation = value
+
+		// Binary translation implies binary encoding.
+		if value == "binary" {
+			c.translation = "lf"
+			c.encoding = "binary"
+		}
+	}
+	return nil
+}
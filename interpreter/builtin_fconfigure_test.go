@@ -0,0 +1,64 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFconfigure(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `fconfigure stdout`, Output: "-buffering none -encoding utf-8 -translation auto"},
+		{Input: `fconfigure stdout -buffering`, Output: "none"},
+		{Input: `fconfigure stdout -buffering line; fconfigure stdout -buffering`, Output: "line"},
+		{Input: `fconfigure stdout -translation crlf -encoding binary; fconfigure stdout`, Output: "-buffering none -encoding binary -translation crlf"},
+		{Input: `fconfigure stdout -encoding iso8859-1; fconfigure stdout -encoding`, Output: "binary"},
+		{Input: `fconfigure stdin -translation binary; fconfigure stdin`, Output: "-buffering none -encoding binary -translation lf"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+
+	// Errors
+	errs := map[string]string{
+		`fconfigure missing`:                          `can not find channel named "missing"`,
+		`fconfigure stdout -blocking`:                 `bad option "-blocking"`,
+		`fconfigure stdout -blocking 1`:               `bad option "-blocking"`,
+		`fconfigure stdout -buffering sometimes`:      `bad value for -buffering`,
+		`fconfigure stdout -encoding ebcdic`:          `bad value for -encoding`,
+		`fconfigure stdout -translation dos`:          `bad value for -translation`,
+		`fconfigure stdout -translation lf -encoding`: `missing value for option "-encoding"`,
+	}
+	for input, expected := range errs {
+		e, er := New(input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("wrong error running %s: %s", input, err)
+		}
+	}
+}
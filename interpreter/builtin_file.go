@@ -0,0 +1,442 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// file is the golang implementation of the TCL `file` function, which
+// manipulates file names, and the files themselves.
+//
+//	file exists name
+//	file isfile name
+//	file isdirectory name
+//	file size name
+//	file mtime name
+//	file join name ?name ...?
+//	file split name
+//	file dirname name
+//	file tail name
+//	file extension name
+//	file rootname name
+//	file normalize name
+//	file mkdir ?dir ...?
+//	file delete ?-force? ?--? ?name ...?
+//	file copy ?-force? ?--? source ?source ...? target
+//	file rename ?-force? ?--? source ?source ...? target
+//	file tempfile ?varName? ?template?
+//
+// Files are accessed via the interpreter's FileSystem.
+func file(i *Interpreter, args []string) (string, error) {
+
+	if len(args) < 1 {
+		return "", fmt.Errorf("file requires a sub-command")
+	}
+
+	sub := args[0]
+	args = args[1:]
+
+	// Sub-commands which take a single name.
+	single := func() error {
+		if len(args) != 1 {
+			return fmt.Errorf("wrong # args: should be \"file %s name\"", sub)
+		}
+		return nil
+	}
+
+	switch sub {
+	case "exists", "isfile", "isdirectory":
+		if err := single(); err != nil {
+			return "", err
+		}
+		info, err := i.filesystem.Stat(args[0])
+		ok := err == nil
+		if ok && sub == "isfile" {
+			ok = info.Mode().IsRegular()
+		}
+		if ok && sub == "isdirectory" {
+			ok = info.IsDir()
+		}
+		if ok {
+			return "1", nil
+		}
+		return "0", nil
+
+	case "size", "mtime":
+		if err := single(); err != nil {
+			return "", err
+		}
+		info, err := i.filesystem.Stat(args[0])
+		if err != nil {
+			return "", fileError("could not read", args[0], err)
+		}
+		if sub == "size" {
+			return strconv.FormatInt(info.Size(), 10), nil
+		}
+		return strconv.FormatInt(info.ModTime().Unix(), 10), nil
+
+	case "join":
+		if len(args) < 1 {
+			return "", fmt.Errorf("wrong # args: should be \"file join name ?name ...?\"")
+		}
+		return joinPath(args), nil
+
+	case "split":
+		if err := single(); err != nil {
+			return "", err
+		}
+		return formatList(splitPath(args[0])), nil
+
+	case "dirname":
+		if err := single(); err != nil {
+			return "", err
+		}
+		return path.Dir(trimSeparators(args[0])), nil
+
+	case "tail":
+		if err := single(); err != nil {
+			return "", err
+		}
+		parts := splitPath(args[0])
+		if len(parts) == 0 || (len(parts) == 1 && path.IsAbs(parts[0])) {
+			return "", nil
+		}
+		return parts[len(parts)-1], nil
+
+	case "extension":
+		if err := single(); err != nil {
+			return "", err
+		}
+		return path.Ext(path.Base(args[0])), nil
+
+	case "rootname":
+		if err := single(); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(args[0], path.Ext(path.Base(args[0]))), nil
+
+	case "normalize":
+		if err := single(); err != nil {
+			return "", err
+		}
+		return i.normalizePath(args[0])
+
+	case "mkdir":
+		for _, dir := range args {
+			if err := i.mkdirAll(dir); err != nil {
+				return "", err
+			}
+		}
+		return "", nil
+
+	case "delete":
+		force, names := fileFlags(args)
+		for _, name := range names {
+			if err := i.removePath(name, force); err != nil {
+				return "", err
+			}
+		}
+		return "", nil
+
+	case "copy", "rename":
+		force, names := fileFlags(args)
+		if len(names) < 2 {
+			return "", fmt.Errorf("wrong # args: should be \"file %s ?-force? ?--? source ?source ...? target\"", sub)
+		}
+		return "", i.transfer(sub, names[:len(names)-1], names[len(names)-1], force)
+
+	case "tempfile":
+		if len(args) > 2 {
+			return "", fmt.Errorf("wrong # args: should be \"file tempfile ?varName? ?template?\"")
+		}
+		dir, pattern := "", "critical"
+		if len(args) == 2 {
+			dir, pattern = path.Split(args[1])
+		}
+
+		f, name, err := i.filesystem.CreateTemp(dir, pattern+"*")
+		if err != nil {
+			return "", fileError("can't create temporary file", path.Join(dir, pattern), err)
+		}
+
+		ch := newChannel(f, f)
+		ch.closer = f
+		if seeker, ok := f.(io.Seeker); ok {
+			ch.seeker = seeker
+		}
+
+		if len(args) > 0 {
+			if err := i.setVar(args[0], name); err != nil {
+				f.Close()
+				return "", err
+			}
+		}
+		return i.addChannel("file", ch), nil
+	}
+
+	return "", fmt.Errorf("unknown or ambiguous subcommand \"%s\": must be copy, delete, dirname, exists, extension, isdirectory, isfile, join, mkdir, mtime, normalize, rename, rootname, size, split, tail, or tempfile", sub)
+}
+
+// normalizePath returns the absolute form of a path, relative to the
+// current directory of our filesystem, with any "." and ".." elements
+// removed.
+func (i *Interpreter) normalizePath(name string) (string, error) {
+	if path.IsAbs(name) {
+		return path.Clean(name), nil
+	}
+
+	wd, err := i.filesystem.Getwd()
+	if err != nil {
+		return "", fileError("can't normalize", name, err)
+	}
+	return path.Join(wd, name), nil
+}
+
+// fileFlags removes the options `-force` and `--` from the start of the
+// arguments given to `file delete`, `file copy`, and `file rename`.
+func fileFlags(args []string) (bool, []string) {
+	force := false
+	for len(args) > 0 {
+		switch args[0] {
+		case "-force":
+			force = true
+		case "--":
+			return force, args[1:]
+		default:
+			return force, args
+		}
+		args = args[1:]
+	}
+	return force, args
+}
+
+// joinPath joins the given names into a single path.  If any name is an
+// absolute path then the names before it are discarded.
+func joinPath(names []string) string {
+	out := ""
+	for _, name := range names {
+		switch {
+		case path.IsAbs(name) || out == "":
+			out = name
+		case name != "":
+			out = strings.TrimRight(out, "/") + "/" + name
+		}
+	}
+	return trimSeparators(out)
+}
+
+// splitPath splits a path into its components.  The first component of
+// an absolute path is the root directory.
+func splitPath(name string) []string {
+	var out []string
+	if path.IsAbs(name) {
+		out = append(out, "/")
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// trimSeparators removes trailing path-separators from a name, other than
+// from the root directory itself.
+func trimSeparators(name string) string {
+	trimmed := strings.TrimRight(name, "/")
+	if trimmed == "" && name != "" {
+		return "/"
+	}
+	return trimmed
+}
+
+// mkdirAll creates the named directory, along with any missing parents.
+func (i *Interpreter) mkdirAll(dir string) error {
+	info, err := i.filesystem.Stat(dir)
+	if err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("can't create directory \"%s\": file already exists", dir)
+		}
+		return nil
+	}
+
+	parent := path.Dir(trimSeparators(dir))
+	if parent != dir && parent != "." {
+		if err := i.mkdirAll(parent); err != nil {
+			return err
+		}
+	}
+
+	err = i.filesystem.Mkdir(dir, 0777)
+	if err != nil && !errors.Is(err, fs.ErrExist) {
+		return fileError("can't create directory", dir, err)
+	}
+	return nil
+}
+
+// removePath removes the named file or directory.  A directory which isn't
+// empty is only removed, along with its contents, if force is set.  It is
+// not an error if the name doesn't exist.
+//
+// Symbolic links are removed themselves, and never followed, so removing
+// a link to a directory leaves the contents of that directory alone.
+func (i *Interpreter) removePath(name string, force bool) error {
+	info, err := i.filesystem.Lstat(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fileError("error deleting", name, err)
+	}
+
+	if info.IsDir() {
+		entries, err := i.filesystem.ReadDir(name)
+		if err != nil {
+			return fileError("error deleting", name, err)
+		}
+		if len(entries) > 0 && !force {
+			return fmt.Errorf("error deleting \"%s\": directory not empty", name)
+		}
+		for _, entry := range entries {
+			if err := i.removePath(path.Join(name, entry.Name()), force); err != nil {
+				return err
+			}
+		}
+	}
+
+	err = i.filesystem.Remove(name)
+	if err != nil {
+		return fileError("error deleting", name, err)
+	}
+	return nil
+}
+
+// transfer implements `file copy` and `file rename`.
+//
+// If the target is an existing directory the sources are placed within
+// it, otherwise there must be a single source which becomes the target.
+// Existing files are only replaced if force is set, and a directory may
+// not be placed within itself.
+func (i *Interpreter) transfer(op string, sources []string, target string, force bool) error {
+
+	info, err := i.filesystem.Stat(target)
+	targetDir := err == nil && info.IsDir()
+
+	if !targetDir && len(sources) > 1 {
+		return fmt.Errorf("error %s: target \"%s\" is not a directory", progressive(op), target)
+	}
+
+	for _, source := range sources {
+		dest := target
+		if targetDir {
+			dest = path.Join(target, path.Base(trimSeparators(source)))
+		}
+
+		info, err := i.filesystem.Stat(source)
+		if err != nil {
+			return fileError("error "+progressive(op), source, err)
+		}
+
+		if info.IsDir() {
+			inside, err := i.isWithin(dest, source)
+			if err != nil {
+				return err
+			}
+			if inside {
+				return fmt.Errorf("error %s \"%s\" to \"%s\": can't place a directory within itself", progressive(op), source, dest)
+			}
+		}
+
+		if existing, err := i.filesystem.Stat(dest); err == nil {
+			if !force {
+				return fmt.Errorf("error %s \"%s\" to \"%s\": file already exists", progressive(op), source, dest)
+			}
+			if existing.IsDir() {
+				if err := i.removePath(dest, false); err != nil {
+					return err
+				}
+			}
+		}
+
+		if op == "rename" {
+			err = i.filesystem.Rename(source, dest)
+		} else {
+			err = i.copyPath(source, dest)
+		}
+		if err != nil {
+			return fileError(fmt.Sprintf("error %s \"%s\" to", progressive(op), source), dest, err)
+		}
+	}
+	return nil
+}
+
+// isWithin returns true if the named path is the directory given, or is
+// within it, once both have been normalized.
+func (i *Interpreter) isWithin(name string, dir string) (bool, error) {
+	name, err := i.normalizePath(name)
+	if err != nil {
+		return false, err
+	}
+	dir, err = i.normalizePath(dir)
+	if err != nil {
+		return false, err
+	}
+	return name == dir || strings.HasPrefix(name, strings.TrimSuffix(dir, "/")+"/"), nil
+}
+
+// progressive returns the form of the operation used in error messages.
+func progressive(op string) string {
+	if op == "rename" {
+		return "renaming"
+	}
+	return "copying"
+}
+
+// copyPath copies a file, or a directory and its contents.
+func (i *Interpreter) copyPath(source string, dest string) error {
+	info, err := i.filesystem.Stat(source)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		err = i.filesystem.Mkdir(dest, info.Mode().Perm())
+		if err != nil && !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		entries, err := i.filesystem.ReadDir(source)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			err = i.copyPath(path.Join(source, entry.Name()), path.Join(dest, entry.Name()))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	in, err := i.filesystem.OpenFile(source, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := i.filesystem.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,251 @@
+package interpreter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFilePaths(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `file join a b c`, Output: "a/b/c"},
+		{Input: `file join a/ b`, Output: "a/b"},
+		{Input: `file join a /b c`, Output: "/b/c"},
+		{Input: `file join / a`, Output: "/a"},
+		{Input: `file join a {} b`, Output: "a/b"},
+		{Input: `file split /usr/local/bin`, Output: "/ usr local bin"},
+		{Input: `file split a//b/`, Output: "a b"},
+		{Input: `file dirname /usr/local/bin`, Output: "/usr/local"},
+		{Input: `file dirname a/b/`, Output: "a"},
+		{Input: `file dirname name`, Output: "."},
+		{Input: `file dirname /`, Output: "/"},
+		{Input: `file tail /usr/local/bin`, Output: "bin"},
+		{Input: `file tail a/b/`, Output: "b"},
+		{Input: `file tail /`, Output: ""},
+		{Input: `file extension report.txt`, Output: ".txt"},
+		{Input: `file extension a.b/report`, Output: ""},
+		{Input: `file extension archive.tar.gz`, Output: ".gz"},
+		{Input: `file rootname a/report.txt`, Output: "a/report"},
+		{Input: `file rootname a.b/report`, Output: "a.b/report"},
+		{Input: `file normalize /a/b/../c/./d`, Output: "/a/c/d"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+}
+
+func TestFile(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		// Metadata
+		{Input: `file exists /data/a.txt`, Output: "1"},
+		{Input: `file exists /data`, Output: "1"},
+		{Input: `file exists /missing`, Output: "0"},
+		{Input: `file isfile /data/a.txt`, Output: "1"},
+		{Input: `file isfile /data`, Output: "0"},
+		{Input: `file isdirectory /data`, Output: "1"},
+		{Input: `file isdirectory /data/a.txt`, Output: "0"},
+		{Input: `file isdirectory /missing`, Output: "0"},
+		{Input: `file size /data/a.txt`, Output: "5"},
+		{Input: `expr [file mtime /data/a.txt] > 0`, Output: "1"},
+
+		// Directories
+		{Input: `file mkdir /x/y/z; file isdirectory /x/y/z`, Output: "1"},
+		{Input: `file mkdir /data; file isdirectory /data`, Output: "1"},
+
+		// Deleting
+		{Input: `file delete /data/a.txt; file exists /data/a.txt`, Output: "0"},
+		{Input: `file delete /missing`, Output: ""},
+		{Input: `file delete -force /data; file exists /data/sub/b.txt`, Output: "0"},
+		{Input: `file delete -- /data/a.txt /data/sub/b.txt /data/sub; file exists /data/sub`, Output: "0"},
+
+		// Copying
+		{Input: `file copy /data/a.txt /c.txt; file size /c.txt`, Output: "5"},
+		{Input: `file copy /data/a.txt /data/sub; file exists /data/sub/a.txt`, Output: "1"},
+		{Input: `file copy /data /copy; file size /copy/sub/b.txt`, Output: "3"},
+		{Input: `file copy -force /data/sub/b.txt /data/a.txt; file size /data/a.txt`, Output: "3"},
+
+		// Renaming
+		{Input: `file rename /data/a.txt /b.txt; file exists /data/a.txt`, Output: "0"},
+		{Input: `file rename /data/a.txt /b.txt; file size /b.txt`, Output: "5"},
+		{Input: `file rename /data/a.txt /data/sub; file exists /data/sub/a.txt`, Output: "1"},
+		{Input: `file rename /data /moved; file size /moved/sub/b.txt`, Output: "3"},
+		{Input: `file rename -force /data/sub/b.txt /data/a.txt; file size /data/a.txt`, Output: "3"},
+
+		// Temporary files
+		{Input: `set f [file tempfile]; puts -nonewline $f hello; close $f`, Output: ""},
+		{Input: `set f [file tempfile name]; puts -nonewline $f hello; close $f; file size $name`, Output: "5"},
+		{Input: `close [file tempfile name /data/report]; file dirname $name`, Output: "/data"},
+		{Input: `set f [file tempfile name]; puts $f hello; seek $f 0; gets $f`, Output: "hello"},
+
+		// Normalized paths are relative to the root of the filesystem.
+		{Input: `file normalize data/../sub/./b.txt`, Output: "/sub/b.txt"},
+		{Input: `file normalize ../../a`, Output: "/a"},
+	}
+
+	for _, test := range tests {
+
+		fsys := NewMemoryFileSystem()
+		fsys.WriteFile("/data/a.txt", []byte("hello"))
+		fsys.WriteFile("/data/sub/b.txt", []byte("bye"))
+
+		e, er := New(test.Input, WithFileSystem(fsys))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+
+	// Errors
+	errs := map[string]string{
+		`file`:                                    `file requires a sub-command`,
+		`file bogus`:                              `unknown or ambiguous subcommand "bogus"`,
+		`file exists`:                             `wrong # args: should be "file exists name"`,
+		`file join`:                               `wrong # args`,
+		`file size /missing`:                      `could not read "/missing": no such file or directory`,
+		`file mtime /missing`:                     `could not read "/missing": no such file or directory`,
+		`file mkdir /data/a.txt`:                  `can't create directory "/data/a.txt": file already exists`,
+		`file mkdir /data/a.txt/b`:                `can't create directory "/data/a.txt"`,
+		`file delete /data`:                       `error deleting "/data": directory not empty`,
+		`file copy /data/a.txt`:                   `wrong # args`,
+		`file copy /missing /x`:                   `error copying "/missing": no such file or directory`,
+		`file copy /data/a.txt /data/sub/b.txt`:   `error copying "/data/a.txt" to "/data/sub/b.txt": file already exists`,
+		`file copy /data/a.txt /data/a.txt /x`:    `target "/x" is not a directory`,
+		`file rename /data/a.txt /data/sub/b.txt`: `error renaming "/data/a.txt" to "/data/sub/b.txt": file already exists`,
+		`file rename /data/a.txt /missing/x`:      `error renaming "/data/a.txt" to "/missing/x": no such file or directory`,
+		`file copy /data /data/sub`:               `error copying "/data" to "/data/sub/data": can't place a directory within itself`,
+		`file copy /data /data/copy`:              `error copying "/data" to "/data/copy": can't place a directory within itself`,
+		`file copy /data/sub/.. /data/sub/x`:      `error copying "/data/sub/.." to "/data/sub/x": can't place a directory within itself`,
+		`file rename /data /data/sub`:             `error renaming "/data" to "/data/sub/data": can't place a directory within itself`,
+		`file tempfile a b c`:                     `wrong # args`,
+		`file tempfile name /missing/x`:           `can't create temporary file`,
+	}
+	for input, expected := range errs {
+		fsys := NewMemoryFileSystem()
+		fsys.WriteFile("/data/a.txt", []byte("hello"))
+		fsys.WriteFile("/data/sub/b.txt", []byte("bye"))
+
+		e, er := New(input, WithFileSystem(fsys))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("wrong error running %s: %s", input, err)
+		}
+	}
+}
+
+// TestFileOS tests the file command against the real filesystem.
+func TestFileOS(t *testing.T) {
+
+	dir := t.TempDir()
+	input := `file mkdir {` + filepath.Join(dir, "a", "b") + `}
+set f [open {` + filepath.Join(dir, "a", "b", "c.txt") + `} w]
+puts $f "Hello"
+close $f
+file copy {` + filepath.Join(dir, "a") + `} {` + filepath.Join(dir, "copy") + `}
+file delete -force {` + filepath.Join(dir, "a") + `}
+file size {` + filepath.Join(dir, "copy", "b", "c.txt") + `}`
+
+	e, err := New(input)
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	out, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "6" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a")); !os.IsNotExist(err) {
+		t.Fatalf("expected directory to be deleted")
+	}
+}
+
+// TestFileDeleteSymlink ensures that deleting a symbolic link to a
+// directory doesn't delete the contents of that directory.
+func TestFileDeleteSymlink(t *testing.T) {
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	link := filepath.Join(dir, "link")
+
+	if err := os.Mkdir(target, 0755); err != nil {
+		t.Fatalf("failed to create directory: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "keep.txt"), []byte("keep"), 0644); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symbolic links are not supported: %s", err)
+	}
+
+	e, err := New(`file delete -force {` + link + `}`)
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	if _, err = e.Evaluate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Fatalf("expected link to be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(target, "keep.txt")); err != nil {
+		t.Fatalf("expected target to survive: %s", err)
+	}
+}
+
+func TestFileSafe(t *testing.T) {
+
+	for _, input := range []string{`file exists /etc/passwd`, `glob /etc/*`} {
+		e, err := New(input, WithSafe())
+		if err != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err = e.Evaluate()
+		if !errors.Is(err, ErrHidden) {
+			t.Fatalf("expected hidden error running %s, got %v", input, err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package interpreter
+
+import "fmt"
+
+// fileevent is the golang implementation of the TCL `fileevent` function,
+// which sets the script the event loop runs when a channel is readable.
+//
+//	fileevent channel readable ?script?
+//
+// Without a script the current one is returned, and an empty script
+// removes the handler.  Handlers are also removed when their channel is
+// closed.
+func fileevent(i *Interpreter, args []string) (string, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return "", fmt.Errorf("wrong # args: should be \"fileevent channel readable ?script?\"")
+	}
+
+	ch, err := i.readChannel(args[0])
+	if err != nil {
+		return "", err
+	}
+	if args[1] != "readable" {
+		return "", fmt.Errorf("bad event name \"%s\": must be readable", args[1])
+	}
+
+	if len(args) == 2 {
+		if fe, ok := i.events.files[args[0]]; ok {
+			return fe.script, nil
+		}
+		return "", nil
+	}
+
+	i.setFileEvent(args[0], ch, args[2])
+	return "", nil
+}
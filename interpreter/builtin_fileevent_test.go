@@ -0,0 +1,125 @@
+package interpreter
+
+import (
+	"io"
+	"testing"
+)
+
+func TestFileevent(t *testing.T) {
+
+	r, w := io.Pipe()
+
+	e, er := New(``, WithStdin(r))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	out, err := e.Eval(`set lines {}
+fileevent stdin readable {
+  if { expr [gets stdin line] < 0 } {
+    set done 1
+    fileevent stdin readable {}
+  } else {
+    append lines $line
+  }
+}
+fileevent stdin readable`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out == "" {
+		t.Fatalf("expected the script to be returned")
+	}
+
+	go func() {
+		io.WriteString(w, "one\n")
+		io.WriteString(w, "two\n")
+		w.Close()
+	}()
+
+	out, err = e.Eval(`vwait done; set lines`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "onetwo" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+
+	// The handler was removed, so there's nothing left to wait for.
+	out, err = e.Eval(`fileevent stdin readable`)
+	if err != nil || out != "" {
+		t.Fatalf("expected no handler, got %q %v", out, err)
+	}
+}
+
+// TestFileeventRemoved tests reading from a channel, after its handler
+// has been removed, doesn't race with the goroutine which was watching it.
+func TestFileeventRemoved(t *testing.T) {
+
+	r, w := io.Pipe()
+
+	e, er := New(``, WithStdin(r))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	go func() {
+		io.WriteString(w, "one\n")
+		io.WriteString(w, "two\n")
+		io.WriteString(w, "three\n")
+		w.Close()
+	}()
+
+	out, err := e.Eval(`set lines {}
+fileevent stdin readable { gets stdin line; append lines $line; set done 1 }
+vwait done
+fileevent stdin readable {}
+while { expr [gets stdin line] >= 0 } { append lines $line }
+set lines`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "onetwothree" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestFileeventErrors(t *testing.T) {
+
+	tests := []string{
+		`fileevent stdin`,
+		`fileevent stdin readable a b`,
+		`fileevent stdin writable {}`,
+		`fileevent stdout readable {}`,
+		`fileevent unknown readable {}`,
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", test)
+		}
+	}
+}
+
+// TestFileeventClose tests that closing a channel removes its handler.
+func TestFileeventClose(t *testing.T) {
+
+	r, _ := io.Pipe()
+
+	e, er := New(`fileevent stdin readable { set a 1 }; close stdin; vwait a`, WithStdin(r))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	_, err := e.Evaluate()
+	if err == nil {
+		t.Fatalf("expected vwait to fail, with no events pending")
+	}
+}
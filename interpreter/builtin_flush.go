@@ -0,0 +1,19 @@
+package interpreter
+
+import "fmt"
+
+// flush is the golang implementation of the TCL `flush` function, which
+// writes any output which has been buffered.
+//
+//	flush channel
+func flush(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("flush only accepts one argument, got %d", len(args))
+	}
+
+	ch, err := i.writeChannel(args[0])
+	if err != nil {
+		return "", err
+	}
+	return "", ch.flush()
+}
@@ -34,6 +34,12 @@ func forFn(i *Interpreter, args []string) (string, error) {
 	// Now the condition
 	for {
 
+		// Stop if we've been cancelled.
+		err = i.cancelled()
+		if err != nil {
+			return "", err
+		}
+
 		// middle part
 		tmp, err = i.Eval(mid)
 		if err != nil {
@@ -0,0 +1,42 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// gets is the golang implementation of the TCL `gets` function.
+//
+//	gets channel ?varName?
+//
+// With no variable the line is returned, otherwise it is stored in the
+// variable and the length of the line is returned, or -1 at the end of
+// the input.
+func gets(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return "", fmt.Errorf("gets accepts one or two arguments, got %d", len(args))
+	}
+
+	ch, err := i.readChannel(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	line, ok, err := ch.readLine()
+	if err != nil {
+		return "", err
+	}
+
+	if len(args) == 1 {
+		return line, nil
+	}
+
+	err = i.setVar(args[1], line)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "-1", nil
+	}
+	return strconv.Itoa(len([]rune(line))), nil
+}
@@ -0,0 +1,99 @@
+package interpreter
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestGets(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Stdin  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `gets stdin`, Stdin: "Hello\nWorld\n", Output: "Hello"},
+		{Input: `gets stdin; gets stdin`, Stdin: "Hello\nWorld\n", Output: "World"},
+		{Input: `gets stdin`, Stdin: "no newline", Output: "no newline"},
+		{Input: `gets stdin`, Stdin: "dos\r\nline", Output: "dos"},
+		{Input: `gets stdin; gets stdin`, Stdin: "dos\r\nline", Output: "line"},
+		{Input: `gets stdin; gets stdin`, Stdin: "mac\rline\r", Output: "line"},
+		{Input: `fconfigure stdin -translation cr; gets stdin; gets stdin`, Stdin: "mac\rline\rlast", Output: "line"},
+		{Input: `fconfigure stdin -translation cr; gets stdin`, Stdin: "mac\nline\r", Output: "mac\nline"},
+		{Input: `set n 0
+while { expr [gets stdin line] >= 0 } { append n "|$line" }
+set n`, Stdin: "a\rb\r\nc\nd\r\r\ne", Output: "0|a|b|c|d||e"},
+		{Input: `gets stdin`, Stdin: "", Output: ""},
+		{Input: `gets stdin line`, Stdin: "Hello\n", Output: "5"},
+		{Input: `gets stdin line; set line`, Stdin: "Hello\n", Output: "Hello"},
+		{Input: `gets stdin line`, Stdin: "\n", Output: "0"},
+		{Input: `gets stdin line`, Stdin: "", Output: "-1"},
+		{Input: `set n 0
+while { expr [gets stdin line] >= 0 } { incr n }
+set n`, Stdin: "a\nb\nc", Output: "3"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input, WithStdin(strings.NewReader(test.Stdin)))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+
+	// Errors
+	errs := map[string]string{
+		`gets missing`: `can not find channel named "missing"`,
+		`gets stdout`:  `wasn't opened for reading`,
+	}
+	for input, expected := range errs {
+		e, er := New(input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("wrong error running %s: %s", input, err)
+		}
+	}
+}
+
+// TestGetsSplitLineEnding tests a CRLF line-ending which arrives in two
+// parts, which mustn't leave an empty line behind.
+func TestGetsSplitLineEnding(t *testing.T) {
+
+	r, w := io.Pipe()
+	go func() {
+		io.WriteString(w, "first\r")
+		io.WriteString(w, "\nsecond\n")
+		w.Close()
+	}()
+
+	e, er := New(`list [gets stdin] [gets stdin]`, WithStdin(r))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	out, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "first second" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
@@ -0,0 +1,236 @@
+package interpreter
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// glob is the golang implementation of the TCL `glob` function, which
+// returns the names of the files which match the given patterns.
+//
+//	glob ?-directory dir? ?-types typeList? ?-nocomplain? ?-tails? ?--? pattern ?pattern ...?
+//
+// Patterns may contain the wildcards "*", "?", and "[chars]", as well as
+// alternatives such as "{a,b}".  The types "f" and "d" restrict the
+// results to files, or directories, respectively.  The results are
+// sorted, and it is an error if there are none, unless -nocomplain is
+// given.
+func glob(i *Interpreter, args []string) (string, error) {
+
+	dir := ""
+	types := []string{}
+	nocomplain := false
+	tails := false
+
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		opt := args[0]
+		args = args[1:]
+
+		if opt == "--" {
+			break
+		}
+
+		switch opt {
+		case "-nocomplain":
+			nocomplain = true
+		case "-tails":
+			tails = true
+		case "-directory", "-types":
+			if len(args) < 1 {
+				return "", fmt.Errorf("missing argument to \"%s\"", opt)
+			}
+			if opt == "-directory" {
+				dir = args[0]
+			} else {
+				var err error
+				types, err = parseList(args[0])
+				if err != nil {
+					return "", err
+				}
+				for _, t := range types {
+					if t != "f" && t != "d" {
+						return "", fmt.Errorf("bad argument to \"-types\": %s", t)
+					}
+				}
+			}
+			args = args[1:]
+		default:
+			return "", fmt.Errorf("bad option \"%s\": must be -directory, -nocomplain, -tails, -types, or --", opt)
+		}
+	}
+
+	if len(args) < 1 {
+		return "", fmt.Errorf("wrong # args: should be \"glob ?-option value ...? pattern ?pattern ...?\"")
+	}
+	if tails && dir == "" {
+		return "", fmt.Errorf("\"-tails\" must be used with \"-directory\"")
+	}
+
+	found := make(map[string]bool)
+	for _, pattern := range args {
+		for _, expanded := range expandBraces(pattern) {
+			for _, match := range i.globPattern(dir, expanded) {
+				full := match
+				if dir != "" && !path.IsAbs(match) {
+					full = path.Join(dir, match)
+				}
+				if !i.globType(full, types) {
+					continue
+				}
+				if tails {
+					found[match] = true
+				} else {
+					found[full] = true
+				}
+			}
+		}
+	}
+
+	if len(found) == 0 && !nocomplain {
+		if len(args) == 1 {
+			return "", fmt.Errorf("no files matched glob pattern \"%s\"", args[0])
+		}
+		return "", fmt.Errorf("no files matched glob patterns \"%s\"", strings.Join(args, " "))
+	}
+
+	out := make([]string, 0, len(found))
+	for match := range found {
+		out = append(out, match)
+	}
+	sort.Strings(out)
+	return formatList(out), nil
+}
+
+// globPattern returns the names which match the pattern, without any
+// alternatives, relative to the given directory if it isn't absolute.
+func (i *Interpreter) globPattern(dir string, pattern string) []string {
+
+	prefix := ""
+	if path.IsAbs(pattern) {
+		prefix = "/"
+		dir = ""
+	}
+	parts := splitPath(pattern)
+	if prefix != "" {
+		parts = parts[1:]
+	}
+
+	var out []string
+	var walk func(name string, parts []string)
+	walk = func(name string, parts []string) {
+
+		if len(parts) == 0 {
+			out = append(out, name)
+			return
+		}
+
+		part := parts[0]
+		join := func(child string) string {
+			if name == "" {
+				return prefix + child
+			}
+			return path.Join(name, child)
+		}
+
+		// Literal names only need to exist.
+		if !strings.ContainsAny(part, "*?[\\") {
+			if _, err := i.filesystem.Stat(path.Join(dir, join(part))); err == nil {
+				walk(join(part), parts[1:])
+			}
+			return
+		}
+
+		search := path.Join(dir, name)
+		if name == "" {
+			search = path.Join(dir, prefix)
+			if search == "" {
+				search = "."
+			}
+		}
+		entries, err := i.filesystem.ReadDir(search)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+
+			// Hidden files must be matched explicitly.
+			if strings.HasPrefix(entry.Name(), ".") && !strings.HasPrefix(part, ".") {
+				continue
+			}
+			if ok, _ := path.Match(part, entry.Name()); !ok {
+				continue
+			}
+			if len(parts) > 1 && !entry.IsDir() {
+				continue
+			}
+			walk(join(entry.Name()), parts[1:])
+		}
+	}
+	walk("", parts)
+	return out
+}
+
+// globType returns true if the named file is of one of the given types,
+// which are "f" for files and "d" for directories.  Any file matches if
+// no types are given.
+func (i *Interpreter) globType(name string, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+
+	info, err := i.filesystem.Stat(name)
+	if err != nil {
+		return false
+	}
+	for _, t := range types {
+		if (t == "f" && info.Mode().IsRegular()) || (t == "d" && info.IsDir()) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandBraces expands the alternatives within a glob pattern, such that
+// "*.{c,h}" becomes "*.c" and "*.h".
+func expandBraces(pattern string) []string {
+
+	start := strings.Index(pattern, "{")
+	if start < 0 {
+		return []string{pattern}
+	}
+
+	// Find the matching close-brace, and the commas which separate
+	// the alternatives.
+	depth := 0
+	commas := []int{}
+	end := -1
+	for n := start; n < len(pattern) && end < 0; n++ {
+		switch pattern[n] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				end = n
+			}
+		case ',':
+			if depth == 1 {
+				commas = append(commas, n)
+			}
+		}
+	}
+	if end < 0 {
+		return []string{pattern}
+	}
+
+	var out []string
+	from := start + 1
+	for _, to := range append(commas, end) {
+		alt := pattern[:start] + pattern[from:to] + pattern[end+1:]
+		out = append(out, expandBraces(alt)...)
+		from = to + 1
+	}
+	return out
+}
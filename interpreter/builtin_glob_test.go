@@ -0,0 +1,134 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+
+	fsys := NewMemoryFileSystem()
+	for _, name := range []string{"/src/a.c", "/src/b.c", "/src/a.h", "/src/.hidden.c", "/src/lib/x.c", "/src/lib/y.go", "/src/doc/readme.txt"} {
+		fsys.WriteFile(name, []byte(name))
+	}
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `glob /src/*.c`, Output: "/src/a.c /src/b.c"},
+		{Input: `glob /src/?.h`, Output: "/src/a.h"},
+		{Input: `glob /src/\[ab\].c`, Output: "/src/a.c /src/b.c"},
+		{Input: `glob /src/.*.c`, Output: "/src/.hidden.c"},
+		{Input: `glob /src/*.{c,h}`, Output: "/src/a.c /src/a.h /src/b.c"},
+		{Input: `glob /src/*/*`, Output: "/src/doc/readme.txt /src/lib/x.c /src/lib/y.go"},
+		{Input: `glob /src/lib/x.c`, Output: "/src/lib/x.c"},
+		{Input: `glob /src/*.c /src/*.h`, Output: "/src/a.c /src/a.h /src/b.c"},
+		{Input: `glob -directory /src *.c`, Output: "/src/a.c /src/b.c"},
+		{Input: `glob -directory /src -tails *.c`, Output: "a.c b.c"},
+		{Input: `glob -directory /src -tails lib/*`, Output: "lib/x.c lib/y.go"},
+		{Input: `glob -types d /src/*`, Output: "/src/doc /src/lib"},
+		{Input: `glob -types f -directory /src -tails *`, Output: "a.c a.h b.c"},
+		{Input: `glob -types {f d} -directory /src -tails *`, Output: "a.c a.h b.c doc lib"},
+		{Input: `glob -nocomplain /src/*.java`, Output: ""},
+		{Input: `glob -nocomplain -- /missing/*`, Output: ""},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input, WithFileSystem(fsys))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+
+	// Errors
+	errs := map[string]string{
+		`glob`:                      `wrong # args`,
+		`glob -nocomplain`:          `wrong # args`,
+		`glob -directory`:           `missing argument to "-directory"`,
+		`glob -types x *`:           `bad argument to "-types": x`,
+		`glob -bogus *`:             `bad option "-bogus"`,
+		`glob -tails *`:             `"-tails" must be used with "-directory"`,
+		`glob /src/*.java`:          `no files matched glob pattern "/src/*.java"`,
+		`glob /src/*.java /src/*.x`: `no files matched glob patterns "/src/*.java /src/*.x"`,
+	}
+	for input, expected := range errs {
+		e, er := New(input, WithFileSystem(fsys))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("wrong error running %s: %s", input, err)
+		}
+	}
+}
+
+// TestGlobRelative tests matching relative patterns against the real
+// filesystem.
+func TestGlobRelative(t *testing.T) {
+
+	dir := t.TempDir()
+	for _, name := range []string{"one.tcl", "two.tcl", "three.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(""), 0644); err != nil {
+			t.Fatalf("failed to write file: %s", err)
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get directory: %s", err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatalf("failed to change directory: %s", err)
+	}
+	defer os.Chdir(cwd)
+
+	e, err := New(`glob *.tcl`)
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	out, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "one.tcl two.tcl" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestExpandBraces(t *testing.T) {
+
+	tests := map[string][]string{
+		"*.c":         {"*.c"},
+		"*.{c,h}":     {"*.c", "*.h"},
+		"{a,b}{1,2}":  {"a1", "a2", "b1", "b2"},
+		"{a,{b,c}}":   {"a", "b", "c"},
+		"{unbalanced": {"{unbalanced"},
+		"x{}":         {"x"},
+	}
+
+	for input, expected := range tests {
+		out := expandBraces(input)
+		if strings.Join(out, " ") != strings.Join(expected, " ") {
+			t.Fatalf("unexpected expansion of %s: %v", input, out)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpGeturlFn is the golang implementation of the `http::geturl`
+// function, which makes an HTTP request.
+//
+//	http::geturl url ?-method method? ?-headers dict? ?-query data? ?-type mimeType? ?-timeout ms?
+//
+// The result is a dictionary containing the "status", "headers", and
+// "body" of the response, with the names of the headers in lower-case.
+//
+// The query is sent as the body of the request, whose method then
+// defaults to POST, and whose type defaults to that of a form.  It is an
+// error if the request fails, or takes longer than the timeout, but not
+// if the server responds with an error status.
+func httpGeturlFn(i *Interpreter, args []string) (string, error) {
+	if len(args) < 1 || len(args)%2 != 1 {
+		return "", fmt.Errorf("wrong # args: should be \"http::geturl url ?-option value ...?\"")
+	}
+
+	target := args[0]
+	method := ""
+	headers := &dictionary{}
+	var query *string
+	mimeType := "application/x-www-form-urlencoded"
+	var timeout time.Duration
+
+	for n := 1; n < len(args); n += 2 {
+		val := args[n+1]
+
+		switch args[n] {
+		case "-method":
+			method = strings.ToUpper(val)
+		case "-headers":
+			d, err := parseDict(val)
+			if err != nil {
+				return "", err
+			}
+			headers = d
+		case "-query":
+			query = &val
+		case "-type":
+			mimeType = val
+		case "-timeout":
+			ms, err := strconv.Atoi(val)
+			if err != nil {
+				return "", fmt.Errorf("expected integer but got \"%s\"", val)
+			}
+			timeout = time.Duration(ms) * time.Millisecond
+		default:
+			return "", fmt.Errorf("bad option \"%s\": must be -headers, -method, -query, -timeout, or -type", args[n])
+		}
+	}
+
+	if method == "" {
+		method = http.MethodGet
+		if query != nil {
+			method = http.MethodPost
+		}
+	}
+
+	ctx := i.ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var body io.Reader
+	if query != nil {
+		body = strings.NewReader(*query)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return "", fmt.Errorf("invalid request for \"%s\": %s", target, err)
+	}
+	if query != nil {
+		req.Header.Set("Content-Type", mimeType)
+	}
+	for n, key := range headers.keys {
+		req.Header.Set(key, headers.values[n])
+	}
+
+	res, err := i.httpClient.Do(req)
+	if err == nil {
+		defer res.Body.Close()
+		var data []byte
+		data, err = io.ReadAll(res.Body)
+		if err == nil {
+			return responseDict(res, data), nil
+		}
+	}
+
+	if cerr := i.cancelled(); cerr != nil {
+		return "", cerr
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "", fmt.Errorf("request to \"%s\" timed out after %s", target, timeout)
+	}
+	return "", fmt.Errorf("request to \"%s\" failed: %s", target, err)
+}
+
+// responseDict returns the dictionary which describes a response.
+func responseDict(res *http.Response, body []byte) string {
+	headers := &dictionary{}
+	for key, values := range res.Header {
+		headers.set(strings.ToLower(key), strings.Join(values, ", "))
+	}
+
+	d := &dictionary{}
+	d.set("status", strconv.Itoa(res.StatusCode))
+	d.set("headers", headers.sorted().String())
+	d.set("body", string(body))
+	return d.String()
+}
+
+// httpFormatQueryFn is the golang implementation of the `http::formatQuery`
+// function, which encodes names and values as the query of a URL, or as
+// the body of a form.
+//
+//	http::formatQuery ?name value ...?
+func httpFormatQueryFn(i *Interpreter, args []string) (string, error) {
+	if len(args)%2 != 0 {
+		return "", fmt.Errorf("wrong # args: should be \"http::formatQuery ?name value ...?\"")
+	}
+
+	parts := make([]string, 0, len(args)/2)
+	for n := 0; n < len(args); n += 2 {
+		parts = append(parts, url.QueryEscape(args[n])+"="+url.QueryEscape(args[n+1]))
+	}
+	return strings.Join(parts, "&"), nil
+}
+
+// httpRouteFn is the golang implementation of the `http::route` function,
+// which registers the procedure which handles requests for a path.
+//
+//	http::route method path proc
+//
+// The method may be "*" to handle any method, and a path which ends with
+// "*" handles every path which begins with the rest of it.  Routes are
+// matched in the order they were registered, and registering a route
+// for the same method and path again replaces it.
+func httpRouteFn(i *Interpreter, args []string) (string, error) {
+	if len(args) != 3 {
+		return "", fmt.Errorf("wrong # args: should be \"http::route method path proc\"")
+	}
+
+	method := strings.ToUpper(args[0])
+	path := args[1]
+	if !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("invalid path \"%s\": must begin with /", path)
+	}
+
+	for n, r := range i.routes {
+		if r.method == method && r.path == path {
+			i.routes[n].proc = args[2]
+			return "", nil
+		}
+	}
+	i.routes = append(i.routes, httpRoute{method: method, path: path, proc: args[2]})
+	return "", nil
+}
+
+// httpServeFn is the golang implementation of the `http::serve` function,
+// which serves HTTP requests via the routes registered by `http::route`.
+//
+//	http::serve ?address?
+//
+// Requests are handled by copies of the interpreter, as it was when
+// `http::serve` was called, so the routes, and the procedures they invoke,
+// must be defined first.  We serve requests until the evaluation is
+// cancelled.
+func httpServeFn(i *Interpreter, args []string) (string, error) {
+	if len(args) > 1 {
+		return "", fmt.Errorf("wrong # args: should be \"http::serve ?address?\"")
+	}
+
+	addr := i.listenAddress
+	if addr == "" {
+		addr = DefaultListenAddress
+	}
+	if len(args) == 1 {
+		addr = args[0]
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("couldn't open socket: %s", socketError(err))
+	}
+
+	srv := &http.Server{Handler: NewHTTPHandler(i.Clone())}
+
+	// Stop serving when we're cancelled.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-i.ctx.Done():
+			srv.Close()
+		case <-done:
+		}
+	}()
+
+	err = srv.Serve(ln)
+	if cerr := i.cancelled(); cerr != nil {
+		return "", cerr
+	}
+	return "", err
+}
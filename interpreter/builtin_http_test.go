@@ -0,0 +1,245 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoHandler responds with a description of each request.
+func echoHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/slow" {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+		return
+	}
+	if r.URL.Path == "/missing" {
+		http.Error(w, "not here", http.StatusNotFound)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("X-Method", r.Method)
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(w, "%s %s %s [%s] [%s] [%s]", r.Method, r.URL.Path, r.URL.RawQuery, r.Header.Get("X-Token"), r.Header.Get("Content-Type"), body)
+}
+
+func TestHTTPGeturl(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(echoHandler))
+	defer server.Close()
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `dict get [http::geturl $url/a?b=c] body`, Output: "GET /a b=c [] [] []"},
+		{Input: `dict get [http::geturl $url/a] status`, Output: "200"},
+		{Input: `dict get [http::geturl $url/a -method put] headers x-method`, Output: "PUT"},
+		{Input: `dict get [http::geturl $url/a -headers {X-Token secret}] body`, Output: "GET /a  [secret] [] []"},
+		{Input: `dict get [http::geturl $url/a -query [http::formatQuery name {Bob Smith} x &]] body`, Output: "POST /a  [] [application/x-www-form-urlencoded] [name=Bob+Smith&x=%26]"},
+		{Input: `dict get [http::geturl $url/a -query {{}} -type application/json -method PATCH] body`, Output: "PATCH /a  [] [application/json] [{}]"},
+		{Input: `set r [http::geturl $url/missing]; list [dict get $r status] [dict get $r body]`, Output: "404 {not here\n}"},
+		{Input: `http::formatQuery`, Output: ""},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input, WithHTTPClient(server.Client()))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+		e.SetVariable("url", server.URL)
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+}
+
+func TestHTTPGeturlErrors(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(echoHandler))
+	defer server.Close()
+
+	type TestCase struct {
+		Input string
+		Error string
+	}
+
+	tests := []TestCase{
+		{Input: `http::geturl $url/slow -timeout 10`, Error: "timed out"},
+		{Input: `http::geturl $url -bogus 1`, Error: "bad option"},
+		{Input: `http::geturl $url -timeout x`, Error: "expected integer"},
+		{Input: `http::geturl $url -headers {a}`, Error: "missing value"},
+		{Input: `http::geturl $url -method`, Error: "wrong # args"},
+		{Input: `http::geturl "::bogus url"`, Error: "invalid request"},
+		{Input: `http::formatQuery a`, Error: "wrong # args"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input, WithHTTPClient(server.Client()))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+		e.SetVariable("url", server.URL)
+
+		_, err := e.Evaluate()
+		if err == nil || !strings.Contains(err.Error(), test.Error) {
+			t.Fatalf("expected error containing %q running %s, got %v", test.Error, test.Input, err)
+		}
+	}
+
+	// A server which isn't listening.
+	url := server.URL
+	server.Close()
+
+	e, _ := New(`http::geturl `+url, WithHTTPClient(server.Client()))
+	_, err := e.Evaluate()
+	if err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Fatalf("expected failure, got %v", err)
+	}
+
+	// Cancellation.
+	server = httptest.NewServer(http.HandlerFunc(echoHandler))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	e, _ = New(`http::geturl `+server.URL+`/slow`, WithHTTPClient(server.Client()))
+	_, err = e.EvaluateContext(ctx)
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
+
+func TestHTTPRoute(t *testing.T) {
+
+	e, er := New(`proc a {req} { return a }; proc b {req} { return b }
+http::route GET /x a
+http::route get /x b
+http::route POST /x a`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	if _, err := e.Evaluate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Registering the same route again replaces it.
+	if len(e.routes) != 2 || e.routes[0].proc != "b" || e.routes[1].method != "POST" {
+		t.Fatalf("unexpected routes: %v", e.routes)
+	}
+
+	// Routes are copied by Clone.
+	c := e.Clone()
+	if _, err := c.Eval(`http::route GET /y a`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(e.routes) != 2 || len(c.routes) != 3 {
+		t.Fatalf("routes were shared by the clone")
+	}
+}
+
+func TestHTTPErrors(t *testing.T) {
+
+	tests := []string{
+		`http::route`,
+		`http::route GET /x`,
+		`http::route GET x proc`,
+		`http::serve a b`,
+		`http::serve bogus:address:here`,
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", test)
+		}
+	}
+}
+
+// TestHTTPServe tests serving requests until cancelled.
+func TestHTTPServe(t *testing.T) {
+
+	// Find a free port.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	e, er := New(`proc hello {req} { return hello }; http::route GET / hello; http::serve`, WithListenAddress(addr))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := e.EvaluateContext(ctx)
+		done <- err
+	}()
+
+	// Wait for the server to start.
+	var res *http.Response
+	for n := 0; n < 100; n++ {
+		res, err = http.Get("http://" + addr + "/")
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("failed to make request: %s", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "hello" {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, ErrCancelled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
+
+func TestHTTPSafe(t *testing.T) {
+
+	for _, test := range []string{`http::serve`, `http::geturl http://example.com/`} {
+		e, err := New(test, WithSafe())
+		if err != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err = e.Evaluate()
+		if !errors.Is(err, ErrHidden) {
+			t.Fatalf("expected hidden error, got %v", err)
+		}
+	}
+}
@@ -37,11 +37,11 @@ func incr(i *Interpreter, args []string) (string, error) {
 
 	// an integer, really?
 	if orig == float64(int(orig)) {
-		i.environment.Set(name, fmt.Sprintf("%d", int(orig)))
-		return fmt.Sprintf("%d", int(orig)), nil
+		out := fmt.Sprintf("%d", int(orig))
+		return out, i.setVar(name, out)
 	}
 
 	// A floating-point number
-	i.environment.Set(name, fmt.Sprintf("%f", orig))
-	return fmt.Sprintf("%f", orig), nil
+	out := fmt.Sprintf("%f", orig)
+	return out, i.setVar(name, out)
 }
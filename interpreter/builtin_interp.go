@@ -0,0 +1,228 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// interp is the golang implementation of the TCL `interp` function,
+// which is used to create and control child interpreters.
+//
+//	interp create ?-safe? ?--? ?path?
+//	interp eval path arg ?arg ...?
+//	interp alias srcPath srcCmd targetPath targetCmd ?arg ...?
+//	interp delete ?path ...?
+//	interp share srcPath channel destPath
+//	interp exists path
+//	interp children ?path?
+//	interp issafe ?path?
+//	interp expose path hiddenCmd
+//	interp hide path cmd
+//	interp hidden ?path?
+//
+// A path is a list of names, each of which is a child of the previous
+// interpreter, so `{a b}` is the child "b" of our child "a".  The empty
+// path refers to the current interpreter.
+func interp(i *Interpreter, args []string) (string, error) {
+
+	if len(args) < 1 {
+		return "", fmt.Errorf("interp requires a sub-command")
+	}
+
+	sub := args[0]
+	args = args[1:]
+
+	switch sub {
+	case "create":
+		return interpCreate(i, args)
+
+	case "eval":
+		if len(args) < 2 {
+			return "", fmt.Errorf("wrong # args: should be \"interp eval path arg ?arg ...?\"")
+		}
+		child, err := i.interpPath(args[0])
+		if err != nil {
+			return "", err
+		}
+		out, err := child.EvalContext(i.ctx, strings.Join(args[1:], " "))
+		if err == ErrReturn {
+			err = nil
+		}
+		return out, err
+
+	case "alias":
+		if len(args) < 4 {
+			return "", fmt.Errorf("wrong # args: should be \"interp alias srcPath srcCmd targetPath targetCmd ?arg ...?\"")
+		}
+		src, err := i.interpPath(args[0])
+		if err != nil {
+			return "", err
+		}
+		target, err := i.interpPath(args[2])
+		if err != nil {
+			return "", err
+		}
+		src.Alias(args[1], target, args[3], args[4:]...)
+		return args[1], nil
+
+	case "delete":
+		for _, path := range args {
+			parent, name, err := i.interpParent(path)
+			if err != nil {
+				return "", err
+			}
+			err = parent.DeleteChild(name)
+			if err != nil {
+				return "", err
+			}
+		}
+		return "", nil
+
+	case "share":
+		if len(args) != 3 {
+			return "", fmt.Errorf("wrong # args: should be \"interp share srcPath channel destPath\"")
+		}
+		src, err := i.interpPath(args[0])
+		if err != nil {
+			return "", err
+		}
+		dest, err := i.interpPath(args[2])
+		if err != nil {
+			return "", err
+		}
+		return "", src.ShareChannel(args[1], dest)
+
+	case "exists":
+		if len(args) != 1 {
+			return "", fmt.Errorf("wrong # args: should be \"interp exists path\"")
+		}
+		if _, err := i.interpPath(args[0]); err != nil {
+			return "0", nil
+		}
+		return "1", nil
+
+	case "children", "issafe", "hidden":
+		if len(args) > 1 {
+			return "", fmt.Errorf("wrong # args: should be \"interp %s ?path?\"", sub)
+		}
+		path := ""
+		if len(args) == 1 {
+			path = args[0]
+		}
+		target, err := i.interpPath(path)
+		if err != nil {
+			return "", err
+		}
+		switch sub {
+		case "children":
+			return formatList(target.Children()), nil
+		case "issafe":
+			if target.IsSafe() {
+				return "1", nil
+			}
+			return "0", nil
+		}
+		return formatList(target.HiddenCommands()), nil
+
+	case "expose", "hide":
+		if len(args) != 2 {
+			return "", fmt.Errorf("wrong # args: should be \"interp %s path cmd\"", sub)
+		}
+		target, err := i.interpPath(args[0])
+		if err != nil {
+			return "", err
+		}
+		if sub == "hide" {
+			return "", target.Hide(args[1])
+		}
+		if i.safe {
+			return "", fmt.Errorf("permission denied: safe interpreters cannot expose commands")
+		}
+		return "", target.Expose(args[1])
+	}
+
+	return "", fmt.Errorf("unknown interp sub-command \"%s\"", sub)
+}
+
+// interpCreate implements `interp create`.
+func interpCreate(i *Interpreter, args []string) (string, error) {
+
+	var opts []Option
+
+	// Parse any flags
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		flag := args[0]
+		args = args[1:]
+
+		if flag == "--" {
+			break
+		}
+		if flag != "-safe" {
+			return "", fmt.Errorf("bad option \"%s\": must be -safe or --", flag)
+		}
+		opts = append(opts, WithSafe())
+	}
+
+	if len(args) > 1 {
+		return "", fmt.Errorf("wrong # args: should be \"interp create ?-safe? ?--? ?path?\"")
+	}
+
+	// Generate a name, if we weren't given one.
+	if len(args) == 0 {
+		for n := 0; ; n++ {
+			name := fmt.Sprintf("interp%d", n)
+			if _, ok := i.children[name]; !ok {
+				args = append(args, name)
+				break
+			}
+		}
+	}
+
+	parent, name, err := i.interpParent(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	_, err = parent.CreateChild(name, opts...)
+	if err != nil {
+		return "", err
+	}
+	return args[0], nil
+}
+
+// interpPath returns the interpreter with the given path, relative to
+// this one.
+func (i *Interpreter) interpPath(path string) (*Interpreter, error) {
+	names, err := parseList(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cur := i
+	for _, name := range names {
+		child, ok := cur.children[name]
+		if !ok {
+			return nil, fmt.Errorf("could not find interpreter \"%s\"", path)
+		}
+		cur = child
+	}
+	return cur, nil
+}
+
+// interpParent returns the parent of the interpreter with the given path,
+// and the name of the final child.
+func (i *Interpreter) interpParent(path string) (*Interpreter, string, error) {
+	names, err := parseList(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(names) == 0 {
+		return nil, "", fmt.Errorf("invalid interpreter path \"%s\"", path)
+	}
+
+	parent, err := i.interpPath(formatList(names[:len(names)-1]))
+	if err != nil {
+		return nil, "", err
+	}
+	return parent, names[len(names)-1], nil
+}
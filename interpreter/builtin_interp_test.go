@@ -0,0 +1,236 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestInterp(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `interp create a`, Output: "a"},
+		{Input: `interp create; interp create`, Output: "interp1"},
+		{Input: `interp create -safe -- a`, Output: "a"},
+		{Input: `interp create a; interp eval a { set x 3 }`, Output: "3"},
+		{Input: `interp create a; interp eval a set x 3`, Output: "3"},
+		{Input: `interp create a; interp eval a { return 4 }`, Output: "4"},
+
+		// Variables are independent
+		{Input: `set x 1; interp create a; interp eval a { set x 2 }; set x`, Output: "1"},
+		{Input: `interp create a; interp eval a { set x 2 }; set x 1; interp eval a { set x }`, Output: "2"},
+
+		// So are procedures
+		{Input: `proc p {} { return 1 }; interp create a; interp eval a { proc p {} { return 2 } }; p`, Output: "1"},
+
+		// Nested children
+		{Input: `interp create a; interp create {a b}; interp eval {a b} { set x 5 }`, Output: "5"},
+		{Input: `interp create a; interp eval a { interp create b }; interp eval {a b} { set x 6 }`, Output: "6"},
+		{Input: `interp create a; interp create {a b}; interp children a`, Output: "b"},
+		{Input: `interp create b; interp create a; interp children`, Output: "a b"},
+		{Input: `interp create a; interp children a`, Output: ""},
+
+		// Existence, and deletion
+		{Input: `interp create a; interp exists a`, Output: "1"},
+		{Input: `interp exists a`, Output: "0"},
+		{Input: `interp exists {}`, Output: "1"},
+		{Input: `interp create a; interp delete a; interp exists a`, Output: "0"},
+		{Input: `interp create a; interp create b; interp delete a b; interp children`, Output: ""},
+
+		// Safety
+		{Input: `interp issafe`, Output: "0"},
+		{Input: `interp create a; interp issafe a`, Output: "0"},
+		{Input: `interp create -safe a; interp issafe a`, Output: "1"},
+		{Input: `interp create -safe a; interp create {a b}; interp issafe {a b}`, Output: "1"},
+		{Input: `interp create a; interp hide a env; interp hidden a`, Output: "env"},
+
+		// Aliases
+		{Input: `proc double {x} { expr $x * 2 }; interp create a; interp alias a d {} double; interp eval a { d 21 }`, Output: "42"},
+		{Input: `interp create a; interp alias a add {} expr 10 +; interp eval a { add 5 }`, Output: "15"},
+		{Input: `interp create a; interp alias a hello {} set greeting; interp eval a { hello world }; set greeting`, Output: "world"},
+		{Input: `interp create -safe a; interp alias a exit {} set quit; interp eval a { exit 3 }; set quit`, Output: "3"},
+		{Input: `interp create a; interp create b; interp eval b { set x 7 }; interp alias a getx b set x; interp eval a { getx }`, Output: "7"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+
+	// Errors
+	errs := map[string]string{
+		`interp foo`:                                      `unknown interp sub-command "foo"`,
+		`interp create -unsafe a`:                         `bad option "-unsafe"`,
+		`interp create a; interp create a`:                `already exists`,
+		`interp create {a b}`:                             `could not find interpreter "a"`,
+		`interp create {}`:                                `invalid interpreter path`,
+		`interp eval a { set x 1 }`:                       `could not find interpreter "a"`,
+		`interp delete a`:                                 `could not find interpreter "a"`,
+		`interp create -safe a; interp eval a { exit 1 }`: `command is hidden: exit`,
+		`interp create -safe a; interp eval a { interp expose {} exit }`:                                   `permission denied`,
+		`interp create a; interp eval a { unknown }`:                                                       `unknown command 'unknown'`,
+		`interp create a; interp alias a x {} missing; interp eval a { x }`:                                `invalid command name "missing"`,
+		`interp create a; interp alias a x b set; interp eval a { x }`:                                     `could not find interpreter "b"`,
+		`interp create a; interp expose a missing`:                                                         `unknown hidden command`,
+		`interp create a; interp share {} missing a`:                                                       `can not find channel named "missing"`,
+		`interp create a; interp create b; interp alias a x b set; interp delete b; interp eval a { x y }`: `has been deleted`,
+		`interp create -safe a; interp eval a { puts hello }`:                                              `can not find channel named "stdout"`,
+	}
+	for input, expected := range errs {
+		e, er := New(input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected error %q running %s, got %s", expected, input, err)
+		}
+	}
+}
+
+func TestInterpHidden(t *testing.T) {
+
+	e, er := New(`interp create -safe a; interp expose a env; interp hidden a`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	out, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var expected []string
+	for _, cmd := range unsafeCommands {
+		if cmd != "env" {
+			expected = append(expected, cmd)
+		}
+	}
+	sort.Strings(expected)
+
+	if out != strings.Join(expected, " ") {
+		t.Fatalf("unexpected hidden commands: %s", out)
+	}
+}
+
+func TestInterpChannels(t *testing.T) {
+
+	var out bytes.Buffer
+
+	// Normal children share our standard channels.
+	e, er := New(`interp create a; interp eval a { puts hello }`, WithStdout(&out))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	_, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out.String() != "hello\n" {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+
+	// Safe children must have them shared explicitly.
+	out.Reset()
+	e, er = New(`interp create -safe a; interp share {} stdout a; interp eval a { puts safe }`, WithStdout(&out))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	_, err = e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out.String() != "safe\n" {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestInterpAPI(t *testing.T) {
+
+	parent, err := New("")
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	child, err := parent.CreateChild("child", WithSafe(), WithMaxCommands(100))
+	if err != nil {
+		t.Fatalf("unexpected error creating child: %s", err)
+	}
+	if !child.IsSafe() {
+		t.Fatalf("child isn't safe")
+	}
+
+	found, ok := parent.Child("child")
+	if !ok || found != child {
+		t.Fatalf("failed to find child")
+	}
+
+	// Expose a host-function via an alias
+	var logged []string
+	parent.RegisterBuiltin("log", func(i *Interpreter, args []string) (string, error) {
+		logged = append(logged, strings.Join(args, " "))
+		return "", nil
+	})
+	child.Alias("warn", parent, "log", "WARN:")
+
+	_, err = child.Eval(`warn something happened`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(logged) != 1 || logged[0] != "WARN: something happened" {
+		t.Fatalf("unexpected log: %v", logged)
+	}
+
+	// The child's limits apply only to it.
+	_, err = child.Eval(`while {1} {}`)
+	if !errors.Is(err, ErrMaxCommands) {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+	_, err = parent.Eval(`set i 0; while { expr $i < 200 } { incr i }`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Children of safe children are safe.
+	grandchild, err := child.CreateChild("grandchild")
+	if err != nil {
+		t.Fatalf("unexpected error creating grandchild: %s", err)
+	}
+	if !grandchild.IsSafe() {
+		t.Fatalf("grandchild isn't safe")
+	}
+
+	// Delete the child
+	err = parent.DeleteChild("child")
+	if err != nil {
+		t.Fatalf("unexpected error deleting child: %s", err)
+	}
+	if len(parent.Children()) != 0 {
+		t.Fatalf("unexpected children: %v", parent.Children())
+	}
+	err = parent.DeleteChild("child")
+	if err == nil {
+		t.Fatalf("expected error deleting child twice")
+	}
+}
@@ -0,0 +1,114 @@
+package interpreter
+
+import "fmt"
+
+// jsonDecodeFn is the golang implementation of the `json::decode` function,
+// which converts a JSON document into TCL values.
+//
+//	json::decode ?-types? json
+//
+// Objects become dictionaries, and arrays become lists.  With -types each
+// value is returned as a list of its type and value instead, which may
+// be given to `json::encode`.
+func jsonDecodeFn(i *Interpreter, args []string) (string, error) {
+	typed := false
+	if len(args) == 2 && args[0] == "-types" {
+		typed = true
+		args = args[1:]
+	}
+	if len(args) != 1 {
+		return "", fmt.Errorf("wrong # args: should be \"json::decode ?-types? json\"")
+	}
+	return decodeJSON(args[0], typed)
+}
+
+// jsonEncodeFn is the golang implementation of the `json::encode` function,
+// which converts a typed value into JSON.
+//
+//	json::encode typedValue
+//
+// Typed values are returned by `json::decode -types`, and created by the
+// builders `json::object`, `json::array`, `json::string`, `json::number`,
+// `json::boolean`, and `json::null`.
+func jsonEncodeFn(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("wrong # args: should be \"json::encode typedValue\"")
+	}
+	return encodeJSON(args[0])
+}
+
+// jsonObjectFn is the golang implementation of the `json::object` function,
+// which creates a typed object.
+//
+//	json::object ?key typedValue ...?
+func jsonObjectFn(i *Interpreter, args []string) (string, error) {
+	if len(args)%2 != 0 {
+		return "", fmt.Errorf("wrong # args: should be \"json::object ?key typedValue ...?\"")
+	}
+	d, err := parseDict(formatList(args))
+	if err != nil {
+		return "", err
+	}
+	return formatList([]string{"object", d.String()}), nil
+}
+
+// jsonArrayFn is the golang implementation of the `json::array` function,
+// which creates a typed array.
+//
+//	json::array ?typedValue ...?
+func jsonArrayFn(i *Interpreter, args []string) (string, error) {
+	return formatList([]string{"array", formatList(args)}), nil
+}
+
+// jsonStringFn is the golang implementation of the `json::string` function,
+// which creates a typed string.
+//
+//	json::string value
+func jsonStringFn(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("wrong # args: should be \"json::string value\"")
+	}
+	return formatList([]string{"string", args[0]}), nil
+}
+
+// jsonNumberFn is the golang implementation of the `json::number` function,
+// which creates a typed number.
+//
+//	json::number value
+func jsonNumberFn(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("wrong # args: should be \"json::number value\"")
+	}
+
+	if !isJSONNumber(args[0]) {
+		return "", fmt.Errorf("invalid JSON number \"%s\"", args[0])
+	}
+	return formatList([]string{"number", args[0]}), nil
+}
+
+// jsonBooleanFn is the golang implementation of the `json::boolean`
+// function, which creates a typed boolean.
+//
+//	json::boolean value
+func jsonBooleanFn(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("wrong # args: should be \"json::boolean value\"")
+	}
+
+	b, err := parseBool(args[0])
+	if err != nil {
+		return "", err
+	}
+	return formatList([]string{"boolean", fmt.Sprintf("%t", b)}), nil
+}
+
+// jsonNullFn is the golang implementation of the `json::null` function,
+// which creates a typed null.
+//
+//	json::null
+func jsonNullFn(i *Interpreter, args []string) (string, error) {
+	if len(args) != 0 {
+		return "", fmt.Errorf("wrong # args: should be \"json::null\"")
+	}
+	return formatList([]string{"null", ""}), nil
+}
@@ -0,0 +1,77 @@
+package interpreter
+
+import "testing"
+
+func TestJSON(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `json::decode {{"name": "Bob", "tags": ["a", "b"]}}`, Output: "name Bob tags {a b}"},
+		{Input: `dict get [json::decode {{"user": {"id": 7}}}] user id`, Output: "7"},
+		{Input: `lindex [json::decode {[10, 20, 30]}] end`, Output: "30"},
+		{Input: `json::decode -types {{"a": 1}}`, Output: "object {a {number 1}}"},
+		{Input: `json::encode [json::decode -types {{"a":[1,"b",null]}}]`, Output: `{"a":[1,"b",null]}`},
+		{Input: `json::encode {object {a {number 1}}}`, Output: `{"a":1}`},
+		{Input: `json::string 42`, Output: "string 42"},
+		{Input: `json::number 42`, Output: "number 42"},
+		{Input: `json::boolean yes`, Output: "boolean true"},
+		{Input: `json::boolean OFF`, Output: "boolean false"},
+		{Input: `json::boolean 2`, Output: "boolean true"},
+		{Input: `json::null`, Output: "null {}"},
+		{Input: `json::array`, Output: "array {}"},
+		{Input: `json::object`, Output: "object {}"},
+		{Input: `json::encode [json::object name [json::string Bob] age [json::number 42] admin [json::boolean 0] manager [json::null]]`, Output: `{"name":"Bob","age":42,"admin":false,"manager":null}`},
+		{Input: `json::encode [json::array [json::string 1] [json::number 1] [json::array]]`, Output: `["1",1,[]]`},
+		{Input: `set doc [json::encode [json::object msg [json::string "hi \"there\""]]]; dict get [json::decode $doc] msg`, Output: `hi "there"`},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+
+	tests := []string{
+		`json::decode`,
+		`json::decode -types`,
+		`json::decode -bogus {}`,
+		`json::decode {{"a":}}`,
+		`json::encode`,
+		`json::encode {number x}`,
+		`json::object a`,
+		`json::string`,
+		`json::number x`,
+		`json::boolean maybe`,
+		`json::null x`,
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", test)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lindex is the golang implementation of the TCL `lindex` function, which
+// returns an element of a list.
+//
+//	lindex list ?index ...?
+//
+// An index may be an integer, "end", or "end-N".  Given several indexes
+// each selects an element of the list selected by the previous one, and
+// an index which is out of range results in an empty string.
+func lindex(i *Interpreter, args []string) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("wrong # args: should be \"lindex list ?index ...?\"")
+	}
+
+	out := args[0]
+	for _, index := range args[1:] {
+		elements, err := parseList(out)
+		if err != nil {
+			return "", err
+		}
+
+		n, err := listIndex(index, len(elements))
+		if err != nil {
+			return "", err
+		}
+		if n < 0 || n >= len(elements) {
+			return "", nil
+		}
+		out = elements[n]
+	}
+	return out, nil
+}
+
+// listIndex converts an index into a list of the given length, which may
+// be relative to the end, into a position.  The result may be out of
+// range.
+func listIndex(index string, length int) (int, error) {
+
+	if index == "end" {
+		return length - 1, nil
+	}
+	if strings.HasPrefix(index, "end-") {
+		n, err := strconv.Atoi(strings.TrimPrefix(index, "end-"))
+		if err == nil {
+			return length - 1 - n, nil
+		}
+	}
+
+	n, err := strconv.Atoi(index)
+	if err != nil {
+		return 0, fmt.Errorf("bad index \"%s\": must be integer?[+-]integer? or end?[+-]integer?", index)
+	}
+	return n, nil
+}
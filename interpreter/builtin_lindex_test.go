@@ -0,0 +1,61 @@
+package interpreter
+
+import "testing"
+
+func TestLindex(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `lindex {a b c}`, Output: "a b c"},
+		{Input: `lindex {a b c} 0`, Output: "a"},
+		{Input: `lindex {a b c} 2`, Output: "c"},
+		{Input: `lindex {a b c} 3`, Output: ""},
+		{Input: `lindex {a b c} -1`, Output: ""},
+		{Input: `lindex {a b c} end`, Output: "c"},
+		{Input: `lindex {a b c} end-1`, Output: "b"},
+		{Input: `lindex {a {b c} d} 1 0`, Output: "b"},
+		{Input: `lindex {a {b {c d}}} end end end`, Output: "d"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+}
+
+func TestLindexErrors(t *testing.T) {
+
+	tests := []string{
+		`lindex`,
+		`lindex {a b} one`,
+		`lindex "a {b" 0`,
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", test)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package interpreter
+
+// list is the golang implementation of the TCL `list` function, which
+// returns a list containing the given arguments.
+//
+//	list ?value ...?
+func list(i *Interpreter, args []string) (string, error) {
+	return formatList(args), nil
+}
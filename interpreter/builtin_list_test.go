@@ -0,0 +1,35 @@
+package interpreter
+
+import "testing"
+
+func TestList(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `list`, Output: ""},
+		{Input: `list a b c`, Output: "a b c"},
+		{Input: `list a {b c} {}`, Output: "a {b c} {}"},
+		{Input: `list "a\"b" {$x}`, Output: `{a"b} {$x}`},
+		{Input: `llength [list a "b c" d]`, Output: "3"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+}
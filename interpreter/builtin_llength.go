@@ -0,0 +1,22 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// llength is the golang implementation of the TCL `llength` function, which
+// returns the number of elements in a list.
+//
+//	llength list
+func llength(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("llength only accepts one argument, got %d", len(args))
+	}
+
+	elements, err := parseList(args[0])
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(len(elements)), nil
+}
@@ -0,0 +1,125 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// open is the golang implementation of the TCL `open` function, which
+// opens a file and returns the name of a channel which may be used to
+// read from, or write to, it.
+//
+//	open path ?access? ?permissions?
+//
+// The access mode may be one of "r", "r+", "w", "w+", "a", or "a+",
+// optionally followed by "b" to open the file in binary mode, or a list
+// of flags such as {WRONLY CREAT EXCL}.
+func open(i *Interpreter, args []string) (string, error) {
+	if len(args) < 1 || len(args) > 3 {
+		return "", fmt.Errorf("wrong # args: should be \"open fileName ?access? ?permissions?\"")
+	}
+
+	access := "r"
+	if len(args) > 1 {
+		access = args[1]
+	}
+
+	flags, binary, err := accessFlags(access)
+	if err != nil {
+		return "", err
+	}
+
+	perm := int64(0666)
+	if len(args) > 2 {
+		perm, err = strconv.ParseInt(args[2], 0, 32)
+		if err != nil {
+			return "", fmt.Errorf("expected integer but got \"%s\"", args[2])
+		}
+	}
+
+	f, err := i.filesystem.OpenFile(args[0], flags, os.FileMode(perm))
+	if err != nil {
+		return "", fileError("couldn't open", args[0], err)
+	}
+
+	var ch *channel
+	switch flags & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+	case os.O_RDONLY:
+		ch = newChannel(f, nil)
+	case os.O_WRONLY:
+		ch = newChannel(nil, f)
+	default:
+		ch = newChannel(f, f)
+	}
+	ch.closer = f
+	if seeker, ok := f.(io.Seeker); ok {
+		ch.seeker = seeker
+	}
+
+	if binary {
+		ch.translation = "lf"
+		ch.encoding = "binary"
+	}
+
+	return i.addChannel("file", ch), nil
+}
+
+// accessFlags converts an access mode into the flags for os.OpenFile.
+func accessFlags(access string) (int, bool, error) {
+
+	// Binary mode?
+	binary := false
+	if len(access) > 1 && strings.HasSuffix(access, "b") && !strings.Contains(access, " ") {
+		binary = true
+		access = strings.TrimSuffix(access, "b")
+	}
+
+	switch access {
+	case "r":
+		return os.O_RDONLY, binary, nil
+	case "r+":
+		return os.O_RDWR, binary, nil
+	case "w":
+		return os.O_WRONLY | os.O_CREATE | os.O_TRUNC, binary, nil
+	case "w+":
+		return os.O_RDWR | os.O_CREATE | os.O_TRUNC, binary, nil
+	case "a":
+		return os.O_WRONLY | os.O_CREATE | os.O_APPEND, binary, nil
+	case "a+":
+		return os.O_RDWR | os.O_CREATE | os.O_APPEND, binary, nil
+	}
+
+	// A list of flags
+	names, err := parseList(access)
+	if err != nil || len(names) == 0 {
+		return 0, false, fmt.Errorf("illegal access mode \"%s\"", access)
+	}
+
+	flags := 0
+	for _, name := range names {
+		switch name {
+		case "RDONLY":
+			flags |= os.O_RDONLY
+		case "WRONLY":
+			flags |= os.O_WRONLY
+		case "RDWR":
+			flags |= os.O_RDWR
+		case "APPEND":
+			flags |= os.O_APPEND
+		case "CREAT":
+			flags |= os.O_CREATE
+		case "EXCL":
+			flags |= os.O_EXCL
+		case "TRUNC":
+			flags |= os.O_TRUNC
+		case "BINARY":
+			binary = true
+		default:
+			return 0, false, fmt.Errorf("invalid access mode \"%s\": must be RDONLY, WRONLY, RDWR, APPEND, BINARY, CREAT, EXCL, or TRUNC", name)
+		}
+	}
+	return flags, binary, nil
+}
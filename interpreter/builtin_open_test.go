@@ -0,0 +1,219 @@
+package interpreter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+
+	dir := t.TempDir()
+
+	input := filepath.Join(dir, "input.txt")
+	err := os.WriteFile(input, []byte("one\r\ntwo\nthree"), 0644)
+	if err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		// Reading
+		{Input: `set f [open {` + input + `}]; gets $f`, Output: "one"},
+		{Input: `set f [open {` + input + `} r]; gets $f; gets $f`, Output: "two"},
+		{Input: `set f [open {` + input + `}]; gets $f; gets $f line; set line`, Output: "two"},
+		{Input: `set f [open {` + input + `}]; gets $f; gets $f; gets $f line`, Output: "5"},
+		{Input: `set f [open {` + input + `}]; read $f; gets $f line`, Output: "-1"},
+		{Input: `set f [open {` + input + `}]; read $f`, Output: "one\ntwo\nthree"},
+		{Input: `set f [open {` + input + `}]; read $f 5`, Output: "one\nt"},
+		{Input: `set f [open {` + input + `} rb]; read $f 5`, Output: "one\r\n"},
+		{Input: `set f [open {` + input + `} RDONLY]; read $f 3`, Output: "one"},
+
+		// End of file
+		{Input: `set f [open {` + input + `}]; eof $f`, Output: "0"},
+		{Input: `set f [open {` + input + `}]; read $f; eof $f`, Output: "1"},
+		{Input: `set f [open {` + input + `}]; read $f; seek $f 0; eof $f`, Output: "0"},
+
+		// Seeking
+		{Input: `set f [open {` + input + `}]; seek $f 5; gets $f`, Output: "two"},
+		{Input: `set f [open {` + input + `}]; gets $f; seek $f 1 current; gets $f`, Output: "wo"},
+		{Input: `set f [open {` + input + `}]; seek $f -5 end; gets $f`, Output: "three"},
+		{Input: `set f [open {` + input + `}]; gets $f; tell $f`, Output: "5"},
+		{Input: `tell stdin`, Output: "-1"},
+
+		// Channel names
+		{Input: `open {` + input + `}`, Output: "file0"},
+		{Input: `close [open {` + input + `}]; open {` + input + `}`, Output: "file1"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+		e.Close()
+	}
+
+	// Errors
+	errs := map[string]string{
+		`open {` + filepath.Join(dir, "missing") + `}`:    `couldn't open`,
+		`open {` + input + `} x`:                          `invalid access mode "x"`,
+		`open {` + input + `} {RDONLY BOGUS}`:             `invalid access mode "BOGUS"`,
+		`open {` + input + `} r steve`:                    `expected integer`,
+		`open {` + input + `} {WRONLY CREAT EXCL}`:        `couldn't open`,
+		`set f [open {` + input + `}]; close $f; gets $f`: `can not find channel named "file0"`,
+		`set f [open {` + input + `}]; puts $f "x"`:       `wasn't opened for writing`,
+		`set f [open {` + input + `}]; seek $f x`:         `expected integer`,
+		`set f [open {` + input + `}]; seek $f 0 middle`:  `bad origin`,
+		`set f [open {` + input + `}]; read $f -1`:        `expected non-negative integer`,
+		`seek stdin 0`:  `doesn't support seeking`,
+		`close missing`: `can not find channel named "missing"`,
+		`eof missing`:   `can not find channel named "missing"`,
+		`tell missing`:  `can not find channel named "missing"`,
+		`flush stdin`:   `wasn't opened for writing`,
+	}
+	for input, expected := range errs {
+		e, er := New(input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("wrong error running %s: %s", input, err)
+		}
+		e.Close()
+	}
+}
+
+func TestOpenWrite(t *testing.T) {
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "output.txt")
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `set f [open {` + path + `} w]; puts $f "one"; puts -nonewline $f "two"; close $f`, Output: "one\ntwo"},
+		{Input: `set f [open {` + path + `} a]; puts $f "three"; close $f`, Output: "one\ntwothree\n"},
+		{Input: `set f [open {` + path + `} w+]; puts $f "four"; seek $f 0; gets $f`, Output: "four\n"},
+		{Input: `set f [open {` + path + `} r+]; seek $f 0 end; puts -nonewline $f "five"; seek $f 0; read $f`, Output: "four\nfive"},
+		{Input: `set f [open {` + path + `} {WRONLY TRUNC}]; puts $f "six"; tell $f`, Output: "six\n"},
+		{Input: `set f [open {` + path + `} wb]; puts $f "seven"`, Output: "seven\n"},
+		{Input: `set f [open {` + path + `} w]; fconfigure $f -translation crlf; puts $f "eight"`, Output: "eight\r\n"},
+		{Input: `set f [open {` + path + `} w]; fconfigure $f -encoding binary; puts -nonewline $f "é"`, Output: "\xe9"},
+		{Input: `set f [open {` + path + `} w]; puts $f "é"`, Output: "é\n"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+
+		// Any remaining output is written when the interpreter
+		// is closed.
+		err = e.Close()
+		if err != nil {
+			t.Fatalf("unexpected error closing interpreter: %s", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read file: %s", err)
+		}
+		if string(data) != test.Output {
+			t.Fatalf("unexpected contents after %s: got %q expected %q", test.Input, data, test.Output)
+		}
+	}
+}
+
+func TestOpenBuffering(t *testing.T) {
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "output.txt")
+
+	e, err := New(`set f [open {` + path + `} w]`)
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	if _, err = e.Evaluate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	contents := func() string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read file: %s", err)
+		}
+		return string(data)
+	}
+
+	type TestCase struct {
+		Input    string
+		Contents string
+	}
+
+	tests := []TestCase{
+		// Fully buffered output isn't written until flushed.
+		{Input: `puts $f "one"`, Contents: ""},
+		{Input: `flush $f`, Contents: "one\n"},
+
+		// Line-buffered output is written at each newline.
+		{Input: `fconfigure $f -buffering line; puts -nonewline $f "two"`, Contents: "one\n"},
+		{Input: `puts $f ""`, Contents: "one\ntwo\n"},
+
+		// Unbuffered output is written immediately.
+		{Input: `fconfigure $f -buffering none; puts -nonewline $f "three"`, Contents: "one\ntwo\nthree"},
+	}
+
+	for _, test := range tests {
+		_, err := e.Eval(test.Input)
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if contents() != test.Contents {
+			t.Fatalf("unexpected contents after %s: got %q expected %q", test.Input, contents(), test.Contents)
+		}
+	}
+}
+
+func TestOpenSafe(t *testing.T) {
+
+	e, err := New(`open /etc/passwd`, WithSafe())
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	_, err = e.Evaluate()
+	if !errors.Is(err, ErrHidden) {
+		t.Fatalf("expected hidden error, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package interpreter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// packageFn is the golang implementation of the TCL `package` function,
+// which is used to load libraries of code.
+//
+//	package provide name ?version?
+//	package require ?-exact? name ?version?
+//	package present ?-exact? name ?version?
+//	package ifneeded name version ?script?
+//	package forget ?name ...?
+//	package names
+//	package versions name
+//	package vcompare version1 version2
+//	package vsatisfies version requirement
+func packageFn(i *Interpreter, args []string) (string, error) {
+
+	if len(args) < 1 {
+		return "", fmt.Errorf("package requires a sub-command")
+	}
+
+	sub := args[0]
+	args = args[1:]
+
+	switch sub {
+	case "provide":
+		if len(args) != 1 && len(args) != 2 {
+			return "", fmt.Errorf("wrong # args: should be \"package provide name ?version?\"")
+		}
+		if len(args) == 1 {
+			return i.packages[args[0]], nil
+		}
+		if _, err := parseVersion(args[1]); err != nil {
+			return "", err
+		}
+		if have, ok := i.packages[args[0]]; ok && have != args[1] {
+			return "", fmt.Errorf("conflicting versions provided for package \"%s\": %s, then %s", args[0], have, args[1])
+		}
+		i.packages[args[0]] = args[1]
+		return "", nil
+
+	case "require", "present":
+		exact := false
+		if len(args) > 0 && args[0] == "-exact" {
+			exact = true
+			args = args[1:]
+		}
+		if len(args) != 1 && len(args) != 2 {
+			return "", fmt.Errorf("wrong # args: should be \"package %s ?-exact? name ?version?\"", sub)
+		}
+		version := ""
+		if len(args) == 2 {
+			version = args[1]
+			if _, err := parseVersion(version); err != nil {
+				return "", err
+			}
+		}
+		if sub == "present" {
+			if _, ok := i.packages[args[0]]; !ok {
+				return "", fmt.Errorf("package %s is not present", args[0])
+			}
+		}
+		return i.requirePackage(args[0], version, exact)
+
+	case "ifneeded":
+		if len(args) != 2 && len(args) != 3 {
+			return "", fmt.Errorf("wrong # args: should be \"package ifneeded name version ?script?\"")
+		}
+		if len(args) == 2 {
+			return i.ifneeded[args[0]][args[1]], nil
+		}
+		return "", i.RegisterPackage(args[0], args[1], args[2])
+
+	case "forget":
+		for _, name := range args {
+			delete(i.packages, name)
+			delete(i.ifneeded, name)
+		}
+		return "", nil
+
+	case "names":
+		if len(args) != 0 {
+			return "", fmt.Errorf("wrong # args: should be \"package names\"")
+		}
+		names := make(map[string]string)
+		for name := range i.packages {
+			names[name] = ""
+		}
+		for name := range i.ifneeded {
+			names[name] = ""
+		}
+		return formatList(sortedKeys(names)), nil
+
+	case "versions":
+		if len(args) != 1 {
+			return "", fmt.Errorf("wrong # args: should be \"package versions name\"")
+		}
+		versions := sortedKeys(i.ifneeded[args[0]])
+		sort.SliceStable(versions, func(a, b int) bool {
+			return compareVersions(versions[a], versions[b]) < 0
+		})
+		return formatList(versions), nil
+
+	case "vcompare", "vsatisfies":
+		if len(args) != 2 {
+			return "", fmt.Errorf("wrong # args: should be \"package %s version1 version2\"", sub)
+		}
+		for _, v := range args {
+			if _, err := parseVersion(v); err != nil {
+				return "", err
+			}
+		}
+		if sub == "vcompare" {
+			return strconv.Itoa(compareVersions(args[0], args[1])), nil
+		}
+		if satisfiesVersion(args[0], args[1]) {
+			return "1", nil
+		}
+		return "0", nil
+	}
+
+	return "", fmt.Errorf("unknown package sub-command \"%s\"", sub)
+}
@@ -0,0 +1,255 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPackage(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `package provide foo 1.2`, Output: ""},
+		{Input: `package provide foo 1.2; package provide foo`, Output: "1.2"},
+		{Input: `package provide foo`, Output: ""},
+		{Input: `package provide foo 1.2; package provide foo 1.2`, Output: ""},
+		{Input: `package provide foo 1.2; package require foo`, Output: "1.2"},
+		{Input: `package provide foo 1.2; package require foo 1.0`, Output: "1.2"},
+		{Input: `package provide foo 1.2; package require -exact foo 1.2`, Output: "1.2"},
+		{Input: `package provide foo 1.2; package present foo`, Output: "1.2"},
+
+		// Loading via ifneeded
+		{Input: `package ifneeded foo 1.0 { set loaded 1; package provide foo 1.0 }; package require foo; set loaded`, Output: "1"},
+		{Input: `package ifneeded foo 1.0 { package provide foo 1.0 }; package ifneeded foo 1.0`, Output: " package provide foo 1.0 "},
+		{Input: `package ifneeded foo 1.0 {}; package ifneeded foo 2.0`, Output: ""},
+
+		// The highest acceptable version is loaded
+		{Input: `package ifneeded foo 1.0 { package provide foo 1.0 }
+package ifneeded foo 1.10 { package provide foo 1.10 }
+package ifneeded foo 1.9 { package provide foo 1.9 }
+package ifneeded foo 2.0 { package provide foo 2.0 }
+package require foo 1.2`, Output: "1.10"},
+		{Input: `package ifneeded foo 1.0 { package provide foo 1.0 }
+package ifneeded foo 2.0 { package provide foo 2.0 }
+package require foo`, Output: "2.0"},
+		{Input: `package ifneeded foo 1.0 { package provide foo 1.0 }
+package ifneeded foo 1.5 { package provide foo 1.5 }
+package require -exact foo 1.0`, Output: "1.0"},
+
+		// Packages load at the global scope
+		{Input: `package ifneeded foo 1.0 { set x 3; package provide foo 1.0 }
+proc p {} { package require foo }
+p
+set x`, Output: "3"},
+
+		// Loading only happens once
+		{Input: `set n 0
+package ifneeded foo 1.0 { incr n; package provide foo 1.0 }
+package require foo
+package require foo
+set n`, Output: "1"},
+
+		// Information
+		{Input: `package ifneeded foo 1.10 {}; package ifneeded foo 1.9 {}; package versions foo`, Output: "1.9 1.10"},
+		{Input: `package versions foo`, Output: ""},
+		{Input: `package ifneeded b 1.0 {}; package provide a 1.0; package names`, Output: "a b"},
+		{Input: `package provide a 1.0; package forget a; package names`, Output: ""},
+
+		// Version comparison
+		{Input: `package vcompare 1.2 1.10`, Output: "-1"},
+		{Input: `package vcompare 1.2 1.2`, Output: "0"},
+		{Input: `package vcompare 2 1.10`, Output: "1"},
+		{Input: `package vcompare 1 1.0`, Output: "-1"},
+		{Input: `package vsatisfies 1.2 1.1`, Output: "1"},
+		{Input: `package vsatisfies 1.2 1.3`, Output: "0"},
+		{Input: `package vsatisfies 2.0 1.0`, Output: "0"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+
+	// Errors
+	errs := map[string]string{
+		`package`:                  `requires a sub-command`,
+		`package foo`:              `unknown package sub-command "foo"`,
+		`package provide foo bar`:  `expected version number but got "bar"`,
+		`package provide foo 1.-1`: `expected version number`,
+		`package provide foo 1.0; package provide foo 2.0`:        `conflicting versions`,
+		`package require foo`:                                     `can't find package foo`,
+		`package require foo 1.0`:                                 `can't find package foo 1.0`,
+		`package require foo x`:                                   `expected version number`,
+		`package present foo`:                                     `package foo is not present`,
+		`package provide foo 1.0; package require foo 2.0`:        `version conflict for package "foo": have 1.0, need 2.0`,
+		`package ifneeded foo 1.0 {}; package require foo`:        `attempt to provide package foo 1.0 failed`,
+		`package ifneeded foo 1.0 {unknown}; package require foo`: `unknown command 'unknown'`,
+		`package ifneeded foo 1.x {}`:                             `expected version number`,
+		`package vcompare 1.0 a`:                                  `expected version number`,
+		`package names extra`:                                     `wrong # args`,
+		`package provide`:                                         `wrong # args`,
+		`package require`:                                         `wrong # args`,
+		`package ifneeded foo`:                                    `wrong # args`,
+		`package versions`:                                        `wrong # args`,
+		`package vsatisfies 1`:                                    `wrong # args`,
+	}
+	for input, expected := range errs {
+		e, er := New(input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected error %q running %s, got %s", expected, input, err)
+		}
+	}
+}
+
+func TestPackageAutoPath(t *testing.T) {
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"greet/pkgIndex.tcl": "package ifneeded greet 1.0 \"source $dir/greet.tcl\"",
+		"greet/greet.tcl":    "source helper.tcl\npackage provide greet 1.0\nproc greet {name} { return \"$greeting, $name\" }",
+		"greet/helper.tcl":   "set greeting Hello",
+		"pkgIndex.tcl":       "package ifneeded top 2.0 { package provide top 2.0 }",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %s", err)
+		}
+	}
+
+	e, er := New(`package require greet; package require top; greet Steve`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	e.SetListVariable("auto_path", []string{filepath.Join(dir, "missing"), dir})
+
+	out, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "Hello, Steve" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if _, ok := e.GetVariable("dir"); ok {
+		t.Fatalf("dir variable leaked")
+	}
+
+	// Safe interpreters don't search the auto_path.
+	e, er = New(`package require greet`, WithSafe())
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	e.SetListVariable("auto_path", []string{dir})
+	_, err = e.Evaluate()
+	if err == nil || !strings.Contains(err.Error(), "can't find package greet") {
+		t.Fatalf("expected error, got %v", err)
+	}
+
+	// Without the auto_path the package can't be found
+	e, er = New(`package require greet`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	_, err = e.Evaluate()
+	if err == nil || !strings.Contains(err.Error(), "can't find package greet") {
+		t.Fatalf("expected error, got %v", err)
+	}
+}
+
+func TestRegisterPackageFS(t *testing.T) {
+
+	fsys := fstest.MapFS{
+		"maths.tcl":          {Data: []byte("package provide maths 1.0\nproc square {x} { expr $x * $x }")},
+		"maths2/maths.tcl":   {Data: []byte("package provide maths 2.0\nproc square {x} { expr $x * $x * 1 }")},
+		"text/upper.tcl":     {Data: []byte("# no package here\nproc upper {x} { return $x }")},
+		"text/pkgIndex.tcl":  {Data: []byte("package ifneeded ignored 1.0 {}")},
+		"deep/er/nested.tcl": {Data: []byte("package provide nested 1.0")},
+	}
+
+	e, er := New(`package require maths 1.0; square 4`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	err := e.RegisterPackageFS(fsys)
+	if err != nil {
+		t.Fatalf("unexpected error registering packages: %s", err)
+	}
+
+	out, err := e.Eval(`package names`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "maths" {
+		t.Fatalf("unexpected packages: %s", out)
+	}
+
+	out, err = e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "16" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	out, err = e.Eval(`package provide maths`)
+	if err != nil || out != "1.0" {
+		t.Fatalf("unexpected version: %s %v", out, err)
+	}
+
+	// Safe interpreters may load the packages the host registered.
+	e, er = New(`package require maths 1.0; square 5`, WithSafe())
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	err = e.RegisterPackageFS(fsys)
+	if err != nil {
+		t.Fatalf("unexpected error registering packages: %s", err)
+	}
+	out, err = e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "25" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+
+	// Errors
+	err = e.RegisterPackageFS(fstest.MapFS{"bad.tcl": {Data: []byte("puts \"unterminated")}})
+	if err == nil || !strings.Contains(err.Error(), "error parsing bad.tcl") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	err = e.RegisterPackageFS(fstest.MapFS{"bad.tcl": {Data: []byte("package provide bad x")}})
+	if err == nil || !strings.Contains(err.Error(), "expected version number") {
+		t.Fatalf("expected version error, got %v", err)
+	}
+}
@@ -30,10 +30,17 @@ func proc(i *Interpreter, args []string) (string, error) {
 	// body
 	body := args[2]
 
+	// parse the body, once, now.
+	script, err := i.parse(body)
+	if err != nil {
+		return "", fmt.Errorf("error parsing body of %s: %s", name, err)
+	}
+
 	// Save the function
 	i.functions[name] = UserFunction{
-		Args: argsOut,
-		Body: body,
+		Args:   argsOut,
+		Body:   body,
+		script: script,
 	}
 
 	return "", nil
@@ -3,11 +3,40 @@ package interpreter
 import "fmt"
 
 // puts is the golang implementation of the TCL `puts` function.
+//
+//	puts ?-nonewline? ?channel? string
 func puts(i *Interpreter, args []string) (string, error) {
-	if len(args) != 1 {
-		return "", fmt.Errorf("puts only accepts one argument, got %d", len(args))
+
+	newline := true
+	if len(args) > 1 && args[0] == "-nonewline" {
+		newline = false
+		args = args[1:]
+	}
+
+	if len(args) != 1 && len(args) != 2 {
+		return "", fmt.Errorf("puts accepts one or two arguments, after any -nonewline flag, got %d", len(args))
+	}
+
+	// Output goes to stdout by default.
+	name := "stdout"
+	if len(args) == 2 {
+		name = args[0]
 	}
+	str := args[len(args)-1]
 
-	fmt.Printf("%s\n", args[0])
-	return args[0], nil
+	ch, err := i.writeChannel(name)
+	if err != nil {
+		return "", err
+	}
+
+	out := str
+	if newline {
+		out += "\n"
+	}
+
+	err = ch.write(out)
+	if err != nil {
+		return "", err
+	}
+	return str, nil
 }
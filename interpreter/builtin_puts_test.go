@@ -0,0 +1,70 @@
+package interpreter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPuts(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Stdout string
+		Stderr string
+	}
+
+	tests := []TestCase{
+		{Input: `puts "Hello, World"`, Stdout: "Hello, World\n"},
+		{Input: `puts -nonewline "Hello"; puts ", World"`, Stdout: "Hello, World\n"},
+		{Input: `puts stdout "out"`, Stdout: "out\n"},
+		{Input: `puts stderr "err"`, Stderr: "err\n"},
+		{Input: `puts -nonewline stderr "err"`, Stderr: "err"},
+		{Input: `puts -nonewline`, Stdout: "-nonewline\n"},
+	}
+
+	for _, test := range tests {
+
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+
+		e, er := New(test.Input, WithStdout(stdout), WithStderr(stderr))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if stdout.String() != test.Stdout {
+			t.Fatalf("unexpected stdout for %s: %q", test.Input, stdout.String())
+		}
+		if stderr.String() != test.Stderr {
+			t.Fatalf("unexpected stderr for %s: %q", test.Input, stderr.String())
+		}
+	}
+
+	// Errors
+	errs := map[string]string{
+		`puts missing "text"`:        `can not find channel named "missing"`,
+		`puts stdin "text"`:          `wasn't opened for writing`,
+		`puts -nonewline a b c`:      `puts accepts one or two arguments`,
+		`puts -nonewline stdin "x"`:  `wasn't opened for writing`,
+		`puts stdout -nonewline "x"`: `puts accepts one or two arguments`,
+	}
+	for input, expected := range errs {
+		e, er := New(input, WithStdout(&bytes.Buffer{}))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("wrong error running %s: %s", input, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// read is the golang implementation of the TCL `read` function.
+//
+//	read ?-nonewline? channel
+//	read channel numChars
+//
+// The first form reads all the remaining input from the channel, and the
+// second reads at most the given number of characters.
+func read(i *Interpreter, args []string) (string, error) {
+
+	nonewline := false
+	if len(args) > 0 && args[0] == "-nonewline" {
+		nonewline = true
+		args = args[1:]
+	}
+
+	if len(args) != 1 && (len(args) != 2 || nonewline) {
+		return "", fmt.Errorf("wrong # args: should be \"read channel ?numChars?\" or \"read ?-nonewline? channel\"")
+	}
+
+	ch, err := i.readChannel(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("expected non-negative integer but got \"%s\"", args[1])
+		}
+		return ch.readChars(n)
+	}
+
+	out, err := ch.readAll()
+	if err != nil {
+		return "", err
+	}
+
+	if nonewline {
+		out = strings.TrimSuffix(out, "\n")
+	}
+	return out, nil
+}
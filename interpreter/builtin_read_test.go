@@ -0,0 +1,58 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Stdin  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `read stdin`, Stdin: "Hello\nWorld\n", Output: "Hello\nWorld\n"},
+		{Input: `read -nonewline stdin`, Stdin: "Hello\nWorld\n", Output: "Hello\nWorld"},
+		{Input: `gets stdin; read stdin`, Stdin: "Hello\nWorld\n", Output: "World\n"},
+		{Input: `read stdin`, Stdin: "", Output: ""},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input, WithStdin(strings.NewReader(test.Stdin)))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+
+	// Errors
+	errs := map[string]string{
+		`read missing`: `can not find channel named "missing"`,
+		`read stderr`:  `wasn't opened for reading`,
+	}
+	for input, expected := range errs {
+		e, er := New(input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("wrong error running %s: %s", input, err)
+		}
+	}
+}
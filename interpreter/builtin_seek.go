@@ -0,0 +1,47 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// seek is the golang implementation of the TCL `seek` function.
+//
+//	seek channel offset ?origin?
+//
+// The origin may be "start", "current", or "end".
+func seek(i *Interpreter, args []string) (string, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return "", fmt.Errorf("wrong # args: should be \"seek channel offset ?origin?\"")
+	}
+
+	ch, err := i.channel(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	offset, err := strconv.ParseInt(args[1], 0, 64)
+	if err != nil {
+		return "", fmt.Errorf("expected integer but got \"%s\"", args[1])
+	}
+
+	whence := io.SeekStart
+	if len(args) == 3 {
+		switch args[2] {
+		case "start":
+		case "current":
+			whence = io.SeekCurrent
+		case "end":
+			whence = io.SeekEnd
+		default:
+			return "", fmt.Errorf("bad origin \"%s\": must be start, current, or end", args[2])
+		}
+	}
+
+	err = ch.seek(offset, whence)
+	if err != nil {
+		return "", fmt.Errorf("error during seek on \"%s\": %s", args[0], err)
+	}
+	return "", nil
+}
@@ -14,7 +14,10 @@ func set(i *Interpreter, args []string) (string, error) {
 	// If we have a value, then set it and return it.
 	if len(args) == 2 {
 		value := args[1]
-		i.environment.Set(name, value)
+		err := i.setVar(name, value)
+		if err != nil {
+			return "", err
+		}
 		return value, nil
 	}
 
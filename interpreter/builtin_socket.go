@@ -0,0 +1,221 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// socket is the golang implementation of the TCL `socket` function, which
+// opens a TCP connection, or listens for them.
+//
+//	socket ?-myaddr addr? ?-myport port? host port
+//	socket -server command ?-myaddr addr? port
+//
+// A client connection returns the name of a channel which may be read
+// from, and written to.
+//
+// A server returns the name of a channel which may be closed to stop
+// listening.  Each connection it accepts is given a channel of its own,
+// and the command is invoked with the name of that channel, along with
+// the address and port of the client.  A server listens upon localhost
+// unless -myaddr is given, so `-myaddr 0.0.0.0` must be used to accept
+// connections upon every interface.  Connections are accepted by the
+// event loop, so a server must wait via `vwait`, or have the host call
+// RunEventLoop.
+//
+// The addresses of a socket are available via `fconfigure`, with the
+// options -sockname and -peername.
+func socket(i *Interpreter, args []string) (string, error) {
+
+	server := ""
+	isServer := false
+	myaddr := ""
+	myport := ""
+
+	for len(args) > 0 && len(args[0]) > 1 && args[0][0] == '-' {
+		opt := args[0]
+		if len(args) < 2 {
+			return "", fmt.Errorf("no argument given for \"%s\" option", opt)
+		}
+
+		switch opt {
+		case "-server":
+			server = args[1]
+			isServer = true
+		case "-myaddr":
+			myaddr = args[1]
+		case "-myport":
+			myport = args[1]
+		default:
+			return "", fmt.Errorf("bad option \"%s\": must be -myaddr, -myport, or -server", opt)
+		}
+		args = args[2:]
+	}
+
+	if isServer {
+		if len(args) != 1 || myport != "" {
+			return "", fmt.Errorf("wrong # args: should be \"socket -server command ?-myaddr addr? port\"")
+		}
+		return i.listen(server, myaddr, args[0])
+	}
+
+	if len(args) != 2 {
+		return "", fmt.Errorf("wrong # args: should be \"socket ?-myaddr addr? ?-myport port? host port\"")
+	}
+	return i.connect(args[0], args[1], myaddr, myport)
+}
+
+// connect opens a connection to the given host and port.
+func (i *Interpreter) connect(host string, port string, myaddr string, myport string) (string, error) {
+
+	if _, err := strconv.Atoi(port); err != nil {
+		return "", fmt.Errorf("expected integer but got \"%s\"", port)
+	}
+
+	dialer := net.Dialer{}
+	if myaddr != "" || myport != "" {
+		if myport == "" {
+			myport = "0"
+		}
+		local, err := net.ResolveTCPAddr("tcp", net.JoinHostPort(myaddr, myport))
+		if err != nil {
+			return "", fmt.Errorf("couldn't open socket: %s", socketError(err))
+		}
+		dialer.LocalAddr = local
+	}
+
+	conn, err := dialer.DialContext(i.ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		if cerr := i.cancelled(); cerr != nil {
+			return "", cerr
+		}
+		return "", fmt.Errorf("couldn't open socket: %s", socketError(err))
+	}
+
+	return i.addChannel("sock", newSocketChannel(conn)), nil
+}
+
+// listen starts a server, which invokes the command for each connection.
+func (i *Interpreter) listen(command string, myaddr string, port string) (string, error) {
+
+	if _, err := strconv.Atoi(port); err != nil {
+		return "", fmt.Errorf("expected integer but got \"%s\"", port)
+	}
+
+	if myaddr == "" {
+		myaddr = "127.0.0.1"
+	}
+
+	ln, err := net.Listen("tcp", net.JoinHostPort(myaddr, port))
+	if err != nil {
+		return "", fmt.Errorf("couldn't open socket: %s", socketError(err))
+	}
+
+	s := &socketServer{listener: ln, events: i.events, stop: make(chan struct{})}
+	i.events.listeners++
+
+	ch := newChannel(nil, nil)
+	ch.closer = s
+	ch.local = ln.Addr()
+
+	// Connections are accepted by a goroutine, but handed to the event
+	// loop, since the script may only be run by it.
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			handler := func() (string, error) {
+				// The server may have been closed since.
+				select {
+				case <-s.stop:
+					conn.Close()
+					return "", nil
+				default:
+				}
+
+				name := i.addChannel("sock", newSocketChannel(conn))
+				addr, port := splitAddr(conn.RemoteAddr())
+				return i.runHandler(command + " " + formatList([]string{name, addr, port}))
+			}
+
+			select {
+			case <-s.stop:
+				conn.Close()
+				return
+			case i.events.posted <- handler:
+			}
+		}
+	}()
+
+	return i.addChannel("sock", ch), nil
+}
+
+// newSocketChannel creates a channel which reads from, and writes to, the
+// given connection.
+func newSocketChannel(conn net.Conn) *channel {
+	ch := newChannel(conn, conn)
+	ch.closer = conn
+	ch.local = conn.LocalAddr()
+	ch.remote = conn.RemoteAddr()
+	return ch
+}
+
+// socketServer is the closer of the channel returned by `socket -server`.
+type socketServer struct {
+
+	// listener accepts the connections.
+	listener net.Listener
+
+	// events is the event loop of the interpreter which created us, which
+	// waits for connections while we're listening.
+	events *eventLoop
+
+	// stop is closed when we're closed, and once ensures that only
+	// happens once.
+	stop chan struct{}
+	once sync.Once
+}
+
+// Close stops listening for connections.
+func (s *socketServer) Close() error {
+	err := s.listener.Close()
+	s.once.Do(func() {
+		close(s.stop)
+		s.events.listeners--
+	})
+	return err
+}
+
+// splitAddr returns the address, and port, of a network address.
+func splitAddr(addr net.Addr) (string, string) {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String(), ""
+	}
+	return host, port
+}
+
+// socketError describes the reason a socket couldn't be opened.
+func socketError(err error) string {
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	case errors.Is(err, syscall.EADDRINUSE):
+		return "address already in use"
+	case errors.Is(err, syscall.EACCES):
+		return "permission denied"
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return "host is unreachable"
+	}
+	return err.Error()
+}
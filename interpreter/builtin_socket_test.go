@@ -0,0 +1,267 @@
+package interpreter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoServer is a script which starts a server which echoes each line it
+// receives, prefixed by the address of the client, on a port chosen by
+// the system.
+const echoServer = `
+proc echo {chan addr} {
+  if { expr [gets $chan line] < 0 } {
+    close $chan
+  } else {
+    puts $chan "$addr: $line"
+    flush $chan
+  }
+}
+proc accept {chan addr port} {
+  fileevent $chan readable "echo $chan $addr"
+}
+set server [socket -server accept -myaddr 127.0.0.1 0]
+`
+
+// serverPort returns the port the named server socket is listening upon.
+func serverPort(t *testing.T, e *Interpreter, name string) string {
+	t.Helper()
+
+	out, err := e.Eval(`fconfigure ` + name + ` -sockname`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	addr, err := parseList(out)
+	if err != nil || len(addr) != 3 {
+		t.Fatalf("unexpected -sockname: %s", out)
+	}
+	return addr[2]
+}
+
+// TestSocket tests a client and server within the same interpreter.
+func TestSocket(t *testing.T) {
+
+	e, er := New(echoServer)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	defer e.Close()
+
+	server, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	port := serverPort(t, e, server)
+
+	out, err := e.Eval(`set client [socket 127.0.0.1 ` + port + `]
+fconfigure $client -buffering line
+puts $client hello
+fileevent $client readable { gets $client reply; set done 1 }
+vwait done
+set reply`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "127.0.0.1: hello" {
+		t.Fatalf("unexpected reply: %s", out)
+	}
+
+	// The client's peer is the server.
+	out, err = e.Eval(`fconfigure $client -peername`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "127.0.0.1 127.0.0.1 "+port {
+		t.Fatalf("unexpected -peername: %s", out)
+	}
+
+	// Closing the server stops it listening.
+	_, err = e.Eval(`close ` + server)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_, err = e.Eval(`socket 127.0.0.1 ` + port)
+	if err == nil || !strings.Contains(err.Error(), "couldn't open socket: connection refused") {
+		t.Fatalf("expected connection to be refused, got %v", err)
+	}
+}
+
+// TestSocketServer tests a server driven by RunEventLoop, with a client
+// which is written in golang.
+func TestSocketServer(t *testing.T) {
+
+	e, er := New(echoServer)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	defer e.Close()
+
+	server, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	port := serverPort(t, e, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := e.RunEventLoop(ctx)
+		done <- err
+	}()
+
+	for n := 0; n < 3; n++ {
+		conn, err := net.Dial("tcp", "127.0.0.1:"+port)
+		if err != nil {
+			t.Fatalf("failed to connect: %s", err)
+		}
+		fmt.Fprintf(conn, "request %d\n", n)
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		reply, err := bufio.NewReader(conn).ReadString('\n')
+		conn.Close()
+		if err != nil {
+			t.Fatalf("failed to read reply: %s", err)
+		}
+		if reply != fmt.Sprintf("127.0.0.1: request %d\n", n) {
+			t.Fatalf("unexpected reply: %q", reply)
+		}
+	}
+
+	// The server keeps the loop running until it is cancelled.
+	cancel()
+	err = <-done
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
+
+// TestSocketServerPool tests that returning an interpreter to a pool stops
+// any server it started.
+func TestSocketServerPool(t *testing.T) {
+
+	template, _ := New(``)
+	pool := NewPool(template)
+
+	e := pool.Get()
+	server, err := e.Eval(`socket -server accept -myaddr 127.0.0.1 0`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	port := serverPort(t, e, server)
+	pool.Put(e)
+
+	// The port is free to be used again.
+	ln, err := net.Listen("tcp", "127.0.0.1:"+port)
+	if err != nil {
+		t.Fatalf("server is still listening: %s", err)
+	}
+	ln.Close()
+}
+
+// TestSocketServerLocalhost tests that a server listens upon localhost,
+// unless told otherwise.
+func TestSocketServerLocalhost(t *testing.T) {
+
+	e, _ := New(`socket -server accept 0`)
+	defer e.Close()
+
+	server, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	out, err := e.Eval(`fconfigure ` + server + ` -sockname`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	addr, err := parseList(out)
+	if err != nil || len(addr) != 3 || addr[0] != "127.0.0.1" {
+		t.Fatalf("unexpected -sockname: %s", out)
+	}
+}
+
+// TestSocketClient tests a client connecting to a server which is written
+// in golang.
+func TestSocketClient(t *testing.T) {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		fmt.Fprintf(conn, "OK %s", line)
+	}()
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	e, er := New(`set s [socket 127.0.0.1 ` + port + `]; puts $s ping; flush $s; set reply [gets $s]; close $s; set reply`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	out, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "OK ping" {
+		t.Fatalf("unexpected reply: %s", out)
+	}
+}
+
+func TestSocketErrors(t *testing.T) {
+
+	tests := []string{
+		`socket`,
+		`socket localhost`,
+		`socket localhost 80 extra`,
+		`socket localhost http`,
+		`socket -bogus 1 localhost 80`,
+		`socket -myaddr`,
+		`socket -server accept`,
+		`socket -server accept -myport 1 0`,
+		`socket -server accept bogus`,
+		`fconfigure stdout -peername`,
+		`set s [socket -server accept -myaddr 127.0.0.1 0]; fconfigure $s -sockname x`,
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		e.Close()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", test)
+		}
+	}
+}
+
+func TestSocketSafe(t *testing.T) {
+
+	e, err := New(`socket localhost 80`, WithSafe())
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	_, err = e.Evaluate()
+	if !errors.Is(err, ErrHidden) {
+		t.Fatalf("expected hidden error, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package interpreter
+
+import (
+	"fmt"
+	"path"
+)
+
+// sourceFn is the golang implementation of the TCL `source` function,
+// which executes the contents of a file.
+//
+//	source path
+//
+// A relative path is found relative to the directory containing the
+// script which is currently being executed, if that is known.
+func sourceFn(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("source only accepts one argument, got %d", len(args))
+	}
+
+	return i.sourceFile(i.resolvePath(args[0]))
+}
+
+// sourceFile executes the contents of the given file, in the current
+// scope.
+func (i *Interpreter) sourceFile(path string) (string, error) {
+
+	data, err := i.readFile(path)
+	if err != nil {
+		return "", fileError("couldn't read file", path, err)
+	}
+
+	script, err := i.parse(string(data))
+	if err != nil {
+		return "", fmt.Errorf("error parsing %s: %s", path, err)
+	}
+
+	// Relative paths within the file are relative to it.
+	old := i.scriptPath
+	i.scriptPath = path
+	defer func() {
+		i.scriptPath = old
+	}()
+
+	out, err := i.evalScript(script)
+	if err == ErrReturn {
+		return out, nil
+	}
+	return out, err
+}
+
+// resolvePath returns the given path, made relative to the directory
+// of the current script if it isn't absolute.
+func (i *Interpreter) resolvePath(name string) string {
+	if path.IsAbs(name) || i.scriptPath == "" {
+		return name
+	}
+	return path.Join(path.Dir(i.scriptPath), name)
+}
@@ -0,0 +1,99 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"main.tcl":       "source lib/helper.tcl\nhelper 3",
+		"lib/helper.tcl": "source nested.tcl\nproc helper {x} { expr $x * $factor }",
+		"lib/nested.tcl": "set factor 7",
+		"return.tcl":     "set a 1\nreturn 5\nset a 2",
+		"local.tcl":      "set local 3",
+		"broken.tcl":     "puts \"unterminated",
+		"error.tcl":      "unknown",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %s", err)
+		}
+	}
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		// Absolute paths
+		{Input: `source ` + filepath.Join(dir, "main.tcl"), Output: "21"},
+		{Input: `source ` + filepath.Join(dir, "return.tcl") + `; set a`, Output: "1"},
+		{Input: `source ` + filepath.Join(dir, "return.tcl"), Output: "5"},
+
+		// Files are executed in the current scope
+		{Input: `proc p {} { source ` + filepath.Join(dir, "local.tcl") + `; set local }; p`, Output: "3"},
+		{Input: `proc p {} { source ` + filepath.Join(dir, "local.tcl") + ` }; p; set local`, Output: ""},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+
+	// Relative paths are relative to the script being executed.
+	e, er := New(`source lib/helper.tcl; helper 2`, WithScriptPath(filepath.Join(dir, "script.tcl")))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	out, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "14" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+
+	// Errors
+	errs := map[string]string{
+		`source`: `source only accepts one argument`,
+		`source ` + filepath.Join(dir, "missing.tcl"): `couldn't read file`,
+		`source ` + filepath.Join(dir, "broken.tcl"):  `error parsing`,
+		`source ` + filepath.Join(dir, "error.tcl"):   `unknown command 'unknown'`,
+	}
+	for input, expected := range errs {
+		e, er := New(input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected error %q running %s, got %s", expected, input, err)
+		}
+	}
+}
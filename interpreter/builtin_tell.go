@@ -0,0 +1,28 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// tell is the golang implementation of the TCL `tell` function, which
+// returns the current position within a channel, or -1 if the channel
+// doesn't support seeking.
+//
+//	tell channel
+func tell(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("tell only accepts one argument, got %d", len(args))
+	}
+
+	ch, err := i.channel(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	pos, err := ch.tell()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(pos, 10), nil
+}
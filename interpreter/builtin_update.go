@@ -0,0 +1,36 @@
+package interpreter
+
+import "fmt"
+
+// update is the golang implementation of the TCL `update` function, which
+// runs events until there are none ready, without waiting for more.
+//
+//	update ?idletasks?
+//
+// Given "idletasks" only the scripts scheduled via `after idle` are run.
+func update(i *Interpreter, args []string) (string, error) {
+	if len(args) > 1 {
+		return "", fmt.Errorf("wrong # args: should be \"update ?idletasks?\"")
+	}
+
+	if len(args) == 1 {
+		if args[0] != "idletasks" {
+			return "", fmt.Errorf("bad option \"%s\": must be idletasks", args[0])
+		}
+		idle := i.events.idle
+		i.events.idle = nil
+		for _, ev := range idle {
+			if out, err := i.runHandler(ev.script); err != nil {
+				return out, err
+			}
+		}
+		return "", nil
+	}
+
+	for {
+		ran, out, err := i.runEvents(false)
+		if err != nil || !ran {
+			return out, err
+		}
+	}
+}
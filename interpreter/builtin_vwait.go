@@ -0,0 +1,16 @@
+package interpreter
+
+import "fmt"
+
+// vwait is the golang implementation of the TCL `vwait` function, which
+// runs the event loop until the named variable has been set.
+//
+//	vwait varName
+//
+// It is an error if there are no events which could ever set it.
+func vwait(i *Interpreter, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("vwait only accepts one argument, got %d", len(args))
+	}
+	return i.vwait(args[0])
+}
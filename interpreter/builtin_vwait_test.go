@@ -0,0 +1,75 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVwait(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `after 0 {set done 1}; vwait done; set done`, Output: "1"},
+		{Input: `after 10 {set done 1}; after 5 {set a 2}; vwait a; set a`, Output: "2"},
+		{Input: `set n 0; proc tick {} { after 1 { incr n; if { expr $n < 3 } { after 1 tick } else { set done 1 } } }; tick; vwait done; set n`, Output: "3"},
+		{Input: `after idle {set done 1}; vwait done`, Output: ""},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+}
+
+func TestVwaitForever(t *testing.T) {
+
+	e, er := New(`vwait done`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	_, err := e.Evaluate()
+	if err == nil || !strings.Contains(err.Error(), `can't wait for variable "done": would wait forever`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestVwaitClock tests that vwait waits for the clock to reach a timer.
+func TestVwaitClock(t *testing.T) {
+
+	clock := NewFakeClock(time.Now())
+
+	e, er := New(``, WithClock(clock))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := e.Eval(`after 1000 {set done 1}; vwait done`)
+		done <- err
+	}()
+
+	start := clock.Now()
+	advance(t, clock, done)
+
+	if clock.Now().Sub(start) < time.Second {
+		t.Fatalf("vwait returned before the timer was due")
+	}
+}
@@ -26,6 +26,12 @@ func while(i *Interpreter, args []string) (string, error) {
 	// Run the body, and repeat until the conditional fails
 	for tmp != "0" && tmp != "" {
 
+		// Stop if we've been cancelled.
+		err = i.cancelled()
+		if err != nil {
+			return "", err
+		}
+
 		// run the body
 		out, err = i.Eval(body)
 
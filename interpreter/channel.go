@@ -0,0 +1,467 @@
+package interpreter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// channel is a stream which a script may read from, or write to, such as
+// "stdin", "stdout", or a file which has been opened.
+type channel struct {
+
+	// reader is used to read from the channel, if it is readable.
+	reader *bufio.Reader
+
+	// readMutex must be held while using the reader, which may also be
+	// used by the goroutine watching for the channel to be readable, on
+	// behalf of `fileevent`.
+	readMutex sync.Mutex
+
+	// writer is used to write to the channel, if it is writable.
+	writer io.Writer
+
+	// buffer holds output which hasn't yet been written to the writer,
+	// if the channel is writable.
+	buffer *bufio.Writer
+
+	// source is the stream the reader reads from, which is required to
+	// reset the reader after seeking.
+	source io.Reader
+
+	// closer is used to close the channel, if it must be closed.
+	closer io.Closer
+
+	// seeker is used to change our position, if the channel supports it.
+	seeker io.Seeker
+
+	// local and remote are the addresses of a socket.
+	local  net.Addr
+	remote net.Addr
+
+	// eof is set when a read has reached the end of the input.
+	eof bool
+
+	// skipNewline is set when a line read with the "auto" translation
+	// ended with a carriage-return, in which case a newline which
+	// follows it is part of the same line-ending.
+	skipNewline bool
+
+	// translation, encoding, and buffering are the options which may
+	// be changed via `fconfigure`.
+	translation string
+	encoding    string
+	buffering   string
+}
+
+// newChannel creates a channel which reads from, and writes to, the given
+// streams, either of which may be nil.
+func newChannel(r io.Reader, w io.Writer) *channel {
+	c := &channel{
+		source:      r,
+		writer:      w,
+		translation: "auto",
+		encoding:    "utf-8",
+		buffering:   "full",
+	}
+	if r != nil {
+		c.reader = bufio.NewReader(r)
+	}
+	if w != nil {
+		c.buffer = bufio.NewWriter(w)
+	}
+	return c
+}
+
+// setupChannels creates the standard channels, using the readers and
+// writers the interpreter was configured with.
+//
+// A standard channel is only created if we have a stream for it, which
+// is not always the case for a safe interpreter.  The standard channels
+// are unbuffered.  Their output is serialized, as
+// background processes started via `exec` may write to them at the same
+// time as we do.
+func (i *Interpreter) setupChannels() {
+	i.stdout = lockWriter(i.stdout)
+	i.stderr = lockWriter(i.stderr)
+
+	i.channels = make(map[string]*channel)
+	if i.stdin != nil {
+		i.channels["stdin"] = newChannel(i.stdin, nil)
+	}
+	if i.stdout != nil {
+		i.channels["stdout"] = newChannel(nil, i.stdout)
+	}
+	if i.stderr != nil {
+		i.channels["stderr"] = newChannel(nil, i.stderr)
+	}
+	for _, ch := range i.channels {
+		ch.buffering = "none"
+	}
+}
+
+// addChannel adds a channel to our channel-table, and returns the name
+// it was given.
+func (i *Interpreter) addChannel(prefix string, c *channel) string {
+	for {
+		name := fmt.Sprintf("%s%d", prefix, i.nextChannel)
+		i.nextChannel++
+		if _, ok := i.channels[name]; !ok {
+			i.channels[name] = c
+			return name
+		}
+	}
+}
+
+// channel returns the named channel.
+func (i *Interpreter) channel(name string) (*channel, error) {
+	ch, ok := i.channels[name]
+	if !ok {
+		return nil, fmt.Errorf("can not find channel named \"%s\"", name)
+	}
+	return ch, nil
+}
+
+// readChannel returns the named channel, which must be readable.
+func (i *Interpreter) readChannel(name string) (*channel, error) {
+	ch, err := i.channel(name)
+	if err != nil {
+		return nil, err
+	}
+	if ch.reader == nil {
+		return nil, fmt.Errorf("channel \"%s\" wasn't opened for reading", name)
+	}
+	return ch, nil
+}
+
+// writeChannel returns the named channel, which must be writable.
+func (i *Interpreter) writeChannel(name string) (*channel, error) {
+	ch, err := i.channel(name)
+	if err != nil {
+		return nil, err
+	}
+	if ch.writer == nil {
+		return nil, fmt.Errorf("channel \"%s\" wasn't opened for writing", name)
+	}
+	return ch, nil
+}
+
+// closeChannel flushes, closes, and removes the named channel.
+func (i *Interpreter) closeChannel(name string) error {
+	ch, err := i.channel(name)
+	if err != nil {
+		return err
+	}
+	delete(i.channels, name)
+	i.removeFileEvent(name)
+	return ch.close()
+}
+
+// Close closes any channels the script opened, which ensures that any
+// output which was buffered is written, and that servers created via
+// `socket -server` stop listening.  It also kills any processes the
+// script left running in the background.
+//
+// The standard channels are not closed.
+func (i *Interpreter) Close() error {
+
+	if i.jobs != nil {
+		i.jobs.kill()
+	}
+
+	// Close in a stable order.
+	names := make([]string, 0, len(i.channels))
+	for name := range i.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var first error
+	for _, name := range names {
+		if isStandardChannel(name) {
+			continue
+		}
+		if err := i.closeChannel(name); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// isStandardChannel returns true if the name is that of a standard channel.
+func isStandardChannel(name string) bool {
+	for _, std := range standardChannels {
+		if name == std {
+			return true
+		}
+	}
+	return false
+}
+
+// readLine reads a single line from the channel, without the trailing
+// newline.  The boolean return value is false if there was no line to
+// be read, because the end of the input was reached.
+func (c *channel) readLine() (string, bool, error) {
+	c.readMutex.Lock()
+	data, err := c.readLineBytes()
+	c.readMutex.Unlock()
+
+	line := c.translateInput(c.decode(data))
+
+	if err == io.EOF {
+		c.eof = true
+		return line, line != "", nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimSuffix(line, "\n"), true, nil
+}
+
+// readLineBytes reads a single line from the channel, including the
+// line-ending, which depends upon our translation.
+func (c *channel) readLineBytes() ([]byte, error) {
+	c.skipLineEnding()
+
+	switch c.translation {
+	case "cr":
+		return c.reader.ReadBytes('\r')
+	case "auto":
+		var line []byte
+		for {
+			b, err := c.reader.ReadByte()
+			if err != nil {
+				return line, err
+			}
+			line = append(line, b)
+			if b == '\n' {
+				return line, nil
+			}
+			if b == '\r' {
+				// We only look for a newline which follows if it
+				// has already arrived, rather than waiting for more
+				// input, and otherwise skip it upon the next read.
+				if c.reader.Buffered() == 0 {
+					c.skipNewline = true
+				} else if next, _ := c.reader.Peek(1); next[0] == '\n' {
+					_, _ = c.reader.ReadByte()
+					line = append(line, '\n')
+				}
+				return line, nil
+			}
+		}
+	}
+	return c.reader.ReadBytes('\n')
+}
+
+// skipLineEnding discards the newline which completes a line-ending, if
+// the previous line ended with a carriage-return.
+func (c *channel) skipLineEnding() {
+	if !c.skipNewline {
+		return
+	}
+	c.skipNewline = false
+
+	next, err := c.reader.Peek(1)
+	if err == nil && next[0] == '\n' {
+		_, _ = c.reader.ReadByte()
+	}
+}
+
+// readAll reads all the remaining input from the channel.
+func (c *channel) readAll() (string, error) {
+	c.readMutex.Lock()
+	c.skipLineEnding()
+	data, err := io.ReadAll(c.reader)
+	c.readMutex.Unlock()
+
+	c.eof = true
+	return c.translateInput(c.decode(data)), err
+}
+
+// readChars reads up to the given number of characters from the channel.
+func (c *channel) readChars(n int) (string, error) {
+	c.readMutex.Lock()
+	defer c.readMutex.Unlock()
+
+	c.skipLineEnding()
+
+	var out strings.Builder
+	for count := 0; count < n; count++ {
+		var r rune
+		var err error
+		if c.encoding == "utf-8" {
+			r, _, err = c.reader.ReadRune()
+		} else {
+			var b byte
+			b, err = c.reader.ReadByte()
+			r = rune(b)
+		}
+		if err == io.EOF {
+			c.eof = true
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		// Line-endings count as a single character.
+		if r == '\r' && c.translation != "lf" && c.translation != "binary" {
+			next, err := c.reader.Peek(1)
+			if err == nil && next[0] == '\n' && c.translation != "cr" {
+				_, _ = c.reader.ReadByte()
+				r = '\n'
+			} else if c.translation != "crlf" {
+				r = '\n'
+			}
+		}
+		out.WriteRune(r)
+	}
+	return out.String(), nil
+}
+
+// write writes the string to the channel, flushing it if our buffering
+// requires that.
+func (c *channel) write(str string) error {
+
+	_, err := c.buffer.Write(c.encode(c.translateOutput(str)))
+	if err != nil {
+		return err
+	}
+
+	if c.buffering == "none" || (c.buffering == "line" && strings.Contains(str, "\n")) {
+		return c.buffer.Flush()
+	}
+	return nil
+}
+
+// flush writes any buffered output.
+func (c *channel) flush() error {
+	if c.buffer == nil {
+		return nil
+	}
+	return c.buffer.Flush()
+}
+
+// close flushes and closes the channel.
+func (c *channel) close() error {
+	err := c.flush()
+	if c.closer != nil {
+		if cerr := c.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// seek changes our position within the channel.
+func (c *channel) seek(offset int64, whence int) error {
+	if c.seeker == nil {
+		return fmt.Errorf("channel doesn't support seeking")
+	}
+
+	err := c.flush()
+	if err != nil {
+		return err
+	}
+
+	c.readMutex.Lock()
+	defer c.readMutex.Unlock()
+
+	// Any input we've buffered hasn't been read by the script.
+	if whence == io.SeekCurrent && c.reader != nil {
+		offset -= int64(c.reader.Buffered())
+	}
+
+	_, err = c.seeker.Seek(offset, whence)
+	if err != nil {
+		return err
+	}
+
+	if c.reader != nil {
+		c.reader.Reset(c.source)
+	}
+	c.eof = false
+	c.skipNewline = false
+	return nil
+}
+
+// tell returns our position within the channel, or -1 if the channel
+// doesn't support seeking.
+func (c *channel) tell() (int64, error) {
+	if c.seeker == nil {
+		return -1, nil
+	}
+
+	pos, err := c.seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1, err
+	}
+	if c.reader != nil {
+		c.readMutex.Lock()
+		pos -= int64(c.reader.Buffered())
+		c.readMutex.Unlock()
+	}
+	if c.buffer != nil {
+		pos += int64(c.buffer.Buffered())
+	}
+	return pos, nil
+}
+
+// translateInput converts the line-endings of input to newlines.
+func (c *channel) translateInput(str string) string {
+	switch c.translation {
+	case "auto":
+		str = strings.ReplaceAll(str, "\r\n", "\n")
+		return strings.ReplaceAll(str, "\r", "\n")
+	case "crlf":
+		return strings.ReplaceAll(str, "\r\n", "\n")
+	case "cr":
+		return strings.ReplaceAll(str, "\r", "\n")
+	}
+	return str
+}
+
+// translateOutput converts newlines to the line-endings of our output.
+func (c *channel) translateOutput(str string) string {
+	switch c.translation {
+	case "crlf":
+		return strings.ReplaceAll(str, "\n", "\r\n")
+	case "cr":
+		return strings.ReplaceAll(str, "\n", "\r")
+	}
+	return str
+}
+
+// decode converts input, in our encoding, to a string.
+func (c *channel) decode(data []byte) string {
+	if c.encoding == "utf-8" {
+		return string(data)
+	}
+
+	runes := make([]rune, len(data))
+	for n, b := range data {
+		runes[n] = rune(b)
+	}
+	return string(runes)
+}
+
+// encode converts a string to output in our encoding.
+//
+// Characters which can't be represented are truncated to a single byte.
+func (c *channel) encode(str string) []byte {
+	if c.encoding == "utf-8" {
+		return []byte(str)
+	}
+
+	out := make([]byte, 0, len(str))
+	for _, r := range str {
+		out = append(out, byte(r))
+	}
+	return out
+}
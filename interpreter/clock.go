@@ -0,0 +1,116 @@
+package interpreter
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time used by the interpreter, for example when
+// scheduling the scripts given to `after`.
+//
+// The default uses the real time, but hosts may supply their own via
+// WithClock, for example a FakeClock to make tests deterministic.
+type Clock interface {
+
+	// Now returns the current time.
+	Now() time.Time
+
+	// After returns a channel which receives the current time once
+	// the given duration has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// WithClock sets the source of time the interpreter uses.
+func WithClock(c Clock) Option {
+	return func(i *Interpreter) {
+		i.clock = c
+	}
+}
+
+// systemClock is a Clock which uses the real time.
+type systemClock struct{}
+
+// Now returns the current time.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse.
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// FakeClock is a Clock whose time only changes when it is advanced, which
+// allows the timing of scripts to be tested deterministically.
+//
+// A FakeClock is safe for concurrent use, so one goroutine may advance the
+// clock while another is waiting upon it.
+type FakeClock struct {
+
+	// mutex protects our state.
+	mutex sync.Mutex
+
+	// now is the current time.
+	now time.Time
+
+	// waiters holds the channels returned by After, which haven't yet
+	// received the time.
+	waiters []fakeWaiter
+}
+
+// fakeWaiter is a channel waiting for a FakeClock to reach a given time.
+type fakeWaiter struct {
+	due time.Time
+	ch  chan time.Time
+}
+
+// NewFakeClock creates a FakeClock, set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time.
+func (f *FakeClock) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.now
+}
+
+// After returns a channel which receives the time once the clock has been
+// advanced by the given duration.
+func (f *FakeClock) After(d time.Duration) <-chan time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, fakeWaiter{due: f.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by the given duration, waking anything
+// which is waiting for a time which has now been reached.
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.now = f.now.Add(d)
+
+	sort.SliceStable(f.waiters, func(a, b int) bool {
+		return f.waiters[a].due.Before(f.waiters[b].due)
+	})
+
+	remaining := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.due.After(f.now) {
+			remaining = append(remaining, w)
+			continue
+		}
+		w.ch <- f.now
+	}
+	f.waiters = remaining
+}
@@ -0,0 +1,103 @@
+package interpreter
+
+import (
+	"context"
+
+	"github.com/skx/critical/environment"
+)
+
+// Clone returns a copy of the interpreter, which may be used independently
+// of the original, including from a different goroutine.
+//
+// The copy has the same builtins, procedures, global variables, options
+// and program as the original.  The parsed bodies of procedures are shared
+// rather than copied, since they are never modified, which makes cloning
+// cheap.
+//
+// The standard channels of the copy use the same readers and writers as
+// the original, so these must be safe for concurrent use if the copies
+// are used concurrently.  Child interpreters, other channels, and pending
+// events are not copied, and the copy of a child has no parent.
+func (i *Interpreter) Clone() *Interpreter {
+	c := &Interpreter{}
+	c.copyFrom(i)
+	return c
+}
+
+// copyFrom resets the state of the interpreter to be a copy of the source,
+// reusing any maps we've already allocated.
+func (i *Interpreter) copyFrom(src *Interpreter) {
+
+	// Anything the previous script left open is closed first, so that
+	// its buffered output is written, and nothing is leaked.
+	i.Close()
+
+	if i.builtins == nil {
+		i.builtins = make(map[string]HostFunction, len(src.builtins))
+		i.hidden = make(map[string]HostFunction, len(src.hidden))
+		i.functions = make(map[string]UserFunction, len(src.functions))
+		i.packages = make(map[string]string, len(src.packages))
+		i.ifneeded = make(map[string]map[string]string, len(src.ifneeded))
+		i.indexes = make(map[string]bool, len(src.indexes))
+	}
+
+	copyMap(i.builtins, src.builtins)
+	copyMap(i.hidden, src.hidden)
+	copyMap(i.functions, src.functions)
+	copyMap(i.packages, src.packages)
+	copyMap(i.indexes, src.indexes)
+
+	// The scripts for each package are never modified, only replaced,
+	// so they may be shared.
+	copyMap(i.ifneeded, src.ifneeded)
+
+	i.globals = environment.New()
+	for k, v := range src.globals.Variables() {
+		i.globals.SetLocal(k, v)
+	}
+	i.environment = i.globals
+
+	i.program = src.program
+	i.scriptPath = src.scriptPath
+	i.safe = src.safe
+	i.comments = src.comments
+	i.stdin = src.stdin
+	i.stdout = src.stdout
+	i.stderr = src.stderr
+	i.filesystem = src.filesystem
+	i.clock = src.clock
+	i.httpClient = src.httpClient
+	i.routes = append(i.routes[:0], src.routes...)
+	i.listenAddress = src.listenAddress
+	i.maxCommands = src.maxCommands
+	i.maxDepth = src.maxDepth
+	i.maxValueSize = src.maxValueSize
+	i.maxVariables = src.maxVariables
+
+	i.ctx = context.Background()
+	i.commands = 0
+	i.depth = 0
+	i.children = make(map[string]*Interpreter)
+	i.parent = nil
+
+	// Close has killed any background processes, so we may forget them.
+	i.jobs = nil
+
+	if i.events != nil {
+		i.events.stop()
+	}
+	i.events = newEventLoop()
+	i.deleted = false
+
+	i.setupChannels()
+}
+
+// copyMap replaces the contents of dst with those of src.
+func copyMap[V any](dst map[string]V, src map[string]V) {
+	for k := range dst {
+		delete(dst, k)
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+}
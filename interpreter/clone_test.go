@@ -0,0 +1,94 @@
+package interpreter
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+
+	e, err := New(`set x 1; proc double {n} { expr $n * 2 }`, WithSafe(), WithMaxDepth(10))
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	_, err = e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c := e.Clone()
+
+	// The clone has the procedures, variables, and options of the original
+	out, err := c.Eval(`double $x`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "2" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if !c.IsSafe() {
+		t.Fatalf("clone isn't safe")
+	}
+	if len(c.HiddenCommands()) != len(e.HiddenCommands()) {
+		t.Fatalf("clone has different hidden commands")
+	}
+
+	// But changes to the clone don't affect the original
+	_, err = c.Eval(`set x 10; set y 3; proc double {n} { expr $n * 3 }`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	c.RegisterBuiltin("new", set)
+
+	out, err = e.Eval(`double $x`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "2" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if _, ok := e.GetVariable("y"); ok {
+		t.Fatalf("variable leaked from clone")
+	}
+	if _, ok := e.builtins["new"]; ok {
+		t.Fatalf("builtin leaked from clone")
+	}
+
+	// The program is shared, and can be evaluated by the clone.
+	out, err = e.Clone().Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestCloneConcurrent(t *testing.T) {
+
+	e, err := New(`proc fib {n} { if { expr $n < 2 } { return $n }; expr [fib [expr $n - 1]] + [fib [expr $n - 2]] }`)
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	_, err = e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	for n := 0; n < 8; n++ {
+		wg.Add(1)
+		go func(c *Interpreter) {
+			defer wg.Done()
+
+			out, err := c.Eval(`set n 10; fib $n`)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if out != "55" {
+				t.Errorf("unexpected output: %s", out)
+			}
+		}(e.Clone())
+	}
+	wg.Wait()
+}
@@ -0,0 +1,48 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrCancelled is returned when execution is stopped because the
+	// context it was running with was cancelled, or timed out.
+	ErrCancelled = errors.New("execution cancelled")
+)
+
+// EvaluateContext executes the program, like Evaluate, but stops with
+// ErrCancelled if the given context is cancelled while it is running.
+//
+// This allows a host to impose a timeout on the scripts it runs.
+func (i *Interpreter) EvaluateContext(ctx context.Context) (string, error) {
+	defer i.withContext(ctx)()
+	return i.Evaluate()
+}
+
+// EvalContext evaluates the given string, like Eval, but stops with
+// ErrCancelled if the given context is cancelled while it is running.
+func (i *Interpreter) EvalContext(ctx context.Context, str string) (string, error) {
+	defer i.withContext(ctx)()
+	return i.Eval(str)
+}
+
+// withContext makes the given context live, and returns a function which
+// will restore the previous one.
+func (i *Interpreter) withContext(ctx context.Context) func() {
+	old := i.ctx
+	i.ctx = ctx
+	return func() {
+		i.ctx = old
+	}
+}
+
+// cancelled returns an error wrapping ErrCancelled if our context has been
+// cancelled, and nil otherwise.
+func (i *Interpreter) cancelled() error {
+	if err := i.ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %s", ErrCancelled, err)
+	}
+	return nil
+}
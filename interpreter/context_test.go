@@ -0,0 +1,84 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEvaluateContext(t *testing.T) {
+
+	tests := []string{
+		`while {1} {}`,
+		`while {1} { set a 1 }`,
+		`for {set i 0} {1} {incr i} {}`,
+		`proc forever {} { while {1} { incr x } }; forever`,
+		`set a [while {1} {}]`,
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+		_, err := e.EvaluateContext(ctx)
+		cancel()
+
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", test)
+		}
+		if !errors.Is(err, ErrCancelled) {
+			t.Fatalf("expected cancellation running %s, got %s", test, err)
+		}
+	}
+}
+
+func TestEvalContext(t *testing.T) {
+
+	e, er := New("")
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	// A context which is already cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := e.EvalContext(ctx, `set a 1`)
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+
+	// The context doesn't persist after the call.
+	out, err := e.Eval(`set a 1`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "1" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+
+	// Host functions receive the context.
+	type key string
+	err = e.RegisterFunc("value", func(ctx context.Context) string {
+		v, _ := ctx.Value(key("name")).(string)
+		return v
+	})
+	if err != nil {
+		t.Fatalf("unexpected error registering function: %s", err)
+	}
+
+	ctx = context.WithValue(context.Background(), key("name"), "steve")
+	out, err = e.EvalContext(ctx, `value`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "steve" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
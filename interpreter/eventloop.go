@@ -0,0 +1,400 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// errNoEvents is returned when waiting for an event, if there are none
+// which could ever happen.
+var errNoEvents = errors.New("no events are pending")
+
+// eventLoop holds the events scheduled by `after` and `fileevent`.
+type eventLoop struct {
+
+	// timers holds the scripts scheduled via `after ms script`, in the
+	// order they're due.
+	timers []*timerEvent
+
+	// idle holds the scripts scheduled via `after idle script`.
+	idle []*timerEvent
+
+	// nextID is used to generate the identifiers of new timers.
+	nextID int
+
+	// files holds the readable handlers of each channel.
+	files map[string]*fileEvent
+
+	// listeners counts the servers, created via `socket -server`,
+	// which may post events when they accept a connection.
+	listeners int
+
+	// posted receives events from other goroutines, such as channels
+	// becoming readable, which must be handled by the loop.
+	posted chan func() (string, error)
+
+	// watching counts the calls to `vwait` waiting for each variable,
+	// and changed records whether those variables have been set.
+	watching map[string]int
+	changed  map[string]bool
+}
+
+// timerEvent is a script scheduled via `after`.
+type timerEvent struct {
+	id     string
+	seq    int
+	due    time.Time
+	script string
+}
+
+// fileEvent is the script to run when a channel becomes readable.
+type fileEvent struct {
+
+	// script is the script to run.
+	script string
+
+	// stop is closed when the handler is removed.
+	stop chan struct{}
+
+	// ack is signalled when the script has been run, which allows
+	// the watcher to look for more input.
+	ack chan struct{}
+}
+
+// newEventLoop creates an empty event loop.
+func newEventLoop() *eventLoop {
+	return &eventLoop{
+		files:    make(map[string]*fileEvent),
+		posted:   make(chan func() (string, error), 16),
+		watching: make(map[string]int),
+		changed:  make(map[string]bool),
+	}
+}
+
+// RunEventLoop processes the events scheduled by scripts, for example via
+// `after` or `fileevent`, until there are none left or the context is
+// cancelled, in which case the error matches ErrCancelled.
+//
+// Scripts which raise errors are reported via the `bgerror` procedure,
+// if one is defined, or to stderr otherwise.  If a script calls `exit`
+// then ErrExit is returned, along with the exit code.
+func (i *Interpreter) RunEventLoop(ctx context.Context) (string, error) {
+	defer i.withContext(ctx)()
+
+	for {
+		_, out, err := i.runEvents(true)
+		if err == errNoEvents {
+			return "", nil
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+}
+
+// after schedules a script to run after the given delay, and returns its
+// identifier.
+func (i *Interpreter) after(delay time.Duration, script string) string {
+	l := i.events
+
+	ev := &timerEvent{id: fmt.Sprintf("after#%d", l.nextID), seq: l.nextID, due: i.clock.Now().Add(delay), script: script}
+	l.nextID++
+
+	// Keep the timers in the order they're due, with those due at the
+	// same time in the order they were created.
+	n := sort.Search(len(l.timers), func(n int) bool {
+		return l.timers[n].due.After(ev.due)
+	})
+	l.timers = append(l.timers, nil)
+	copy(l.timers[n+1:], l.timers[n:])
+	l.timers[n] = ev
+
+	return ev.id
+}
+
+// afterIdle schedules a script to run when the loop is next idle, and
+// returns its identifier.
+func (i *Interpreter) afterIdle(script string) string {
+	l := i.events
+
+	ev := &timerEvent{id: fmt.Sprintf("after#%d", l.nextID), seq: l.nextID, script: script}
+	l.nextID++
+	l.idle = append(l.idle, ev)
+	return ev.id
+}
+
+// runEvents runs each of the events which are ready, and returns true if
+// there were any.  If none are ready, and wait is true, we wait for one.
+//
+// The string returned is the exit code, if a script called `exit`.
+func (i *Interpreter) runEvents(wait bool) (bool, string, error) {
+	l := i.events
+
+	for {
+		if err := i.cancelled(); err != nil {
+			return false, "", err
+		}
+
+		ran := false
+
+		// Timers which are due, excluding any they schedule
+		// themselves.
+		now := i.clock.Now()
+		limit := l.nextID
+		for len(l.timers) > 0 && !l.timers[0].due.After(now) && l.timers[0].seq < limit {
+			ev := l.timers[0]
+			l.timers = l.timers[1:]
+			ran = true
+			if out, err := i.runHandler(ev.script); err != nil {
+				return ran, out, err
+			}
+		}
+
+		// Events from other goroutines.
+		for more := true; more; {
+			select {
+			case fn := <-l.posted:
+				ran = true
+				if out, err := fn(); err != nil {
+					return ran, out, err
+				}
+			default:
+				more = false
+			}
+		}
+
+		// Idle handlers, excluding any they schedule themselves.
+		idle := l.idle
+		l.idle = nil
+		for _, ev := range idle {
+			ran = true
+			if out, err := i.runHandler(ev.script); err != nil {
+				return ran, out, err
+			}
+		}
+
+		if ran || !wait {
+			return ran, "", nil
+		}
+
+		// Wait for something to happen.
+		if len(l.timers) == 0 && len(l.files) == 0 && l.listeners == 0 {
+			return false, "", errNoEvents
+		}
+
+		var timer <-chan time.Time
+		if len(l.timers) > 0 {
+			timer = i.clock.After(l.timers[0].due.Sub(i.clock.Now()))
+		}
+
+		select {
+		case <-i.ctx.Done():
+		case <-timer:
+		case fn := <-l.posted:
+			out, err := fn()
+			return true, out, err
+		}
+	}
+}
+
+// runHandler runs a script scheduled by the event loop, at the global
+// scope.
+//
+// Errors are reported, rather than returned, unless they're fatal or the
+// script called `exit`.
+func (i *Interpreter) runHandler(script string) (string, error) {
+
+	old := i.environment
+	i.environment = i.globals
+	out, err := i.Eval(script)
+	i.environment = old
+
+	if err == nil || err == ErrReturn || err == errBreak || err == errContinue {
+		return "", nil
+	}
+	if err == ErrExit || isFatal(err) {
+		return out, err
+	}
+
+	return i.backgroundError(err)
+}
+
+// backgroundError reports an error raised by a script run by the event
+// loop, via the `bgerror` procedure if one has been defined.
+func (i *Interpreter) backgroundError(err error) (string, error) {
+	msg := errorMessage(err)
+
+	if _, ok := i.functions["bgerror"]; ok {
+		out, _, err := i.invoke("bgerror", []string{msg})
+		if err == ErrExit || isFatal(err) {
+			return out, err
+		}
+		return "", nil
+	}
+
+	if ch, ok := i.channels["stderr"]; ok && ch.writer != nil {
+		_ = ch.write("background error: " + msg + "\n")
+	}
+	return "", nil
+}
+
+// varChanged records that a variable has been set, which ends any `vwait`
+// which is waiting for it.
+func (i *Interpreter) varChanged(name string) {
+	if i.events.watching[name] > 0 {
+		i.events.changed[name] = true
+	}
+}
+
+// vwait runs the event loop until the named variable has been set.
+func (i *Interpreter) vwait(name string) (string, error) {
+	l := i.events
+
+	l.watching[name]++
+	l.changed[name] = false
+	defer func() {
+		l.watching[name]--
+		if l.watching[name] == 0 {
+			delete(l.watching, name)
+			delete(l.changed, name)
+		}
+	}()
+
+	for !l.changed[name] {
+		_, out, err := i.runEvents(true)
+		if err == errNoEvents {
+			return "", fmt.Errorf("can't wait for variable \"%s\": would wait forever", name)
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+	return "", nil
+}
+
+// setFileEvent sets the script to run when the named channel becomes
+// readable, replacing any existing script.  An empty script removes
+// the handler.
+func (i *Interpreter) setFileEvent(name string, ch *channel, script string) {
+	l := i.events
+
+	// Replacing a script reuses the existing watcher.
+	if old, ok := l.files[name]; ok {
+		if script != "" {
+			old.script = script
+			return
+		}
+		close(old.stop)
+		delete(l.files, name)
+	}
+	if script == "" {
+		return
+	}
+
+	fe := &fileEvent{script: script, stop: make(chan struct{}), ack: make(chan struct{}, 1)}
+	l.files[name] = fe
+
+	// The watcher waits for input, and then for the script to have
+	// run.  It holds the channel's read-lock while waiting, so that
+	// the script can't read at the same time, even after the handler
+	// has been removed.
+	go func() {
+		for {
+			ch.readMutex.Lock()
+			_, _ = ch.reader.Peek(1)
+			ch.readMutex.Unlock()
+
+			select {
+			case <-fe.stop:
+				return
+			default:
+			}
+
+			handler := func() (string, error) {
+				defer func() {
+					fe.ack <- struct{}{}
+				}()
+
+				// The handler may have been removed since.
+				if l.files[name] != fe {
+					return "", nil
+				}
+				return i.runHandler(fe.script)
+			}
+
+			select {
+			case <-fe.stop:
+				return
+			case l.posted <- handler:
+			}
+
+			select {
+			case <-fe.stop:
+				return
+			case <-fe.ack:
+			}
+		}
+	}()
+}
+
+// removeFileEvent removes any handler for the named channel.
+func (i *Interpreter) removeFileEvent(name string) {
+	if fe, ok := i.events.files[name]; ok {
+		close(fe.stop)
+		delete(i.events.files, name)
+	}
+}
+
+// stop removes every event, stopping the goroutines which watch for
+// channels becoming readable.
+func (l *eventLoop) stop() {
+	for name, fe := range l.files {
+		close(fe.stop)
+		delete(l.files, name)
+	}
+	l.timers = nil
+	l.idle = nil
+}
+
+// cancelEvent removes the timer, or idle handler, which has the given
+// identifier, or failing that the first which would run the given script.
+func (i *Interpreter) cancelEvent(key string) {
+	l := i.events
+
+	for _, match := range []func(ev *timerEvent) bool{
+		func(ev *timerEvent) bool { return ev.id == key },
+		func(ev *timerEvent) bool { return ev.script == key },
+	} {
+		for n, ev := range l.timers {
+			if match(ev) {
+				l.timers = append(l.timers[:n], l.timers[n+1:]...)
+				return
+			}
+		}
+		for n, ev := range l.idle {
+			if match(ev) {
+				l.idle = append(l.idle[:n], l.idle[n+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// findEvent returns the timer, or idle handler, with the given identifier,
+// and whether it is an idle handler.
+func (i *Interpreter) findEvent(id string) (*timerEvent, bool) {
+	for _, ev := range i.events.timers {
+		if ev.id == id {
+			return ev, false
+		}
+	}
+	for _, ev := range i.events.idle {
+		if ev.id == id {
+			return ev, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,172 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunEventLoop(t *testing.T) {
+
+	e, er := New(`set a {}; after 100 {append a 2}; after 10 {append a 1; after 5 {append a 3}}`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	_, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The loop returns once there are no events left.
+	_, err = e.RunEventLoop(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	out, _ := e.GetVariable("a")
+	if out != "132" {
+		t.Fatalf("unexpected order of events: %s", out)
+	}
+}
+
+func TestRunEventLoopCancelled(t *testing.T) {
+
+	e, er := New(`after 60000 {set a 1}`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	_, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = e.RunEventLoop(ctx)
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
+
+func TestRunEventLoopExit(t *testing.T) {
+
+	e, er := New(`after 0 {exit 3}; after 10 {set a 1}`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	_, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	out, err := e.RunEventLoop(context.Background())
+	if err != ErrExit || out != "3" {
+		t.Fatalf("expected exit, got %s %v", out, err)
+	}
+}
+
+// TestBackgroundError tests that errors raised by events are reported,
+// rather than stopping the loop.
+func TestBackgroundError(t *testing.T) {
+
+	stderr := &bytes.Buffer{}
+	e, er := New(`after 0 {set}; after 0 {set a 1}; vwait a`, WithStderr(stderr))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	_, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stderr.String() != "background error: set accepts one or two arguments, got 0\n" {
+		t.Fatalf("unexpected error output: %q", stderr.String())
+	}
+
+	// A bgerror procedure receives the message instead.
+	stdout := &bytes.Buffer{}
+	stderr.Reset()
+	e, er = New(`proc bgerror {msg} { puts "caught: $msg" }; after 0 {unknown}; update`, WithStdout(stdout), WithStderr(stderr))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	_, err = e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stdout.String() != "caught: unknown command 'unknown':unknown\n" {
+		t.Fatalf("unexpected output: %q", stdout.String())
+	}
+	if stderr.Len() != 0 {
+		t.Fatalf("unexpected error output: %q", stderr.String())
+	}
+}
+
+// TestEventsNotCloned tests that pending events aren't copied by Clone.
+func TestEventsNotCloned(t *testing.T) {
+
+	e, er := New(`after 0 {set a 1}`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	_, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c := e.Clone()
+	out, err := c.Eval(`after info`)
+	if err != nil || out != "" {
+		t.Fatalf("expected no events in the clone, got %q %v", out, err)
+	}
+
+	out, err = e.Eval(`after info`)
+	if err != nil || out != "after#0" {
+		t.Fatalf("expected the original's events to remain, got %q %v", out, err)
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	late := clock.After(2 * time.Second)
+	early := clock.After(time.Second)
+	now := clock.After(0)
+
+	select {
+	case got := <-now:
+		if !got.Equal(start) {
+			t.Fatalf("unexpected time: %s", got)
+		}
+	default:
+		t.Fatalf("expected a zero duration to fire immediately")
+	}
+
+	clock.Advance(1500 * time.Millisecond)
+
+	select {
+	case <-early:
+	default:
+		t.Fatalf("expected the earlier waiter to fire")
+	}
+	select {
+	case <-late:
+		t.Fatalf("the later waiter fired early")
+	default:
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case got := <-late:
+		if !got.Equal(start.Add(2 * time.Second)) {
+			t.Fatalf("unexpected time: %s", got)
+		}
+	default:
+		t.Fatalf("expected the later waiter to fire")
+	}
+}
@@ -0,0 +1,289 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileSystem is the interface through which scripts access files, via
+// commands such as `open`, `source`, `file`, and `glob`.
+//
+// The default is OSFileSystem, which uses the real filesystem, but hosts
+// may supply their own via WithFileSystem, for example to restrict
+// scripts to the contents of an fs.FS, or to use a MemoryFileSystem
+// in tests.
+//
+// Paths are slash-separated, whatever the host operating system, and
+// are manipulated via the path package, rather than path/filepath.
+type FileSystem interface {
+
+	// OpenFile opens the named file, with flags such as os.O_RDONLY
+	// and os.O_CREATE, in the manner of os.OpenFile.
+	OpenFile(name string, flag int, perm fs.FileMode) (File, error)
+
+	// Stat returns information about the named file.
+	Stat(name string) (fs.FileInfo, error)
+
+	// Lstat returns information about the named file, without
+	// following it if it is a symbolic link.
+	Lstat(name string) (fs.FileInfo, error)
+
+	// ReadDir returns the entries of the named directory, sorted by
+	// name.
+	ReadDir(name string) ([]fs.DirEntry, error)
+
+	// Mkdir creates the named directory, whose parent must exist.
+	Mkdir(name string, perm fs.FileMode) error
+
+	// Remove removes the named file, or empty directory.
+	Remove(name string) error
+
+	// Rename moves a file, or directory, to a new name.
+	Rename(oldpath string, newpath string) error
+
+	// CreateTemp creates a new temporary file, in the manner of
+	// os.CreateTemp, and returns it along with its name.
+	CreateTemp(dir string, pattern string) (File, string, error)
+
+	// Getwd returns the directory which relative paths are relative
+	// to.
+	Getwd() (string, error)
+}
+
+// File is a file which has been opened via a FileSystem.
+//
+// If the file also implements io.Seeker then scripts may use `seek`
+// and `tell` upon it.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+}
+
+// WithFileSystem sets the filesystem which scripts access.
+func WithFileSystem(fsys FileSystem) Option {
+	return func(i *Interpreter) {
+		i.filesystem = fsys
+	}
+}
+
+// OSFileSystem is a FileSystem which uses the real filesystem, via the
+// functions of the os package.  Paths are converted to, and from, those
+// of the host operating system.
+type OSFileSystem struct{}
+
+// OpenFile opens the named file.
+func (OSFileSystem) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	return os.OpenFile(filepath.FromSlash(name), flag, perm)
+}
+
+// Stat returns information about the named file.
+func (OSFileSystem) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(filepath.FromSlash(name))
+}
+
+// Lstat returns information about the named file, without following
+// symbolic links.
+func (OSFileSystem) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(filepath.FromSlash(name))
+}
+
+// ReadDir returns the entries of the named directory.
+func (OSFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(filepath.FromSlash(name))
+}
+
+// Mkdir creates the named directory.
+func (OSFileSystem) Mkdir(name string, perm fs.FileMode) error {
+	return os.Mkdir(filepath.FromSlash(name), perm)
+}
+
+// Remove removes the named file, or empty directory.
+func (OSFileSystem) Remove(name string) error {
+	return os.Remove(filepath.FromSlash(name))
+}
+
+// Rename moves a file, or directory, to a new name.
+func (OSFileSystem) Rename(oldpath string, newpath string) error {
+	return os.Rename(filepath.FromSlash(oldpath), filepath.FromSlash(newpath))
+}
+
+// CreateTemp creates a new temporary file.
+func (OSFileSystem) CreateTemp(dir string, pattern string) (File, string, error) {
+	f, err := os.CreateTemp(filepath.FromSlash(dir), pattern)
+	if err != nil {
+		return nil, "", err
+	}
+	return f, filepath.ToSlash(f.Name()), nil
+}
+
+// Getwd returns the current directory of the process.
+func (OSFileSystem) Getwd() (string, error) {
+	wd, err := os.Getwd()
+	return filepath.ToSlash(wd), err
+}
+
+// errReadOnly is returned when attempting to modify a read-only filesystem.
+var errReadOnly = errors.New("read-only file system")
+
+// readOnlyFileSystem adapts an fs.FS to our FileSystem interface.
+type readOnlyFileSystem struct {
+	fsys fs.FS
+}
+
+// ReadOnlyFileSystem returns a FileSystem which allows scripts to read
+// the contents of the given fs.FS, such as an embed.FS, but not to
+// modify them.
+//
+// Paths are slash-separated, and are relative to the root of the fs.FS
+// whether they begin with a slash or not.
+func ReadOnlyFileSystem(fsys fs.FS) FileSystem {
+	return &readOnlyFileSystem{fsys: fsys}
+}
+
+// name converts the given path to the form an fs.FS expects.
+func (r *readOnlyFileSystem) name(op string, name string) (string, error) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return name, nil
+}
+
+// OpenFile opens the named file, which must be opened for reading only.
+func (r *readOnlyFileSystem) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errReadOnly}
+	}
+
+	n, err := r.name("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := r.fsys.Open(n)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := f.(io.Seeker); ok {
+		return &readOnlySeekableFile{readOnlyFile{f}}, nil
+	}
+	return &readOnlyFile{f}, nil
+}
+
+// Stat returns information about the named file.
+func (r *readOnlyFileSystem) Stat(name string) (fs.FileInfo, error) {
+	n, err := r.name("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(r.fsys, n)
+}
+
+// Lstat returns information about the named file.  An fs.FS has no
+// symbolic links of its own, so this is the same as Stat.
+func (r *readOnlyFileSystem) Lstat(name string) (fs.FileInfo, error) {
+	n, err := r.name("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(r.fsys, n)
+}
+
+// ReadDir returns the entries of the named directory.
+func (r *readOnlyFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	n, err := r.name("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(r.fsys, n)
+}
+
+// Mkdir always fails, as the filesystem is read-only.
+func (r *readOnlyFileSystem) Mkdir(name string, perm fs.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: name, Err: errReadOnly}
+}
+
+// Remove always fails, as the filesystem is read-only.
+func (r *readOnlyFileSystem) Remove(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: errReadOnly}
+}
+
+// Rename always fails, as the filesystem is read-only.
+func (r *readOnlyFileSystem) Rename(oldpath string, newpath string) error {
+	return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errReadOnly}
+}
+
+// CreateTemp always fails, as the filesystem is read-only.
+func (r *readOnlyFileSystem) CreateTemp(dir string, pattern string) (File, string, error) {
+	return nil, "", &fs.PathError{Op: "createtemp", Path: path.Join(dir, pattern), Err: errReadOnly}
+}
+
+// Getwd returns the root, as paths are relative to it.
+func (r *readOnlyFileSystem) Getwd() (string, error) {
+	return "/", nil
+}
+
+// readOnlyFile is a file opened from an fs.FS.
+type readOnlyFile struct {
+	fs.File
+}
+
+// Write always fails, as the file is read-only.
+func (f *readOnlyFile) Write(p []byte) (int, error) {
+	return 0, errReadOnly
+}
+
+// readOnlySeekableFile is a file opened from an fs.FS, which supports
+// seeking.
+type readOnlySeekableFile struct {
+	readOnlyFile
+}
+
+// Seek changes our position within the file.
+func (f *readOnlySeekableFile) Seek(offset int64, whence int) (int64, error) {
+	return f.File.(io.Seeker).Seek(offset, whence)
+}
+
+// readFile returns the contents of the named file, read via our
+// filesystem.
+func (i *Interpreter) readFile(name string) ([]byte, error) {
+	f, err := i.filesystem.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// fileError converts an error from our filesystem into the form scripts
+// expect, for example `could not read "x": no such file or directory`.
+func fileError(action string, name string, err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		err = pe.Err
+	}
+	var le *os.LinkError
+	if errors.As(err, &le) {
+		err = le.Err
+	}
+
+	msg := err.Error()
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		msg = "no such file or directory"
+	case errors.Is(err, fs.ErrExist):
+		msg = "file already exists"
+	case errors.Is(err, fs.ErrPermission):
+		msg = "permission denied"
+	}
+	return fmt.Errorf("%s \"%s\": %s", action, name, msg)
+}
@@ -0,0 +1,278 @@
+package interpreter
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestReadOnlyFileSystem(t *testing.T) {
+
+	fsys := ReadOnlyFileSystem(fstest.MapFS{
+		"config.txt":     {Data: []byte("name=steve\nage=42\n")},
+		"lib/helper.tcl": {Data: []byte("proc helper {} { return 3 }")},
+		"lib/other.tcl":  {Data: []byte("")},
+	})
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `set f [open /config.txt]; gets $f`, Output: "name=steve"},
+		{Input: `set f [open config.txt]; seek $f 11; gets $f`, Output: "age=42"},
+		{Input: `source /lib/helper.tcl; helper`, Output: "3"},
+		{Input: `file exists /lib/helper.tcl`, Output: "1"},
+		{Input: `file isdirectory lib`, Output: "1"},
+		{Input: `file size /config.txt`, Output: "18"},
+		{Input: `glob -directory /lib -tails *.tcl`, Output: "helper.tcl other.tcl"},
+		{Input: `glob /*/*.tcl`, Output: "/lib/helper.tcl /lib/other.tcl"},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input, WithFileSystem(fsys))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+
+	// Errors
+	errs := map[string]string{
+		`open /config.txt w`:             `couldn't open "/config.txt": read-only file system`,
+		`open /missing.txt`:              `couldn't open "/missing.txt": no such file or directory`,
+		`file mkdir /x`:                  `can't create directory "/x": read-only file system`,
+		`file delete /config.txt`:        `error deleting "/config.txt": read-only file system`,
+		`file rename /config.txt /x.txt`: `read-only file system`,
+		`file copy /config.txt /x.txt`:   `read-only file system`,
+		`file tempfile`:                  `read-only file system`,
+		`source ../../etc/passwd`:        `no such file or directory`,
+	}
+	for input, expected := range errs {
+		e, er := New(input, WithFileSystem(fsys))
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter")
+		}
+
+		_, err := e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("wrong error running %s: %s", input, err)
+		}
+	}
+}
+
+func TestMemoryFileSystem(t *testing.T) {
+
+	m := NewMemoryFileSystem()
+
+	// Write, then read back.
+	f, err := m.OpenFile("/a.txt", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err = f.Write([]byte("Hello, world")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err = f.(io.Seeker).Seek(7, io.SeekStart); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil || string(data) != "world" {
+		t.Fatalf("unexpected read: %q %v", data, err)
+	}
+	if _, err = f.(io.Seeker).Seek(-1, io.SeekStart); err == nil {
+		t.Fatalf("expected error seeking before the start")
+	}
+	if err = f.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err = f.Close(); !errors.Is(err, fs.ErrClosed) {
+		t.Fatalf("expected closed error, got %v", err)
+	}
+	if _, err = f.Write([]byte("x")); !errors.Is(err, fs.ErrClosed) {
+		t.Fatalf("expected closed error, got %v", err)
+	}
+
+	// Appending
+	f, _ = m.OpenFile("a.txt", os.O_WRONLY|os.O_APPEND, 0)
+	f.Write([]byte("!"))
+	f.Close()
+	info, err := m.Stat("/a.txt")
+	if err != nil || info.Size() != 13 || info.IsDir() || info.Name() != "a.txt" || info.Sys() != nil {
+		t.Fatalf("unexpected stat: %v %v", info, err)
+	}
+
+	// Read-only and write-only handles.
+	f, _ = m.OpenFile("/a.txt", os.O_RDONLY, 0)
+	if _, err = f.Write([]byte("x")); err == nil {
+		t.Fatalf("expected error writing to read-only file")
+	}
+	f, _ = m.OpenFile("/a.txt", os.O_WRONLY, 0)
+	if _, err = f.Read(make([]byte, 1)); err == nil {
+		t.Fatalf("expected error reading from write-only file")
+	}
+
+	// Exclusive creation, and truncation.
+	if _, err = m.OpenFile("/a.txt", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected exists error, got %v", err)
+	}
+	f, _ = m.OpenFile("/a.txt", os.O_WRONLY|os.O_TRUNC, 0)
+	f.Close()
+	if info, _ = m.Stat("/a.txt"); info.Size() != 0 {
+		t.Fatalf("expected file to be truncated")
+	}
+
+	// Missing files and directories.
+	if _, err = m.OpenFile("/missing", os.O_RDONLY, 0); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+	if _, err = m.OpenFile("/missing/a.txt", os.O_RDWR|os.O_CREATE, 0644); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+	if _, err = m.OpenFile("/a.txt/b.txt", os.O_RDWR|os.O_CREATE, 0644); err == nil {
+		t.Fatalf("expected error creating a file beneath a file")
+	}
+	if _, err = m.OpenFile("/tmp", os.O_RDONLY, 0); err == nil {
+		t.Fatalf("expected error opening a directory")
+	}
+	if _, err = m.Stat("/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+	if _, err = m.Lstat("/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+	if info, err = m.Lstat("/tmp"); err != nil || !info.IsDir() {
+		t.Fatalf("unexpected lstat: %v %v", info, err)
+	}
+
+	// Directories
+	if err = m.Mkdir("/dir", 0755); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err = m.Mkdir("/dir", 0755); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected exists error, got %v", err)
+	}
+	if err = m.Mkdir("/missing/dir", 0755); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+	m.WriteFile("/dir/sub/b.txt", []byte("b"))
+	m.WriteFile("/dir/c.txt", []byte("c"))
+	if err = m.WriteFile("/a.txt/x", nil); err == nil {
+		t.Fatalf("expected error writing beneath a file")
+	}
+	if err = m.WriteFile("/dir", nil); err == nil {
+		t.Fatalf("expected error writing to a directory")
+	}
+
+	entries, err := m.ReadDir("/dir")
+	if err != nil || len(entries) != 2 || entries[0].Name() != "c.txt" || entries[1].Name() != "sub" || !entries[1].IsDir() {
+		t.Fatalf("unexpected entries: %v %v", entries, err)
+	}
+	if _, err = m.ReadDir("/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+	if _, err = m.ReadDir("/a.txt"); err == nil {
+		t.Fatalf("expected error reading a file as a directory")
+	}
+
+	// Removal
+	if err = m.Remove("/dir"); err == nil {
+		t.Fatalf("expected error removing non-empty directory")
+	}
+	if err = m.Remove("/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+	if err = m.Remove("/dir/c.txt"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Renaming
+	if err = m.Rename("/dir", "/moved"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err = m.Stat("/moved/sub/b.txt"); err != nil {
+		t.Fatalf("expected file to be moved: %s", err)
+	}
+	if err = m.Rename("/moved", "/moved"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err = m.Rename("/moved", "/moved/sub/x"); err == nil {
+		t.Fatalf("expected error moving a directory beneath itself")
+	}
+	if err = m.Rename("/missing", "/x"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+	if err = m.Rename("/a.txt", "/missing/x"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+	if err = m.Rename("/a.txt", "/moved"); err == nil {
+		t.Fatalf("expected error replacing a directory with a file")
+	}
+	if err = m.Rename("/moved/sub", "/a.txt"); err == nil {
+		t.Fatalf("expected error replacing a file with a directory")
+	}
+	m.Mkdir("/other", 0755)
+	if err = m.Rename("/other", "/moved"); err == nil {
+		t.Fatalf("expected error replacing a non-empty directory")
+	}
+
+	// Temporary files
+	f, name, err := m.CreateTemp("", "report-*.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	f.Close()
+	if !strings.HasPrefix(name, "/tmp/report-") || !strings.HasSuffix(name, ".txt") {
+		t.Fatalf("unexpected name: %s", name)
+	}
+	m.WriteFile("/tmp/x2", nil)
+	_, name, err = m.CreateTemp("/tmp", "x")
+	if err != nil || name != "/tmp/x3" {
+		t.Fatalf("unexpected temporary file: %s %v", name, err)
+	}
+	if _, _, err = m.CreateTemp("/missing", "x"); err == nil {
+		t.Fatalf("expected error creating a temporary file in a missing directory")
+	}
+}
+
+// TestMemoryFileSystemClock ensures the modification times of files come
+// from the clock the filesystem was created with.
+func TestMemoryFileSystemClock(t *testing.T) {
+
+	clock := NewFakeClock(time.Unix(1700000000, 0))
+	m := NewMemoryFileSystemWithClock(clock)
+	m.WriteFile("/a.txt", []byte("hello"))
+
+	e, err := New(`file mtime /a.txt`, WithFileSystem(m))
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	out, err := e.Evaluate()
+	if err != nil || out != "1700000000" {
+		t.Fatalf("unexpected mtime: %s %v", out, err)
+	}
+
+	clock.Advance(time.Minute)
+
+	out, err = e.Eval(`set f [open /a.txt a]; puts $f world; close $f; file mtime /a.txt`)
+	if err != nil || out != "1700000060" {
+		t.Fatalf("unexpected mtime: %s %v", out, err)
+	}
+}
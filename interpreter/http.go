@@ -0,0 +1,221 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultListenAddress is the address `http::serve` listens upon, unless
+// another is given.
+const DefaultListenAddress = ":8080"
+
+// MaxRequestBody is the largest request body which will be passed to a
+// procedure registered via `http::route`.
+const MaxRequestBody = 10 * 1024 * 1024
+
+// WithHTTPClient sets the client `http::geturl` uses to make requests,
+// which by default is http.DefaultClient.  Tests may supply the client
+// of an httptest.Server, for example.
+func WithHTTPClient(c *http.Client) Option {
+	return func(i *Interpreter) {
+		i.httpClient = c
+	}
+}
+
+// WithListenAddress sets the address `http::serve` listens upon when the
+// script doesn't give one, for example ":8080" or "localhost:9000".
+func WithListenAddress(addr string) Option {
+	return func(i *Interpreter) {
+		i.listenAddress = addr
+	}
+}
+
+// httpRoute is a procedure registered via `http::route`.
+type httpRoute struct {
+
+	// method is the HTTP method which is handled, or "*" for any.
+	method string
+
+	// path is the path which is handled.  If it ends with "*" then any
+	// path beginning with the rest of it is handled.
+	path string
+
+	// proc is the name of the procedure to invoke.
+	proc string
+}
+
+// matches returns true if the route handles the given path.
+func (r httpRoute) matches(path string) bool {
+	if strings.HasSuffix(r.path, "*") {
+		return strings.HasPrefix(path, strings.TrimSuffix(r.path, "*"))
+	}
+	return r.path == path
+}
+
+// HTTPHandler is an http.Handler which serves requests via the procedures
+// a script registered with `http::route`.
+//
+// Each request is handled by an interpreter taken from a Pool, so requests
+// may be handled concurrently.  The procedure is invoked with a dictionary
+// describing the request, which has the keys "method", "path", "query",
+// "headers", and "body".  The query and headers are dictionaries, with
+// the names of the headers in lower-case, and only the first value of
+// each is present.
+//
+// The procedure returns a dictionary, which may have the keys "status",
+// "headers", and "body".  The status defaults to 200.  Anything else the
+// procedure returns is treated as the body of the response.
+//
+// Errors are written to the template's stderr, and result in a response
+// with the status 500.
+type HTTPHandler struct {
+
+	// pool provides the interpreters which handle requests.
+	pool *Pool
+
+	// routes holds the procedures to invoke.
+	routes []httpRoute
+
+	// errors is where errors are written.
+	errors io.Writer
+}
+
+// NewHTTPHandler creates a handler which serves requests via the routes
+// registered by the template, which must have already been evaluated.
+//
+// The template must not be modified once the handler has been created.
+func NewHTTPHandler(template *Interpreter) *HTTPHandler {
+	h := &HTTPHandler{
+		pool:   NewPool(template),
+		routes: template.routes,
+		errors: template.stderr,
+	}
+
+	// A safe template may have no stderr.
+	if h.errors == nil {
+		h.errors = io.Discard
+	}
+	return h
+}
+
+// ServeHTTP handles a single request.
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	route, status := h.match(r.Method, r.URL.Path)
+	if route == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxRequestBody))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	i := h.pool.Get()
+	defer h.pool.Put(i)
+
+	out, err := i.EvalContext(r.Context(), formatList([]string{route.proc, requestDict(r, body)}))
+	if err == ErrExit {
+		err = fmt.Errorf("exit called with code %s", out)
+	}
+	if err == nil || err == ErrReturn {
+		err = writeResponse(w, out)
+	}
+	if err != nil {
+		fmt.Fprintf(h.errors, "error handling %s %s: %s\n", r.Method, r.URL.Path, errorMessage(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// match returns the route which handles the given request, or the status
+// to respond with if there is none.
+func (h *HTTPHandler) match(method string, path string) (*httpRoute, int) {
+	status := http.StatusNotFound
+	for n, r := range h.routes {
+		if !r.matches(path) {
+			continue
+		}
+		if r.method == "*" || r.method == method {
+			return &h.routes[n], 0
+		}
+		status = http.StatusMethodNotAllowed
+	}
+	return nil, status
+}
+
+// requestDict returns the dictionary which describes a request.
+func requestDict(r *http.Request, body []byte) string {
+
+	query := &dictionary{}
+	for key, values := range r.URL.Query() {
+		query.set(key, values[0])
+	}
+
+	headers := &dictionary{}
+	for key, values := range r.Header {
+		headers.set(strings.ToLower(key), values[0])
+	}
+	if r.Host != "" {
+		headers.set("host", r.Host)
+	}
+
+	req := &dictionary{}
+	req.set("method", r.Method)
+	req.set("path", r.URL.Path)
+	req.set("query", query.sorted().String())
+	req.set("headers", headers.sorted().String())
+	req.set("body", string(body))
+	return req.String()
+}
+
+// writeResponse writes the response a procedure returned.
+func writeResponse(w http.ResponseWriter, out string) error {
+
+	status := http.StatusOK
+	body := out
+
+	d, err := parseDict(out)
+	if err == nil && isResponse(d) {
+		body, _ = d.get("body")
+
+		if val, ok := d.get("status"); ok {
+			status, err = strconv.Atoi(val)
+			if err != nil || status < 100 || status > 999 {
+				return fmt.Errorf("invalid status \"%s\"", val)
+			}
+		}
+
+		if val, ok := d.get("headers"); ok {
+			headers, err := parseDict(val)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %s", err)
+			}
+			for n, key := range headers.keys {
+				w.Header().Set(key, headers.values[n])
+			}
+		}
+	}
+
+	w.WriteHeader(status)
+	_, err = io.WriteString(w, body)
+	return err
+}
+
+// isResponse returns true if the dictionary only contains the keys of a
+// response.
+func isResponse(d *dictionary) bool {
+	if len(d.keys) == 0 {
+		return false
+	}
+	for _, key := range d.keys {
+		if key != "status" && key != "headers" && key != "body" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package interpreter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// webApp is a script which registers some routes.
+const webApp = `
+proc hello {req} {
+  set name [dict get $req query name]
+  dict create status 201 headers [list Content-Type text/plain X-Name $name] body "Hello, $name"
+}
+proc echo {req} {
+  list [dict get $req method] [dict get $req path] [dict get $req headers x-test] [dict get $req body]
+}
+proc plain {req} {
+  return "just text"
+}
+proc bad {req} {
+  dict create status nope
+}
+proc fails {req} {
+  unknown
+}
+proc count {req} {
+  incr counter
+}
+http::route GET /hello hello
+http::route * /echo/* echo
+http::route get /plain plain
+http::route GET /bad bad
+http::route GET /fails fails
+http::route GET /count count
+`
+
+// newHandler returns a handler which serves the routes of webApp.
+func newHandler(t *testing.T, stderr io.Writer) *HTTPHandler {
+	t.Helper()
+
+	template, err := New(webApp, WithStderr(stderr))
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	if _, err := template.Evaluate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return NewHTTPHandler(template)
+}
+
+func TestHTTPHandler(t *testing.T) {
+
+	stderr := &syncBuffer{}
+	server := httptest.NewServer(newHandler(t, stderr))
+	defer server.Close()
+
+	type TestCase struct {
+		Method  string
+		Path    string
+		Body    string
+		Status  int
+		Output  string
+		Headers map[string]string
+	}
+
+	tests := []TestCase{
+		{Method: "GET", Path: "/hello?name=Bob", Status: 201, Output: "Hello, Bob", Headers: map[string]string{"Content-Type": "text/plain", "X-Name": "Bob"}},
+		{Method: "POST", Path: "/echo/a/b", Body: "some data", Status: 200, Output: "POST /echo/a/b yes {some data}"},
+		{Method: "PUT", Path: "/echo/", Status: 200, Output: "PUT /echo/ yes {}"},
+		{Method: "GET", Path: "/plain", Status: 200, Output: "just text"},
+		{Method: "GET", Path: "/count", Status: 200, Output: "1"},
+		{Method: "GET", Path: "/count", Status: 200, Output: "1"},
+		{Method: "POST", Path: "/hello", Status: 405, Output: "Method Not Allowed\n"},
+		{Method: "GET", Path: "/missing", Status: 404, Output: "Not Found\n"},
+		{Method: "GET", Path: "/bad", Status: 500, Output: "Internal Server Error\n"},
+		{Method: "GET", Path: "/fails", Status: 500, Output: "Internal Server Error\n"},
+	}
+
+	for _, test := range tests {
+
+		req, err := http.NewRequest(test.Method, server.URL+test.Path, strings.NewReader(test.Body))
+		if err != nil {
+			t.Fatalf("failed to create request: %s", err)
+		}
+		req.Header.Set("X-Test", "yes")
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %s", err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if res.StatusCode != test.Status {
+			t.Fatalf("unexpected status for %s %s: %d", test.Method, test.Path, res.StatusCode)
+		}
+		if string(body) != test.Output {
+			t.Fatalf("unexpected body for %s %s: %q", test.Method, test.Path, body)
+		}
+		for key, val := range test.Headers {
+			if res.Header.Get(key) != val {
+				t.Fatalf("unexpected header %s for %s %s: %q", key, test.Method, test.Path, res.Header.Get(key))
+			}
+		}
+	}
+
+	// The failures were reported.
+	for _, msg := range []string{
+		`error handling GET /bad: invalid status "nope"`,
+		`error handling GET /fails: unknown command 'unknown':unknown`,
+	} {
+		if !strings.Contains(stderr.String(), msg) {
+			t.Fatalf("expected %q in error output, got %q", msg, stderr.String())
+		}
+	}
+}
+
+// TestHTTPHandlerConcurrent tests that requests may be handled at the same
+// time.
+func TestHTTPHandlerConcurrent(t *testing.T) {
+
+	handler := newHandler(t, io.Discard)
+
+	done := make(chan string)
+	for n := 0; n < 20; n++ {
+		go func() {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/hello?name=X", nil))
+			done <- rec.Body.String()
+		}()
+	}
+	for n := 0; n < 20; n++ {
+		if out := <-done; out != "Hello, X" {
+			t.Fatalf("unexpected body: %s", out)
+		}
+	}
+}
+
+func TestRequestDict(t *testing.T) {
+
+	req := httptest.NewRequest("GET", "http://example.com/path?b=2&a=1&a=3", nil)
+	req.Header.Set("Accept", "text/html")
+
+	out := requestDict(req, []byte("body"))
+	expected := "method GET path /path query {a 1 b 2} headers {accept text/html host example.com} body body"
+	if out != expected {
+		t.Fatalf("unexpected dictionary: %s", out)
+	}
+}
@@ -0,0 +1,144 @@
+package interpreter
+
+import (
+	"fmt"
+	"sort"
+)
+
+// standardChannels are the names of the channels every interpreter has,
+// unless it is a safe child.
+var standardChannels = []string{"stdin", "stdout", "stderr"}
+
+// CreateChild creates a new interpreter, with its own variables and
+// commands, as a child of this one.
+//
+// The child uses the same comment-style, filesystem, clock, HTTP client,
+// and limits, as its parent.  Children of a normal interpreter share its
+// standard channels, but safe children have none until they're shared via
+// ShareChannel.  Any options supplied are applied to the child, and the
+// children of a safe interpreter are always safe themselves.
+//
+// The commands a child executes, and the variables it creates, also count
+// towards the limits of its parent, so a script can't escape its limits by
+// running code within a child.
+func (i *Interpreter) CreateChild(name string, opts ...Option) (*Interpreter, error) {
+
+	if _, ok := i.children[name]; ok {
+		return nil, fmt.Errorf("interpreter named \"%s\" already exists", name)
+	}
+
+	all := []Option{
+		WithComments(i.comments),
+		WithStdin(i.stdin), WithStdout(i.stdout), WithStderr(i.stderr),
+		WithFileSystem(i.filesystem),
+		WithClock(i.clock),
+		WithHTTPClient(i.httpClient),
+		WithMaxCommands(i.maxCommands),
+		WithMaxDepth(i.maxDepth),
+		WithMaxValueSize(i.maxValueSize),
+		WithMaxVariables(i.maxVariables),
+	}
+	all = append(all, opts...)
+	if i.safe {
+		all = append(all, WithSafe())
+	}
+
+	child, err := New("", all...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range standardChannels {
+		ch, ok := i.channels[name]
+		if child.safe || !ok {
+			delete(child.channels, name)
+		} else {
+			child.channels[name] = ch
+		}
+	}
+
+	child.parent = i
+	i.children[name] = child
+	return child, nil
+}
+
+// Child returns the named child interpreter, and whether it was found.
+func (i *Interpreter) Child(name string) (*Interpreter, bool) {
+	child, ok := i.children[name]
+	return child, ok
+}
+
+// Children returns the names of our child interpreters, in sorted order.
+func (i *Interpreter) Children() []string {
+	out := make([]string, 0, len(i.children))
+	for name := range i.children {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// DeleteChild deletes the named child interpreter, and any children of
+// its own.  Aliases which refer to a deleted interpreter will fail.
+func (i *Interpreter) DeleteChild(name string) error {
+	child, ok := i.children[name]
+	if !ok {
+		return fmt.Errorf("could not find interpreter \"%s\"", name)
+	}
+	child.markDeleted()
+	delete(i.children, name)
+	return nil
+}
+
+// markDeleted marks an interpreter, and its children, as deleted.
+func (i *Interpreter) markDeleted() {
+	i.deleted = true
+	for _, child := range i.children {
+		child.markDeleted()
+	}
+	i.children = make(map[string]*Interpreter)
+}
+
+// Alias creates a command in this interpreter which invokes a command
+// within the target interpreter, with any prefix arguments given placed
+// before the arguments the alias was called with.
+//
+// This allows a parent to make selected commands available within a
+// safe child, for example:
+//
+//	child.Alias("log", parent, "puts", "stderr")
+func (i *Interpreter) Alias(name string, target *Interpreter, targetName string, prefix ...string) {
+
+	i.RegisterBuiltin(name, func(caller *Interpreter, args []string) (string, error) {
+
+		if target.deleted {
+			return "", fmt.Errorf("target interpreter for alias \"%s\" has been deleted", name)
+		}
+
+		// The target is cancelled along with the caller.
+		if target != caller {
+			defer target.withContext(caller.ctx)()
+		}
+
+		all := make([]string, 0, len(prefix)+len(args))
+		all = append(all, prefix...)
+		all = append(all, args...)
+
+		out, ok, err := target.invoke(targetName, all)
+		if !ok {
+			return "", fmt.Errorf("invalid command name \"%s\"", targetName)
+		}
+		return out, err
+	})
+}
+
+// ShareChannel makes the named channel available within the destination
+// interpreter too.
+func (i *Interpreter) ShareChannel(name string, dest *Interpreter) error {
+	ch, ok := i.channels[name]
+	if !ok {
+		return fmt.Errorf("can not find channel named \"%s\"", name)
+	}
+	dest.channels[name] = ch
+	return nil
+}
@@ -3,13 +3,19 @@
 package interpreter
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
-	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/skx/critical/environment"
+	"github.com/skx/critical/lexer"
 	"github.com/skx/critical/parser"
-	"github.com/skx/critical/token"
 )
 
 // HostFunctionSignature is the signature of a function implemented in golang, which
@@ -33,6 +39,9 @@ type UserFunction struct {
 
 	// Body contains the function body
 	Body string
+
+	// script contains the parsed function body
+	script *parser.Script
 }
 
 // Interpreter holds the interpreters state.
@@ -42,275 +51,523 @@ type Interpreter struct {
 	// the TCL functions.
 	builtins map[string]HostFunction
 
+	// hidden contains builtins which scripts may not call, such as
+	// those which are unsafe.
+	hidden map[string]HostFunction
+
+	// safe is true if this is a safe interpreter.
+	safe bool
+
 	// functions contain user-defined functions, written in TCL.
 	functions map[string]UserFunction
 
 	// program is the parsed program.
-	program []parser.Command
+	program *parser.Script
 
 	// environment holds any variable-references the user has defined.
 	//
 	// Note that functions the user defines are not stored here, they
 	// live in the `functions` map.
 	environment *environment.Environment
+
+	// globals holds the top-level environment, which is the parent of
+	// the environments created when procedures are called.
+	globals *environment.Environment
+
+	// comments is the style of comments our parser recognizes.
+	comments lexer.CommentStyle
+
+	// stdin, stdout, and stderr are used for the standard channels.
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	// filesystem is used to access files.
+	filesystem FileSystem
+
+	// channels holds the channels a script may read from, or write to.
+	channels map[string]*channel
+
+	// nextChannel is used to generate the names of new channels.
+	nextChannel int
+
+	// clock is our source of time.
+	clock Clock
+
+	// events holds the events scheduled by scripts.
+	events *eventLoop
+
+	// jobs holds the processes started in the background via `exec`.
+	jobs *backgroundJobs
+
+	// ctx is the context the current evaluation is running with.
+	ctx context.Context
+
+	// maxCommands, maxDepth, maxValueSize, and maxVariables are the
+	// limits we enforce, zero meaning unlimited.
+	maxCommands  int
+	maxDepth     int
+	maxValueSize int
+	maxVariables int
+
+	// commands is the number of commands we've executed.
+	commands int
+
+	// depth is the number of procedure calls currently active.
+	depth int
+
+	// children holds the child interpreters we've created, and parent
+	// is the interpreter which created us, if any.
+	children map[string]*Interpreter
+	parent   *Interpreter
+
+	// deleted is set when a child interpreter has been deleted.
+	deleted bool
+
+	// scriptPath is the path of the script being executed, if known.
+	scriptPath string
+
+	// packages holds the version of each package which has been
+	// provided.
+	packages map[string]string
+
+	// ifneeded holds the scripts which load each version of the
+	// packages we know about.
+	ifneeded map[string]map[string]string
+
+	// indexes records the package indexes we've loaded.
+	indexes map[string]bool
+
+	// httpClient is used to make HTTP requests.
+	httpClient *http.Client
+
+	// routes holds the procedures registered via `http::route`, and
+	// listenAddress is the address `http::serve` listens upon by
+	// default.
+	routes        []httpRoute
+	listenAddress string
 }
 
 // New creates a new object to interpret.
-func New(source string) (*Interpreter, error) {
+//
+// Any options supplied are applied before the source is parsed.
+func New(source string, opts ...Option) (*Interpreter, error) {
 
 	// Create the object we'll return
 	i := &Interpreter{
-		builtins:    make(map[string]HostFunction),
-		environment: environment.New(),
-		functions:   make(map[string]UserFunction),
+		builtins:   make(map[string]HostFunction),
+		hidden:     make(map[string]HostFunction),
+		children:   make(map[string]*Interpreter),
+		packages:   make(map[string]string),
+		ifneeded:   make(map[string]map[string]string),
+		indexes:    make(map[string]bool),
+		functions:  make(map[string]UserFunction),
+		globals:    environment.New(),
+		filesystem: OSFileSystem{},
+		httpClient: http.DefaultClient,
+		clock:      systemClock{},
+		events:     newEventLoop(),
+		ctx:        context.Background(),
+		maxDepth:   DefaultMaxDepth,
 	}
+	i.environment = i.globals
 
-	// parser is the object we use to transform the source into
-	// a program we can evaluate.
-	parser := parser.New(source)
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	// A safe interpreter only has the standard channels the host gave
+	// it, rather than those of the process.
+	if !i.safe {
+		if i.stdin == nil {
+			i.stdin = os.Stdin
+		}
+		if i.stdout == nil {
+			i.stdout = os.Stdout
+		}
+		if i.stderr == nil {
+			i.stderr = os.Stderr
+		}
+	}
+
+	i.setupChannels()
 
 	// Parse the program to find any obvious errors immediately.
 	var err error
-	i.program, err = parser.Parse()
+	i.program, err = i.parse(source)
 	if err != nil {
 		return nil, err
 	}
 
 	// Bind the expected primitives
+	i.RegisterBuiltin("after", after)
 	i.RegisterBuiltin("append", appendFn)
 	i.RegisterBuiltin("break", breakFn)
+	i.RegisterBuiltin("catch", catch)
+	i.RegisterBuiltin("clock", clock)
+	i.RegisterBuiltin("close", closeFn)
 	i.RegisterBuiltin("continue", continueFn)
+	i.RegisterBuiltin("csv::join", csvJoinFn)
+	i.RegisterBuiltin("csv::read", csvReadFn)
+	i.RegisterBuiltin("csv::split", csvSplitFn)
+	i.RegisterBuiltin("csv::write", csvWriteFn)
 	i.RegisterBuiltin("decr", decr)
+	i.RegisterBuiltin("dict", dict)
 	i.RegisterBuiltin("env", env)
+	i.RegisterBuiltin("eof", eof)
 	i.RegisterBuiltin("eval", evalFn)
+	i.RegisterBuiltin("exec", execFn)
 	i.RegisterBuiltin("exit", exitFn)
 	i.RegisterBuiltin("expr", expr)
+	i.RegisterBuiltin("fconfigure", fconfigure)
+	i.RegisterBuiltin("file", file)
+	i.RegisterBuiltin("fileevent", fileevent)
+	i.RegisterBuiltin("flush", flush)
 	i.RegisterBuiltin("for", forFn)
+	i.RegisterBuiltin("gets", gets)
+	i.RegisterBuiltin("glob", glob)
+	i.RegisterBuiltin("http::formatQuery", httpFormatQueryFn)
+	i.RegisterBuiltin("http::geturl", httpGeturlFn)
+	i.RegisterBuiltin("http::route", httpRouteFn)
+	i.RegisterBuiltin("http::serve", httpServeFn)
 	i.RegisterBuiltin("if", ifFn)
 	i.RegisterBuiltin("incr", incr)
+	i.RegisterBuiltin("interp", interp)
+	i.RegisterBuiltin("json::array", jsonArrayFn)
+	i.RegisterBuiltin("json::boolean", jsonBooleanFn)
+	i.RegisterBuiltin("json::decode", jsonDecodeFn)
+	i.RegisterBuiltin("json::encode", jsonEncodeFn)
+	i.RegisterBuiltin("json::null", jsonNullFn)
+	i.RegisterBuiltin("json::number", jsonNumberFn)
+	i.RegisterBuiltin("json::object", jsonObjectFn)
+	i.RegisterBuiltin("json::string", jsonStringFn)
+	i.RegisterBuiltin("lindex", lindex)
+	i.RegisterBuiltin("list", list)
+	i.RegisterBuiltin("llength", llength)
+	i.RegisterBuiltin("open", open)
+	i.RegisterBuiltin("package", packageFn)
 	i.RegisterBuiltin("proc", proc)
 	i.RegisterBuiltin("puts", puts)
+	i.RegisterBuiltin("read", read)
 	i.RegisterBuiltin("regexp", regexpFn)
 	i.RegisterBuiltin("return", returnFn)
+	i.RegisterBuiltin("seek", seek)
 	i.RegisterBuiltin("set", set)
+	i.RegisterBuiltin("socket", socket)
+	i.RegisterBuiltin("source", sourceFn)
+	i.RegisterBuiltin("tell", tell)
+	i.RegisterBuiltin("update", update)
+	i.RegisterBuiltin("vwait", vwait)
 	i.RegisterBuiltin("while", while)
 
+	// Safe interpreters can't access the host.
+	if i.safe {
+		i.hideUnsafe()
+	}
+
 	return i, nil
 }
 
 // Evaluate parses the program source, and executes the program.
 func (i *Interpreter) Evaluate() (string, error) {
+	return i.evalScript(i.program)
+}
+
+// evalScript executes each of the commands in the given script.
+func (i *Interpreter) evalScript(script *parser.Script) (string, error) {
 
 	// Output of the evaluation is the output received from the
 	// last statement which was executed.
 	out := ""
 
-	// Holder for any error we might receive
-	var err error
-
 	// For each parsed command, evaluate it
-	for _, cmd := range i.program {
-
-		// The name of the command we're going to run
-		name := ""
-
-		// The name might require expansion, so handle that first.
-		switch cmd.Command.Type {
-		case token.STRING:
-			name = cmd.Command.Literal
-		case token.NUMBER:
-			name = cmd.Command.Literal
-		case token.IDENT:
-			name = cmd.Command.Literal
-		case token.VARIABLE:
-			name = i.expandString(cmd.Command.Literal)
-		default:
-			return "", fmt.Errorf("unknown command type %v", cmd.Command)
+	for _, cmd := range script.Commands {
+
+		// Stop if we've been cancelled.
+		err := i.cancelled()
+		if err != nil {
+			return "", err
 		}
 
-		// We need to expand the arguments to the command, so here
-		// is the place to store those converted args, before we
-		// pass them to the handler.
-		var args []string
+		out, err = i.evalCommand(cmd)
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
 
-		// For each argument
-		for _, arg := range cmd.Arguments {
+// evalCommand executes a single command.
+func (i *Interpreter) evalCommand(cmd *parser.Command) (string, error) {
 
-			// If the token isn't a quoted form we've got
-			// expand it.
-			if arg.Type != token.BLOCK {
+	err := i.countCommand()
+	if err != nil {
+		return "", err
+	}
+
+	// The name of the command we're going to run, which might
+	// require expansion.
+	var name string
+	name, err = i.evalWord(cmd.Name())
+	if err != nil {
+		return name, err
+	}
 
-				// Expand "$a" -> "$ENV{a}"
-				expand := i.expandString(arg.Literal)
+	// We need to expand the arguments to the command, so here
+	// is the place to store those converted args, before we
+	// pass them to the handler.
+	var args []string
 
-				// Now expand "[ .. x .. ]" to include
-				// having that there.
-				expand = i.expandEval(expand)
+	// For each argument
+	for _, arg := range cmd.Args() {
 
-				// Save the argument away.
-				args = append(args, expand)
-			} else {
-				// This is a quoted-block, just append literally
-				args = append(args, arg.Literal)
-			}
+		val, err := i.evalWord(arg)
+		if err != nil {
+			return val, err
 		}
 
-		// Is the function a built-in implemented in golang?
-		fn, ok := i.builtins[name]
-		if ok {
+		// Save the argument away.
+		args = append(args, val)
+	}
 
-			// Call the function, and if it errors then abort
-			var e error
-			out, e = fn.function(i, args)
+	// Invoke the command, if it exists.
+	out, ok, err := i.invoke(name, args)
+	if ok {
+		return out, err
+	}
 
-			// If the function returned a value then use that.
-			if e == ErrReturn {
-				return out, e
-			}
+	// At this point we've been given a "command" which
+	// doesn't exist as a function - either in golang, or
+	// user-defined.
+	//
+	// If the input was a literal string, number, or variable
+	// to be expanded then we set our return value to that.
+	//
+	if isValue(cmd.Name()) {
+		return name, nil
+	}
 
-			//
-			// `break` and `continue` errors are handled specially
-			// within the handlers for `if` and `while`.
-			//
-			// Here we just return them, and they'll do the
-			// right thing.
-			//
-			// The same thing applies more generally to the
-			// exit handler
-			//
-			if e == errBreak || e == errContinue || e == ErrExit {
-				return out, e
-			}
+	//
+	// Otherwise we just return an error.
+	//
+	return "", fmt.Errorf("unknown command '%s':%s", name, cmd)
+}
 
-			if e != nil {
-				return "", fmt.Errorf("error invoking %s: %s", cmd.Command.Literal, e)
-			}
-			continue
-		}
+// invoke calls the named command, which may be a builtin or a procedure
+// the user has defined, with the given arguments.
+//
+// The boolean return value is false if there is no such command.
+func (i *Interpreter) invoke(name string, args []string) (string, bool, error) {
 
-		// Is the function a user-written function in TCL?
-		userFN, ok2 := i.functions[name]
+	// Output of the command
+	out := ""
 
-		if ok2 {
-			var e error
+	// Is the function a built-in implemented in golang?
+	fn, ok := i.builtins[name]
+	if ok {
 
-			if len(args) != len(userFN.Args) {
-				return "", fmt.Errorf("function argument mismatch, %s takes %d arguments, %d supplied", name, len(userFN.Args), len(args))
-			}
+		// Call the function, and if it errors then abort
+		var e error
+		out, e = fn.function(i, args)
 
-			// Save old environment
-			oldE := i.environment
+		// If the function returned a value then use that.
+		if e == ErrReturn {
+			return out, true, e
+		}
 
-			// Create a new environment
-			newE := environment.NewEnclosedEnvironment(oldE)
+		//
+		// `break` and `continue` errors are handled specially
+		// within the handlers for `if` and `while`.
+		//
+		// Here we just return them, and they'll do the
+		// right thing.
+		//
+		// The same thing applies more generally to the
+		// exit handler
+		//
+		if e == errBreak || e == errContinue || e == ErrExit {
+			return out, true, e
+		}
 
-			// Make the environment live
-			i.environment = newE
+		// Cancellation, and exceeded limits, are reported unchanged.
+		if isFatal(e) {
+			return "", true, e
+		}
 
-			// Set the environment variables for the proc
-			// arguments.
-			for idx, arg := range userFN.Args {
-				i.environment.Set(arg, args[idx])
-			}
+		if e != nil {
+			return "", true, &invokeError{name: name, err: e}
+		}
+		return out, true, nil
+	}
 
-			out, e = i.Eval(userFN.Body)
+	// Is the function a user-written function in TCL?
+	userFN, ok2 := i.functions[name]
 
-			// Restore the old environment, now the function
-			// is over.
-			i.environment = oldE
+	if ok2 {
+		var e error
 
-			// If the function returned a value then use that.
-			if e == ErrReturn {
-				continue
-			}
+		if len(args) != len(userFN.Args) {
+			return "", true, fmt.Errorf("function argument mismatch, %s takes %d arguments, %d supplied", name, len(userFN.Args), len(args))
+		}
 
-			// Exit inside a proc.
-			if e == ErrExit {
-				return out, e
-			}
+		if i.maxDepth > 0 && i.depth >= i.maxDepth {
+			return "", true, fmt.Errorf("%w: limit is %d", ErrMaxDepth, i.maxDepth)
+		}
 
-			// Now we've restored the environment we can
-			// handle the error-detection
+		// Save old environment
+		oldE := i.environment
+
+		// Create a new environment
+		newE := environment.NewEnclosedEnvironment(oldE)
+
+		// Make the environment live
+		i.environment = newE
+		i.depth++
+
+		// Set the environment variables for the proc
+		// arguments.
+		for idx, arg := range userFN.Args {
+			e = i.setLocal(arg, args[idx])
 			if e != nil {
-				return "", e
+				break
 			}
+		}
 
-			continue
+		if e == nil {
+			out, e = i.evalScript(userFN.script)
 		}
 
-		// At this point we've been given a "command" which
-		// doesn't exist as a function - either in golang, or
-		// user-defined.
-		//
-		// If the input was a literal string, number, or variable
-		// to be expanded then we set our return value to that.
-		//
-		if cmd.Command.Type == token.STRING {
-			out = cmd.Command.Literal
-			continue
+		// Restore the old environment, now the function
+		// is over.
+		i.environment = oldE
+		i.depth--
+
+		// If the function returned a value then use that.
+		if e == ErrReturn {
+			return out, true, nil
 		}
-		if cmd.Command.Type == token.NUMBER {
-			out = cmd.Command.Literal
-			continue
+
+		// Exit inside a proc.
+		if e == ErrExit {
+			return out, true, e
 		}
-		if cmd.Command.Type == token.VARIABLE {
-			// The actual literal will have been updated
-			out = i.expandString(cmd.Command.Literal)
-			continue
+
+		// Now we've restored the environment we can
+		// handle the error-detection
+		if e != nil {
+			return "", true, e
 		}
 
-		//
-		// Otherwise we just return an error.
-		//
-		return "", fmt.Errorf("unknown command '%s':%v", name, cmd)
+		return out, true, nil
+	}
 
+	// Hidden commands may not be called.
+	if _, ok := i.hidden[name]; ok {
+		return "", true, fmt.Errorf("%w: %s", ErrHidden, name)
 	}
-	return out, err
+
+	return "", false, nil
 }
 
-// expandString converts "$foo $bar" into "$ENV{'FOO'} $ENV{'BAR'}".
-func (i *Interpreter) expandString(str string) string {
+// invokeError is returned when a builtin command fails, and records the
+// name of the command.
+type invokeError struct {
+	name string
+	err  error
+}
 
-	// Lookup any variables inside our environment.
-	//
-	// If they're not found then return ""
-	//
-	mapper := func(placeholderName string) string {
-		if placeholderName == "$" {
-			return "$"
-		}
-		val, ok := i.environment.Get(placeholderName)
-		if ok {
-			return val
+// Error returns the message of the error, prefixed by the command name.
+func (e *invokeError) Error() string {
+	return fmt.Sprintf("error invoking %s: %s", e.name, e.err)
+}
+
+// Unwrap returns the error the command failed with.
+func (e *invokeError) Unwrap() error {
+	return e.err
+}
+
+// evalWord returns the value of the given word, performing any
+// substitutions which are required.
+func (i *Interpreter) evalWord(word *parser.Word) (string, error) {
+
+	var out strings.Builder
+
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *parser.TextPart:
+			out.WriteString(p.Text)
+		case *parser.BackslashPart:
+			out.WriteString(p.Value)
+		case *parser.VariablePart:
+			// Missing variables are regarded as empty.
+			val, _ := i.environment.Get(p.Name)
+			out.WriteString(val)
+		case *parser.CommandPart:
+			val, err := i.evalScript(p.Script)
+
+			// A return is fine, it just gives us the value.
+			if err != nil && err != ErrReturn {
+				return val, err
+			}
+			out.WriteString(val)
 		}
-		return ""
 	}
 
-	// Replace the string, using the mapper.
-	return os.Expand(str, mapper)
+	return out.String(), nil
 }
 
-// Eval handles sub-expressions, this is horrid.
-// TODO / FIXME / HACK / XXX
-func (i *Interpreter) Eval(str string) (string, error) {
+// isValue returns true if the word should evaluate to itself, when it
+// is used as the name of a command which does not exist.
+//
+// That is true for quoted-strings, numbers, and variable references,
+// which allows a procedure to end with `"$result"`, or a condition to
+// be written as `if { $x } { ... }`.
+func isValue(word *parser.Word) bool {
 
-	// sub-evaluator.  horrid
-	tmp, er := New(str)
-	if er != nil {
-		return "", er
+	if word.Kind == parser.QuotedWord {
+		return true
+	}
+	if word.Kind == parser.BracedWord {
+		return false
 	}
 
-	// Hacky way to ensure that any updates to the variables
-	// affect this environment too, not just the child one.
-	tmp.environment = i.environment
+	variable := false
+	for _, part := range word.Parts {
+		switch part.(type) {
+		case *parser.CommandPart:
+			return false
+		case *parser.VariablePart:
+			variable = true
+		}
+	}
+	if variable {
+		return true
+	}
 
-	// Hacky way to ensure the child environment has the same
-	// functions as we do.
-	tmp.functions = i.functions
-	tmp.builtins = i.builtins
+	// Is it a number?
+	str, _ := word.Literal()
+	if str == "" || (str[0] != '-' && !unicode.IsDigit(rune(str[0]))) {
+		return false
+	}
+	if _, err := strconv.ParseInt(str, 0, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseFloat(str, 64)
+	return err == nil
+}
+
+// Eval evaluates the given string, as a script, within the current
+// environment.
+func (i *Interpreter) Eval(str string) (string, error) {
+
+	// Parse the script
+	script, err := i.parse(str)
+	if err != nil {
+		return "", err
+	}
 
 	// run the script
-	out, err := tmp.Evaluate()
+	out, err := i.evalScript(script)
 
 	if err == ErrReturn || err == ErrExit {
 		return out, err
@@ -322,40 +579,32 @@ func (i *Interpreter) Eval(str string) (string, error) {
 	return out, nil
 }
 
-// expandEval handles the expansion of "[ FOO ]" blocks.
-//
-// It correctly handles nested values, such that this works:
-//
-//    puts [ expr 1 + [ expr 2 + 3 ] ]
-//
-// The downside is we use a regular expression to handle the nested
-// processing, and that means we've got three problems..
-func (i *Interpreter) expandEval(str string) string {
-
-	r := regexp.MustCompile(`^(.*)\[([^\]]+)\](.*)$`)
-
-	out := r.FindStringSubmatch(str)
-	for len(out) > 1 {
-
-		// The pieces of the match
-		before := out[1]
-		match := out[2]
-		after := out[3]
-
-		// Evaluate the middle.
-		eval, _ := i.Eval(match)
-
-		// Now update our string.
-		str = before + eval + after
-
-		// And continue going until we find no more matches.
-		out = r.FindStringSubmatch(str)
-	}
-
-	return str
+// parse converts the given source into a script, using the options the
+// interpreter was configured with.
+func (i *Interpreter) parse(str string) (*parser.Script, error) {
+	return parser.New(str, lexer.WithComments(i.comments)).Parse()
 }
 
 // RegisterBuiltin registers a builtin function.
+//
+// Registering a function replaces any hidden command of the same name.
 func (i *Interpreter) RegisterBuiltin(name string, fn HostFunctionSignature) {
 	i.builtins[name] = HostFunction{function: fn}
+	delete(i.hidden, name)
+}
+
+// Commands returns the names of the commands which scripts may call, both
+// builtins and procedures, in sorted order.
+func (i *Interpreter) Commands() []string {
+	out := make([]string, 0, len(i.builtins)+len(i.functions))
+	for name := range i.builtins {
+		out = append(out, name)
+	}
+	for name := range i.functions {
+		if _, ok := i.builtins[name]; !ok {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
 }
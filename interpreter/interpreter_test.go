@@ -3,6 +3,8 @@ package interpreter
 import (
 	"strings"
 	"testing"
+
+	"github.com/skx/critical/lexer"
 )
 
 func TestExpandEval(t *testing.T) {
@@ -56,7 +58,10 @@ func TestExpandString(t *testing.T) {
 
 	// now we have "$a -> pu"
 	// now we have "$b -> ts"
-	out = x.expandString("A$$A$a$b$c CC")
+	out, err = x.Eval(`"A$$A$a$b$c CC"`)
+	if err != nil {
+		t.Fatalf("error expanding string: %s", err)
+	}
 	if out != "A$Aputs CC" {
 		t.Fatalf("unexpected output expanding string '%s'", out)
 	}
@@ -334,3 +339,149 @@ func TestRepeat(t *testing.T) {
 	}
 
 }
+
+// TestComments ensures that the comment-style is honoured, including
+// within the bodies of procedures.
+func TestComments(t *testing.T) {
+
+	// TCL-style comments are the default
+	x, er := New(`
+# A comment
+proc hash {} {
+    # a comment in a proc
+    puts #hashtag
+}
+hash`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	out, err := x.Evaluate()
+	if err != nil {
+		t.Fatalf("error running program: %s", err)
+	}
+	if out != "#hashtag" {
+		t.Fatalf("unexpected output '%s'", out)
+	}
+
+	// C-style comments can be enabled.
+	x, er = New(`
+// A comment
+proc hash {} {
+    // a comment in a proc
+    set a 3 // trailing comment
+}
+hash`, WithComments(lexer.CComments))
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	out, err = x.Evaluate()
+	if err != nil {
+		t.Fatalf("error running program: %s", err)
+	}
+	if out != "3" {
+		t.Fatalf("unexpected output '%s'", out)
+	}
+}
+
+// TestSubstitution tests the substitutions performed upon words.
+func TestSubstitution(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `set name Steve; "Hello, ${name}!"`, Output: `Hello, Steve!`},
+		{Input: `set a 3; set b 4; "$a$b"`, Output: `34`},
+		{Input: `set a 3; "[expr $a * 2]x"`, Output: `6x`},
+		{Input: `set a 3; set b pre[expr $a * 2]post`, Output: `pre6post`},
+		{Input: `set a {$a [b]}`, Output: `$a [b]`},
+		{Input: `set a "nested [set b "quotes"]"`, Output: `nested quotes`},
+		{Input: `set cmd set; [set cmd] x 12`, Output: `12`},
+		{Input: `set a Hello\ World`, Output: `Hello World`},
+		{Input: `"\x41é"`, Output: `Aé`},
+		{Input: `3.4`, Output: `3.4`},
+		{Input: `-3`, Output: `-3`},
+
+		// Arguments are evaluated in order, and the parameters
+		// of procedures don't change those of the caller.
+		{Input: `proc fib {x} {
+    if { expr $x <= 1 } {
+        return 1
+    } else {
+        return [expr [fib [expr $x - 1]] + [fib [expr $x - 2]]]
+    }
+}
+fib 10`, Output: `89`},
+	}
+
+	for _, test := range tests {
+
+		e, er := New(test.Input)
+		if er != nil {
+			t.Fatalf("unexpected error creating interpreter: %s", er)
+		}
+
+		out, err := e.Evaluate()
+		if err != nil {
+			t.Fatalf("error running %s:%s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s - got %s, but expected %s", test.Input, out, test.Output)
+		}
+	}
+
+	// Errors within command-substitutions are reported.
+	e, er := New(`puts [expr 1 / 0]`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter: %s", er)
+	}
+	_, err := e.Evaluate()
+	if err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Fatalf("expected division error, got %v", err)
+	}
+
+	// As are exits
+	e, er = New(`puts [exit 3]; puts "NOT REACHED"`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter: %s", er)
+	}
+	out, err := e.Evaluate()
+	if err != ErrExit || out != "3" {
+		t.Fatalf("expected exit, got %v %s", err, out)
+	}
+}
+
+func TestCommands(t *testing.T) {
+
+	e, er := New(`proc set {} {}; proc zzz {} {}`, WithSafe())
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter: %s", er)
+	}
+	_, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cmds := e.Commands()
+	if cmds[len(cmds)-1] != "zzz" {
+		t.Fatalf("procedure missing from commands: %v", cmds)
+	}
+
+	seen := make(map[string]int)
+	for _, c := range cmds {
+		seen[c]++
+	}
+	if seen["set"] != 1 {
+		t.Fatalf("expected set once, got %d", seen["set"])
+	}
+	if seen["exit"] != 0 {
+		t.Fatalf("hidden command present")
+	}
+	if seen["puts"] != 1 {
+		t.Fatalf("builtin missing")
+	}
+}
@@ -0,0 +1,222 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JSON values are represented in one of two ways.
+//
+// Plain values are what scripts usually want: objects become dictionaries,
+// arrays become lists, and strings, numbers, booleans, and null become
+// their text.  This loses the distinction between, for example, the
+// string "1" and the number 1.
+//
+// Typed values are lists of two elements, a type and a value, which
+// preserve that distinction:
+//
+//	object {key typedValue ...}
+//	array {typedValue ...}
+//	string text
+//	number 1.5
+//	boolean true
+//	null {}
+//
+// Typed values are returned by `json::decode -types`, created by the
+// builders such as `json::object`, and encoded by `json::encode`.
+
+// decodeJSON parses a JSON document, returning either its plain, or its
+// typed, representation.
+func decodeJSON(src string, typed bool) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(src))
+	dec.UseNumber()
+
+	out, err := decodeValue(dec, typed)
+	if err != nil {
+		return "", jsonError(err)
+	}
+
+	// There must be nothing after the value.
+	if _, err := dec.Token(); err != io.EOF {
+		return "", fmt.Errorf("invalid JSON: unexpected data after the value")
+	}
+	return out, nil
+}
+
+// decodeValue decodes the next value from the decoder.
+func decodeValue(dec *json.Decoder, typed bool) (string, error) {
+
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+
+	kind := ""
+	val := ""
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			kind = "object"
+			d := &dictionary{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return "", err
+				}
+				elem, err := decodeValue(dec, typed)
+				if err != nil {
+					return "", err
+				}
+				d.set(key.(string), elem)
+			}
+			val = d.String()
+		case '[':
+			kind = "array"
+			elements := []string{}
+			for dec.More() {
+				elem, err := decodeValue(dec, typed)
+				if err != nil {
+					return "", err
+				}
+				elements = append(elements, elem)
+			}
+			val = formatList(elements)
+		}
+
+		// Consume the closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			return "", err
+		}
+
+	case string:
+		kind, val = "string", t
+	case json.Number:
+		kind, val = "number", t.String()
+	case bool:
+		kind, val = "boolean", fmt.Sprintf("%t", t)
+	case nil:
+		kind, val = "null", "null"
+		if typed {
+			val = ""
+		}
+	}
+
+	if typed {
+		return formatList([]string{kind, val}), nil
+	}
+	return val, nil
+}
+
+// jsonError describes a failure to decode a document.
+func jsonError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("invalid JSON: unexpected end of input")
+	}
+	return fmt.Errorf("invalid JSON: %s", err)
+}
+
+// encodeJSON encodes a typed value as JSON.
+func encodeJSON(typed string) (string, error) {
+	var buf bytes.Buffer
+	if err := encodeValue(&buf, typed); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// encodeValue writes the JSON encoding of a typed value to the buffer.
+func encodeValue(buf *bytes.Buffer, typed string) error {
+
+	parts, err := parseList(typed)
+	if err != nil {
+		return err
+	}
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid JSON value \"%s\": must be a type and a value", typed)
+	}
+	kind, val := parts[0], parts[1]
+
+	switch kind {
+	case "object":
+		d, err := parseDict(val)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('{')
+		for n, key := range d.keys {
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			encodeString(buf, key)
+			buf.WriteByte(':')
+			if err := encodeValue(buf, d.values[n]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+
+	case "array":
+		elements, err := parseList(val)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('[')
+		for n, elem := range elements {
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeValue(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+
+	case "string":
+		encodeString(buf, val)
+
+	case "number":
+		if !isJSONNumber(val) {
+			return fmt.Errorf("invalid JSON number \"%s\"", val)
+		}
+		buf.WriteString(val)
+
+	case "boolean":
+		b, err := parseBool(val)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "%t", b)
+
+	case "null":
+		buf.WriteString("null")
+
+	default:
+		return fmt.Errorf("invalid JSON type \"%s\": must be array, boolean, null, number, object, or string", kind)
+	}
+	return nil
+}
+
+// encodeString writes a string to the buffer as JSON, without escaping
+// HTML characters as json.Marshal would.
+func encodeString(buf *bytes.Buffer, str string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(str)
+
+	// Remove the newline the encoder added.
+	buf.Truncate(buf.Len() - 1)
+}
+
+// isJSONNumber returns true if the string is a valid JSON number.
+func isJSONNumber(str string) bool {
+	var n json.Number
+	if err := json.Unmarshal([]byte(str), &n); err != nil {
+		return false
+	}
+	return !strings.HasPrefix(str, "\"")
+}
@@ -0,0 +1,128 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+
+	type TestCase struct {
+		Input string
+		Plain string
+		Typed string
+	}
+
+	tests := []TestCase{
+		{Input: `"hello world"`, Plain: "hello world", Typed: "string {hello world}"},
+		{Input: `42`, Plain: "42", Typed: "number 42"},
+		{Input: `-1.5e3`, Plain: "-1.5e3", Typed: "number -1.5e3"},
+		{Input: `true`, Plain: "true", Typed: "boolean true"},
+		{Input: `null`, Plain: "null", Typed: "null {}"},
+		{Input: `[]`, Plain: "", Typed: "array {}"},
+		{Input: `{}`, Plain: "", Typed: "object {}"},
+		{Input: `[1, "two", [3]]`, Plain: "1 two 3", Typed: "array {{number 1} {string two} {array {{number 3}}}}"},
+		{Input: `{"b": 1, "a": {"c": null}}`, Plain: "b 1 a {c null}", Typed: "object {b {number 1} a {object {c {null {}}}}}"},
+		{Input: ` {"k": "a \"quoted\" é"} `, Plain: `k {a "quoted" é}`, Typed: `object {k {string {a "quoted" é}}}`},
+	}
+
+	for _, test := range tests {
+
+		out, err := decodeJSON(test.Input, false)
+		if err != nil {
+			t.Fatalf("unexpected error decoding %s: %s", test.Input, err)
+		}
+		if out != test.Plain {
+			t.Fatalf("unexpected result decoding %s: got %q expected %q", test.Input, out, test.Plain)
+		}
+
+		out, err = decodeJSON(test.Input, true)
+		if err != nil {
+			t.Fatalf("unexpected error decoding %s: %s", test.Input, err)
+		}
+		if out != test.Typed {
+			t.Fatalf("unexpected typed result decoding %s: got %q expected %q", test.Input, out, test.Typed)
+		}
+	}
+}
+
+func TestDecodeJSONMalformed(t *testing.T) {
+
+	tests := []string{
+		``,
+		`{`,
+		`[1, 2`,
+		`{"a" 1}`,
+		`{"a": }`,
+		`[1,]`,
+		`tru`,
+		`"unterminated`,
+		`1 2`,
+		`{} []`,
+		`{'a': 1}`,
+	}
+
+	for _, test := range tests {
+		_, err := decodeJSON(test, false)
+		if err == nil || !strings.HasPrefix(err.Error(), "invalid JSON") {
+			t.Fatalf("expected error decoding %q, got %v", test, err)
+		}
+	}
+}
+
+// TestJSONRoundTrip tests that decoding, and then encoding, a document
+// gives the same document.
+func TestJSONRoundTrip(t *testing.T) {
+
+	tests := []string{
+		`"hello"`,
+		`"<tag> & \"quotes\"\n"`,
+		`0`,
+		`-12.5e-3`,
+		`false`,
+		`null`,
+		`[]`,
+		`{}`,
+		`[1,"1",true,"true",null,"null",""]`,
+		`{"z":1,"a":[{"b":{}},[]],"m":"x y"}`,
+		`{"":"empty key"}`,
+	}
+
+	for _, test := range tests {
+		typed, err := decodeJSON(test, true)
+		if err != nil {
+			t.Fatalf("unexpected error decoding %s: %s", test, err)
+		}
+		out, err := encodeJSON(typed)
+		if err != nil {
+			t.Fatalf("unexpected error encoding %s: %s", typed, err)
+		}
+		if out != test {
+			t.Fatalf("round-trip of %s gave %s", test, out)
+		}
+	}
+}
+
+func TestEncodeJSONErrors(t *testing.T) {
+
+	tests := []string{
+		`string`,
+		`string a b`,
+		`bogus 1`,
+		`number abc`,
+		`number {"1"}`,
+		`number true`,
+		`boolean maybe`,
+		`object {a}`,
+		`object {a {bogus 1}}`,
+		`array {{number x}}`,
+		`array "{"`,
+	}
+
+	for _, test := range tests {
+		_, err := encodeJSON(test)
+		if err == nil {
+			t.Fatalf("expected error encoding %s", test)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrMaxCommands is returned when a script executes more commands
+	// than the limit set via WithMaxCommands.
+	ErrMaxCommands = errors.New("too many commands executed")
+
+	// ErrMaxDepth is returned when procedures are nested more deeply
+	// than the limit set via WithMaxDepth.
+	ErrMaxDepth = errors.New("too many nested calls (infinite recursion?)")
+
+	// ErrMaxValueSize is returned when a script attempts to store a value
+	// larger than the limit set via WithMaxValueSize.
+	ErrMaxValueSize = errors.New("value too large")
+
+	// ErrMaxVariables is returned when a script attempts to create more
+	// variables than the limit set via WithMaxVariables.
+	ErrMaxVariables = errors.New("too many variables")
+)
+
+// DefaultMaxDepth is the default limit upon the depth of nested procedure
+// calls, which prevents runaway recursion from exhausting the stack.
+const DefaultMaxDepth = 1000
+
+// WithMaxCommands limits the total number of commands the interpreter
+// will execute, over its lifetime.  Zero means there is no limit.
+func WithMaxCommands(n int) Option {
+	return func(i *Interpreter) {
+		i.maxCommands = n
+	}
+}
+
+// WithMaxDepth limits how deeply procedure calls may be nested.  Zero
+// means there is no limit, and the default is DefaultMaxDepth.
+func WithMaxDepth(n int) Option {
+	return func(i *Interpreter) {
+		i.maxDepth = n
+	}
+}
+
+// WithMaxValueSize limits the size, in bytes, of the values which may be
+// stored in variables.  Zero means there is no limit.
+func WithMaxValueSize(n int) Option {
+	return func(i *Interpreter) {
+		i.maxValueSize = n
+	}
+}
+
+// WithMaxVariables limits the number of variables which may exist at
+// once, counting those in every active procedure call.  Zero means there
+// is no limit.
+func WithMaxVariables(n int) Option {
+	return func(i *Interpreter) {
+		i.maxVariables = n
+	}
+}
+
+// isFatal returns true if the given error should abort the script, and
+// be reported to the host unchanged.
+func isFatal(err error) bool {
+	return errors.Is(err, ErrCancelled) ||
+		errors.Is(err, ErrMaxCommands) ||
+		errors.Is(err, ErrMaxDepth) ||
+		errors.Is(err, ErrMaxValueSize) ||
+		errors.Is(err, ErrMaxVariables)
+}
+
+// countCommand records that a command is about to be executed, by us and
+// by each of our parents, and returns an error if that exceeds any of
+// their limits.
+func (i *Interpreter) countCommand() error {
+	for in := i; in != nil; in = in.parent {
+		in.commands++
+		if in.maxCommands > 0 && in.commands > in.maxCommands {
+			return fmt.Errorf("%w: limit is %d", ErrMaxCommands, in.maxCommands)
+		}
+	}
+	return nil
+}
+
+// setVar sets the value of a variable in the current scope, updating
+// the variable of a parent-scope if one exists, subject to our limits.
+func (i *Interpreter) setVar(name string, value string) error {
+	_, exists := i.environment.Get(name)
+	err := i.checkVar(value, exists)
+	if err != nil {
+		return err
+	}
+	i.environment.Set(name, value)
+	i.varChanged(name)
+	return nil
+}
+
+// setLocal creates a variable in the current scope, subject to our
+// limits.  This is used for the parameters of procedures, which are
+// always new variables.
+func (i *Interpreter) setLocal(name string, value string) error {
+	err := i.checkVar(value, false)
+	if err != nil {
+		return err
+	}
+	i.environment.SetLocal(name, value)
+	return nil
+}
+
+// checkVar tests that storing the given value, in a variable which
+// may or may not already exist, is within our limits.
+func (i *Interpreter) checkVar(value string, exists bool) error {
+	if i.maxValueSize > 0 && len(value) > i.maxValueSize {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrMaxValueSize, len(value), i.maxValueSize)
+	}
+	if exists {
+		return nil
+	}
+	for in := i; in != nil; in = in.parent {
+		if in.maxVariables > 0 && in.variables() >= in.maxVariables {
+			return fmt.Errorf("%w: limit is %d", ErrMaxVariables, in.maxVariables)
+		}
+	}
+	return nil
+}
+
+// variables returns the number of variables which exist, in every active
+// procedure call, including those of our children.
+func (i *Interpreter) variables() int {
+	n := i.environment.Count()
+	for _, child := range i.children {
+		n += child.variables()
+	}
+	return n
+}
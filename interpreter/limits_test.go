@@ -0,0 +1,129 @@
+package interpreter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+
+	type TestCase struct {
+		input  string
+		option Option
+		err    error
+	}
+
+	tests := []TestCase{
+		{input: `while {1} {}`, option: WithMaxCommands(100), err: ErrMaxCommands},
+		{input: `for {set i 0} {1} {incr i} { set a $i }`, option: WithMaxCommands(100), err: ErrMaxCommands},
+		{input: `proc r {} { r }; r`, option: WithMaxDepth(10), err: ErrMaxDepth},
+		{input: `proc r {x} { if { 1 } { r $x } }; r 1`, option: WithMaxDepth(10), err: ErrMaxDepth},
+		{input: `proc r {} { r }; r`, err: ErrMaxDepth},
+		{input: `set a 12345`, option: WithMaxValueSize(4), err: ErrMaxValueSize},
+		{input: `while {1} { append a x }`, option: WithMaxValueSize(1024), err: ErrMaxValueSize},
+		{input: `proc p {x} { }; p 12345`, option: WithMaxValueSize(4), err: ErrMaxValueSize},
+		{input: `set a 1; set b 2; set c 3`, option: WithMaxVariables(2), err: ErrMaxVariables},
+		{input: `set a 1; set b 2; proc p {x} { }; p 1`, option: WithMaxVariables(2), err: ErrMaxVariables},
+		{input: `for {set i 0} {1} {incr i} { set a$i $i }`, option: WithMaxVariables(100), err: ErrMaxVariables},
+
+		// Child interpreters have the same limits.
+		{input: `interp create c; interp eval c {while {1} {}}`, option: WithMaxCommands(100), err: ErrMaxCommands},
+		{input: `interp create c; interp eval c {proc r {} { r }; r}`, option: WithMaxDepth(10), err: ErrMaxDepth},
+		{input: `interp create c; interp eval c {set a 12345}`, option: WithMaxValueSize(4), err: ErrMaxValueSize},
+		{input: `interp create c; interp eval c {set a 1; set b 2}`, option: WithMaxVariables(1), err: ErrMaxVariables},
+		{input: `set a 1; interp create c; interp eval c {set b 2}`, option: WithMaxVariables(1), err: ErrMaxVariables},
+		{input: `interp create c; interp create {c d}; interp eval {c d} {while {1} {}}`, option: WithMaxCommands(100), err: ErrMaxCommands},
+	}
+
+	for _, test := range tests {
+
+		var opts []Option
+		if test.option != nil {
+			opts = append(opts, test.option)
+		}
+
+		e, err := New(test.input, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error creating interpreter for %s: %s", test.input, err)
+		}
+
+		_, err = e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error running %s, got none", test.input)
+		}
+		if !errors.Is(err, test.err) {
+			t.Fatalf("expected %s running %s, got %s", test.err, test.input, err)
+		}
+	}
+}
+
+func TestWithinLimits(t *testing.T) {
+
+	tests := []string{
+		`set a 1; set a 2; set a 3`,
+		`set a 1; set b 2; proc p {x} { set x 2 }; p 1`,
+		`set a 1234; append a ""`,
+		`proc fib {n} { if { expr $n < 2 } { return $n }; expr [fib [expr $n - 1]] + [fib [expr $n - 2]] }; fib 10`,
+	}
+
+	for _, test := range tests {
+
+		e, err := New(test,
+			WithMaxCommands(10000),
+			WithMaxDepth(20),
+			WithMaxValueSize(4),
+			WithMaxVariables(20))
+		if err != nil {
+			t.Fatalf("unexpected error creating interpreter for %s: %s", test, err)
+		}
+
+		_, err = e.Evaluate()
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test, err)
+		}
+	}
+}
+
+func TestMaxDepthDisabled(t *testing.T) {
+
+	e, err := New(`proc r {x} { if { $x > 0 } { r [expr $x - 1] } }; r 2000`, WithMaxDepth(0))
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter: %s", err)
+	}
+
+	_, err = e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The depth is restored after the calls return.
+	if e.depth != 0 {
+		t.Fatalf("depth wasn't restored, got %d", e.depth)
+	}
+}
+
+// TestChildLimits ensures that the commands a child executes count towards
+// the limit of its parent, even if the child has a limit of its own.
+func TestChildLimits(t *testing.T) {
+
+	e, err := New("", WithMaxCommands(100))
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter: %s", err)
+	}
+
+	child, err := e.CreateChild("c", WithMaxCommands(0))
+	if err != nil {
+		t.Fatalf("unexpected error creating child: %s", err)
+	}
+
+	_, err = child.Eval(`while {1} {}`)
+	if !errors.Is(err, ErrMaxCommands) {
+		t.Fatalf("expected %s, got %v", ErrMaxCommands, err)
+	}
+
+	// The parent's budget has been spent.
+	_, err = e.Eval(`set a 1`)
+	if !errors.Is(err, ErrMaxCommands) {
+		t.Fatalf("expected %s, got %v", ErrMaxCommands, err)
+	}
+}
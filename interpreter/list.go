@@ -0,0 +1,187 @@
+package interpreter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/skx/critical/lexer"
+)
+
+// parseList splits the given string into the elements of a TCL list.
+//
+// Elements are separated by whitespace, and may be surrounded by braces
+// or double-quotes to allow them to contain whitespace of their own.
+func parseList(str string) ([]string, error) {
+
+	src := []rune(str)
+	out := []string{}
+
+	i := 0
+	for {
+
+		// skip leading whitespace
+		for i < len(src) && isListSpace(src[i]) {
+			i++
+		}
+		if i >= len(src) {
+			return out, nil
+		}
+
+		elem := ""
+
+		switch src[i] {
+		case '{':
+			depth := 1
+			start := i + 1
+			i++
+			for i < len(src) && depth > 0 {
+				switch src[i] {
+				case '\\':
+					i++
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+				i++
+			}
+			if depth > 0 {
+				return nil, fmt.Errorf("unmatched open brace in list")
+			}
+			elem = string(src[start : i-1])
+
+		case '"':
+			i++
+			closed := false
+			for i < len(src) {
+				if src[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				if src[i] == '\\' {
+					val, n := lexer.Backslash(src[i:])
+					elem += val
+					i += n
+					continue
+				}
+				elem += string(src[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unmatched open quote in list")
+			}
+
+		default:
+			for i < len(src) && !isListSpace(src[i]) {
+				if src[i] == '\\' {
+					val, n := lexer.Backslash(src[i:])
+					elem += val
+					i += n
+					continue
+				}
+				elem += string(src[i])
+				i++
+			}
+			out = append(out, elem)
+			continue
+		}
+
+		// braced and quoted elements must be followed by whitespace
+		if i < len(src) && !isListSpace(src[i]) {
+			return nil, fmt.Errorf("list element in braces or quotes followed by \"%c\" instead of space", src[i])
+		}
+		out = append(out, elem)
+	}
+}
+
+// formatList joins the given elements into a TCL list, quoting them as
+// required.
+func formatList(elements []string) string {
+	out := make([]string, len(elements))
+	for i, e := range elements {
+		out[i] = quoteListElement(e)
+	}
+	return strings.Join(out, " ")
+}
+
+// formatDict converts the given map into a TCL dictionary, which is a list
+// of alternating keys and values.  The keys are sorted, so that the output
+// is stable.
+func formatDict(dict map[string]string) string {
+	keys := make([]string, 0, len(dict))
+	for k := range dict {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	elements := make([]string, 0, len(dict)*2)
+	for _, k := range keys {
+		elements = append(elements, k, dict[k])
+	}
+	return formatList(elements)
+}
+
+// quoteListElement quotes a single element such that it will be parsed
+// as a single element by parseList.
+func quoteListElement(elem string) string {
+
+	if elem == "" {
+		return "{}"
+	}
+
+	// Is quoting required at all?
+	if !strings.ContainsAny(elem, " \t\n\r\v\f{}[]$;\"\\") && elem[0] != '#' {
+		return elem
+	}
+
+	// Can we use braces?  Only if they'll balance, and the
+	// element doesn't end with a backslash.
+	depth := 0
+	balanced := true
+	src := []rune(elem)
+	for i := 0; i < len(src) && balanced; i++ {
+		switch src[i] {
+		case '\\':
+			if i == len(src)-1 {
+				balanced = false
+			}
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth < 0 {
+				balanced = false
+			}
+		}
+	}
+	if balanced && depth == 0 {
+		return "{" + elem + "}"
+	}
+
+	// Otherwise we escape each special character.
+	out := ""
+	for _, c := range src {
+		switch c {
+		case '\n':
+			out += "\\n"
+		case '\t':
+			out += "\\t"
+		case ' ', '{', '}', '[', ']', '$', ';', '"', '\\', '\r', '\v', '\f':
+			out += "\\" + string(c)
+		default:
+			out += string(c)
+		}
+	}
+	if strings.HasPrefix(out, "#") {
+		out = "\\" + out
+	}
+	return out
+}
+
+// isListSpace returns true if the character separates list elements.
+func isListSpace(ch rune) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\v' || ch == '\f'
+}
@@ -0,0 +1,97 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseList(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output []string
+	}
+
+	tests := []TestCase{
+		{Input: ``, Output: []string{}},
+		{Input: `   `, Output: []string{}},
+		{Input: `a b c`, Output: []string{"a", "b", "c"}},
+		{Input: "  a\tb\n c  ", Output: []string{"a", "b", "c"}},
+		{Input: `a {b c} d`, Output: []string{"a", "b c", "d"}},
+		{Input: `{a {b c}} {}`, Output: []string{"a {b c}", ""}},
+		{Input: `"a b" "c\td"`, Output: []string{"a b", "c\td"}},
+		{Input: `a\ b c\x41`, Output: []string{"a b", "cA"}},
+		{Input: `{a\}b}`, Output: []string{`a\}b`}},
+	}
+
+	for _, test := range tests {
+		out, err := parseList(test.Input)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %s: %s", test.Input, err)
+		}
+		if strings.Join(out, ",") != strings.Join(test.Output, ",") || len(out) != len(test.Output) {
+			t.Fatalf("unexpected result parsing %s: got %q", test.Input, out)
+		}
+	}
+
+	// Errors
+	errors := []string{
+		`{a b`,
+		`"a b`,
+		`{a}b`,
+		`"a"b`,
+	}
+	for _, test := range errors {
+		_, err := parseList(test)
+		if err == nil {
+			t.Fatalf("expected error parsing %s, got none", test)
+		}
+	}
+}
+
+func TestFormatList(t *testing.T) {
+
+	type TestCase struct {
+		Input  []string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: []string{}, Output: ``},
+		{Input: []string{"a", "b"}, Output: `a b`},
+		{Input: []string{"a b", ""}, Output: `{a b} {}`},
+		{Input: []string{"$x", "[y]", "#z"}, Output: `{$x} {[y]} {#z}`},
+		{Input: []string{"a{b"}, Output: `a\{b`},
+		{Input: []string{"a}b c"}, Output: `a\}b\ c`},
+		{Input: []string{"trailing\\"}, Output: `trailing\\`},
+		{Input: []string{"#{"}, Output: `\#\{`},
+	}
+
+	for _, test := range tests {
+		out := formatList(test.Input)
+		if out != test.Output {
+			t.Fatalf("unexpected result formatting %q: got %s expected %s", test.Input, out, test.Output)
+		}
+
+		// Ensure we can parse it back
+		back, err := parseList(out)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %s: %s", out, err)
+		}
+		if len(back) != len(test.Input) {
+			t.Fatalf("round-trip failed for %q: %q", test.Input, back)
+		}
+		for n := range back {
+			if back[n] != test.Input[n] {
+				t.Fatalf("round-trip failed for %q: %q", test.Input, back)
+			}
+		}
+	}
+}
+
+func TestFormatDict(t *testing.T) {
+	out := formatDict(map[string]string{"b": "2", "a": "one value"})
+	if out != "a {one value} b 2" {
+		t.Fatalf("unexpected dict: %s", out)
+	}
+}
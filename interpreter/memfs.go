@@ -0,0 +1,459 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryFileSystem is a FileSystem which holds its contents in memory,
+// which is useful for tests, and for hosts which wish to give scripts
+// a scratch area without touching the disk.
+//
+// Paths are slash-separated, and relative paths are relative to the root.
+// The directory "/tmp" exists initially, and is used for temporary files.
+//
+// A MemoryFileSystem is safe for concurrent use.
+type MemoryFileSystem struct {
+
+	// clock is used to set the modification times of files.
+	clock Clock
+
+	// mutex protects the nodes, and their contents.
+	mutex sync.Mutex
+
+	// nodes holds each file and directory, keyed by cleaned path.
+	nodes map[string]*memNode
+
+	// temp is used to generate the names of temporary files.
+	temp int
+}
+
+// memNode is a single file or directory.
+type memNode struct {
+	name    string
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewMemoryFileSystem creates a new, empty, MemoryFileSystem.
+func NewMemoryFileSystem() *MemoryFileSystem {
+	return NewMemoryFileSystemWithClock(systemClock{})
+}
+
+// NewMemoryFileSystemWithClock creates a new, empty, MemoryFileSystem,
+// whose files have modification times taken from the given clock, such
+// as a FakeClock.
+func NewMemoryFileSystemWithClock(clock Clock) *MemoryFileSystem {
+	m := &MemoryFileSystem{clock: clock, nodes: make(map[string]*memNode)}
+	now := clock.Now()
+	m.nodes["/"] = &memNode{name: "/", mode: fs.ModeDir | 0755, modTime: now}
+	m.nodes["/tmp"] = &memNode{name: "tmp", mode: fs.ModeDir | 0777, modTime: now}
+	return m
+}
+
+// WriteFile creates the named file, and any missing parent directories,
+// with the given contents.  It is intended to populate the filesystem
+// before it is used.
+func (m *MemoryFileSystem) WriteFile(name string, data []byte) error {
+	p := m.clean(name)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Create the parents.
+	parts := strings.Split(strings.TrimPrefix(path.Dir(p), "/"), "/")
+	dir := "/"
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		dir = path.Join(dir, part)
+		n, ok := m.nodes[dir]
+		if !ok {
+			m.nodes[dir] = &memNode{name: part, mode: fs.ModeDir | 0755, modTime: m.clock.Now()}
+			continue
+		}
+		if !n.mode.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: dir, Err: errNotDir}
+		}
+	}
+
+	if n, ok := m.nodes[p]; ok && n.mode.IsDir() {
+		return &fs.PathError{Op: "open", Path: name, Err: errIsDir}
+	}
+	m.nodes[p] = &memNode{name: path.Base(p), data: append([]byte{}, data...), mode: 0644, modTime: m.clock.Now()}
+	return nil
+}
+
+var (
+	// errNotDir is returned when a directory was expected.
+	errNotDir = errors.New("not a directory")
+
+	// errIsDir is returned when a directory wasn't expected.
+	errIsDir = errors.New("is a directory")
+
+	// errNotEmpty is returned when removing a directory which has
+	// contents.
+	errNotEmpty = errors.New("directory not empty")
+)
+
+// clean converts a path to the form we use as a key.
+func (m *MemoryFileSystem) clean(name string) string {
+	return path.Clean("/" + name)
+}
+
+// parent checks that the directory which would contain the given path
+// exists.  The mutex must be held.
+func (m *MemoryFileSystem) parent(op string, p string) error {
+	n, ok := m.nodes[path.Dir(p)]
+	if !ok {
+		return &fs.PathError{Op: op, Path: p, Err: fs.ErrNotExist}
+	}
+	if !n.mode.IsDir() {
+		return &fs.PathError{Op: op, Path: p, Err: errNotDir}
+	}
+	return nil
+}
+
+// OpenFile opens the named file.
+func (m *MemoryFileSystem) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	p := m.clean(name)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	n, ok := m.nodes[p]
+	if ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	}
+	if !ok {
+		if flag&os.O_CREATE == 0 {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		if err := m.parent("open", p); err != nil {
+			return nil, err
+		}
+		n = &memNode{name: path.Base(p), mode: perm.Perm(), modTime: m.clock.Now()}
+		m.nodes[p] = n
+	}
+	if n.mode.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errIsDir}
+	}
+
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	if flag&os.O_TRUNC != 0 && writable {
+		n.data = nil
+		n.modTime = m.clock.Now()
+	}
+
+	return &memFile{
+		fs:       m,
+		node:     n,
+		readable: flag&os.O_WRONLY == 0,
+		writable: writable,
+		append:   flag&os.O_APPEND != 0,
+	}, nil
+}
+
+// Stat returns information about the named file.
+func (m *MemoryFileSystem) Stat(name string) (fs.FileInfo, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	n, ok := m.nodes[m.clean(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return n.info(), nil
+}
+
+// Lstat returns information about the named file.  There are no symbolic
+// links in a MemoryFileSystem, so this is the same as Stat.
+func (m *MemoryFileSystem) Lstat(name string) (fs.FileInfo, error) {
+	return m.Stat(name)
+}
+
+// ReadDir returns the entries of the named directory, sorted by name.
+func (m *MemoryFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	p := m.clean(name)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	n, ok := m.nodes[p]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	if !n.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+
+	var out []fs.DirEntry
+	for k, child := range m.nodes {
+		if k != "/" && path.Dir(k) == p {
+			out = append(out, fs.FileInfoToDirEntry(child.info()))
+		}
+	}
+	sort.Slice(out, func(a, b int) bool {
+		return out[a].Name() < out[b].Name()
+	})
+	return out, nil
+}
+
+// Mkdir creates the named directory.
+func (m *MemoryFileSystem) Mkdir(name string, perm fs.FileMode) error {
+	p := m.clean(name)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.nodes[p]; ok {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	}
+	if err := m.parent("mkdir", p); err != nil {
+		return err
+	}
+	m.nodes[p] = &memNode{name: path.Base(p), mode: fs.ModeDir | perm.Perm(), modTime: m.clock.Now()}
+	return nil
+}
+
+// Remove removes the named file, or empty directory.
+func (m *MemoryFileSystem) Remove(name string) error {
+	p := m.clean(name)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	n, ok := m.nodes[p]
+	if !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if n.mode.IsDir() {
+		for k := range m.nodes {
+			if strings.HasPrefix(k, strings.TrimSuffix(p, "/")+"/") && k != p {
+				return &fs.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+			}
+		}
+	}
+	delete(m.nodes, p)
+	return nil
+}
+
+// Rename moves a file, or directory, to a new name.  Any existing file
+// with the new name is replaced.
+func (m *MemoryFileSystem) Rename(oldpath string, newpath string) error {
+	src := m.clean(oldpath)
+	dst := m.clean(newpath)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	n, ok := m.nodes[src]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+	if err := m.parent("rename", dst); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	if src == dst {
+		return nil
+	}
+	if n.mode.IsDir() && strings.HasPrefix(dst, src+"/") {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrInvalid}
+	}
+	if existing, ok := m.nodes[dst]; ok {
+		if existing.mode.IsDir() != n.mode.IsDir() {
+			err := errIsDir
+			if !existing.mode.IsDir() {
+				err = errNotDir
+			}
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+		}
+		for k := range m.nodes {
+			if strings.HasPrefix(k, dst+"/") {
+				return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errNotEmpty}
+			}
+		}
+	}
+
+	// Move the node, and anything beneath it.
+	moved := make(map[string]*memNode)
+	for k, child := range m.nodes {
+		if k == src || strings.HasPrefix(k, src+"/") {
+			moved[dst+strings.TrimPrefix(k, src)] = child
+			delete(m.nodes, k)
+		}
+	}
+	for k, child := range moved {
+		m.nodes[k] = child
+	}
+	n.name = path.Base(dst)
+	return nil
+}
+
+// CreateTemp creates a new temporary file, within "/tmp" if no directory
+// is given.
+func (m *MemoryFileSystem) CreateTemp(dir string, pattern string) (File, string, error) {
+	if dir == "" {
+		dir = "/tmp"
+	}
+
+	for {
+		m.mutex.Lock()
+		m.temp++
+		suffix := fmt.Sprintf("%d", m.temp)
+		m.mutex.Unlock()
+
+		name := pattern + suffix
+		if strings.Contains(pattern, "*") {
+			idx := strings.LastIndex(pattern, "*")
+			name = pattern[:idx] + suffix + pattern[idx+1:]
+		}
+		name = path.Join(m.clean(dir), name)
+
+		f, err := m.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		return f, name, nil
+	}
+}
+
+// Getwd returns the root, as paths are relative to it.
+func (m *MemoryFileSystem) Getwd() (string, error) {
+	return "/", nil
+}
+
+// info returns information about the node.
+func (n *memNode) info() fs.FileInfo {
+	return &memInfo{name: n.name, size: int64(len(n.data)), mode: n.mode, modTime: n.modTime}
+}
+
+// memInfo describes a file within a MemoryFileSystem.
+type memInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// Name returns the base name of the file.
+func (i *memInfo) Name() string { return i.name }
+
+// Size returns the length of the file, in bytes.
+func (i *memInfo) Size() int64 { return i.size }
+
+// Mode returns the mode of the file.
+func (i *memInfo) Mode() fs.FileMode { return i.mode }
+
+// ModTime returns the time the file was last modified.
+func (i *memInfo) ModTime() time.Time { return i.modTime }
+
+// IsDir returns true if the file is a directory.
+func (i *memInfo) IsDir() bool { return i.mode.IsDir() }
+
+// Sys returns nil.
+func (i *memInfo) Sys() any { return nil }
+
+// memFile is a file which has been opened within a MemoryFileSystem.
+type memFile struct {
+	fs       *MemoryFileSystem
+	node     *memNode
+	offset   int64
+	readable bool
+	writable bool
+	append   bool
+	closed   bool
+}
+
+// Read reads from the file.
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mutex.Lock()
+	defer f.fs.mutex.Unlock()
+
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if !f.readable {
+		return 0, errors.New("file not opened for reading")
+	}
+	if f.offset >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+// Write writes to the file.
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mutex.Lock()
+	defer f.fs.mutex.Unlock()
+
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if !f.writable {
+		return 0, errors.New("file not opened for writing")
+	}
+	if f.append {
+		f.offset = int64(len(f.node.data))
+	}
+
+	end := f.offset + int64(len(p))
+	if end > int64(len(f.node.data)) {
+		data := make([]byte, end)
+		copy(data, f.node.data)
+		f.node.data = data
+	}
+	copy(f.node.data[f.offset:], p)
+	f.offset = end
+	f.node.modTime = f.fs.clock.Now()
+	return len(p), nil
+}
+
+// Seek changes our position within the file.
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	f.fs.mutex.Lock()
+	defer f.fs.mutex.Unlock()
+
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.node.data))
+	}
+	if offset < 0 {
+		return 0, errors.New("invalid argument")
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// Close closes the file.
+func (f *memFile) Close() error {
+	f.fs.mutex.Lock()
+	defer f.fs.mutex.Unlock()
+
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+	return nil
+}
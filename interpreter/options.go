@@ -0,0 +1,40 @@
+package interpreter
+
+import (
+	"io"
+
+	"github.com/skx/critical/lexer"
+)
+
+// Option is used to configure the interpreter, when it is created.
+type Option func(i *Interpreter)
+
+// WithComments sets the style of comments which will be recognized
+// when parsing the program, and any code evaluated later.
+func WithComments(style lexer.CommentStyle) Option {
+	return func(i *Interpreter) {
+		i.comments = style
+	}
+}
+
+// WithStdout sets the writer which the "stdout" channel writes to, which
+// is where the output of `puts` goes by default.
+func WithStdout(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.stdout = w
+	}
+}
+
+// WithStderr sets the writer which the "stderr" channel writes to.
+func WithStderr(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.stderr = w
+	}
+}
+
+// WithStdin sets the reader which the "stdin" channel reads from.
+func WithStdin(r io.Reader) Option {
+	return func(i *Interpreter) {
+		i.stdin = r
+	}
+}
@@ -0,0 +1,292 @@
+package interpreter
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WithScriptPath records the path of the script which is being executed,
+// which is used to find any files it loads via `source`.
+func WithScriptPath(path string) Option {
+	return func(i *Interpreter) {
+		i.scriptPath = path
+	}
+}
+
+// RegisterPackage makes a package available to `package require`, which
+// will load it by evaluating the given script.
+//
+// The script should call `package provide` with the same name and
+// version.
+func (i *Interpreter) RegisterPackage(name string, version string, script string) error {
+	if _, err := parseVersion(version); err != nil {
+		return err
+	}
+
+	// The versions are replaced, rather than updated, as they may
+	// be shared with a clone.
+	versions := make(map[string]string, len(i.ifneeded[name])+1)
+	for v, s := range i.ifneeded[name] {
+		versions[v] = s
+	}
+	versions[version] = script
+	i.ifneeded[name] = versions
+	return nil
+}
+
+// RegisterPackageFS makes the packages contained within a filesystem,
+// such as an embed.FS, available to `package require`.
+//
+// Each ".tcl" file beneath the root of the filesystem, or within the
+// directories immediately below it, is examined, and if it contains a
+// top-level `package provide name version` command it is registered as
+// that package.  Each package must be contained within a single file.
+func (i *Interpreter) RegisterPackageFS(fsys fs.FS) error {
+
+	var files []string
+	for _, pattern := range []string{"*.tcl", "*/*.tcl"} {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return err
+		}
+		files = append(files, matches...)
+	}
+
+	for _, file := range files {
+		if path.Base(file) == "pkgIndex.tcl" {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		script, err := i.parse(string(data))
+		if err != nil {
+			return fmt.Errorf("error parsing %s: %s", file, err)
+		}
+
+		// Find the `package provide` command.
+		for _, cmd := range script.Commands {
+			var words []string
+			for _, w := range cmd.Words {
+				lit, ok := w.Literal()
+				if !ok {
+					break
+				}
+				words = append(words, lit)
+			}
+
+			if len(words) == 4 && words[0] == "package" && words[1] == "provide" {
+				err = i.RegisterPackage(words[2], words[3], string(data))
+				if err != nil {
+					return fmt.Errorf("error registering %s: %s", file, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// requirePackage loads the named package, if it hasn't already been
+// loaded, and returns the version which was loaded.
+//
+// If exact is true then only the given version is acceptable, otherwise
+// the highest version which satisfies the requirement is loaded.
+func (i *Interpreter) requirePackage(name string, version string, exact bool) (string, error) {
+
+	satisfies := func(have string) bool {
+		if version == "" {
+			return true
+		}
+		if exact {
+			return compareVersions(have, version) == 0
+		}
+		return satisfiesVersion(have, version)
+	}
+
+	// Already loaded?
+	if have, ok := i.packages[name]; ok {
+		if !satisfies(have) {
+			return "", fmt.Errorf("version conflict for package \"%s\": have %s, need %s", name, have, version)
+		}
+		return have, nil
+	}
+
+	// Find the best version which is available, searching our
+	// auto_path if we don't know of any.
+	best := i.bestVersion(name, satisfies)
+	if best == "" {
+		err := i.loadIndexes()
+		if err != nil {
+			return "", err
+		}
+		best = i.bestVersion(name, satisfies)
+	}
+	if best == "" {
+		if version != "" {
+			return "", fmt.Errorf("can't find package %s %s", name, version)
+		}
+		return "", fmt.Errorf("can't find package %s", name)
+	}
+
+	// Load the package at the global scope.
+	old := i.environment
+	i.environment = i.globals
+	_, err := i.Eval(i.ifneeded[name][best])
+	i.environment = old
+
+	if err != nil && err != ErrReturn {
+		return "", err
+	}
+
+	have, ok := i.packages[name]
+	if !ok || have != best {
+		return "", fmt.Errorf("attempt to provide package %s %s failed: package %s %s not provided", name, best, name, best)
+	}
+	return have, nil
+}
+
+// bestVersion returns the highest version of the package which we can
+// load, and which is acceptable.
+func (i *Interpreter) bestVersion(name string, acceptable func(string) bool) string {
+	best := ""
+	for v := range i.ifneeded[name] {
+		if acceptable(v) && (best == "" || compareVersions(v, best) > 0) {
+			best = v
+		}
+	}
+	return best
+}
+
+// loadIndexes evaluates any `pkgIndex.tcl` files within the directories
+// listed in the global `auto_path` variable, or the directories directly
+// below them, which we haven't already evaluated.
+//
+// Each index is executed with the variable `dir` set to the directory
+// which contains it, and is expected to call `package ifneeded` for each
+// package it knows about.
+//
+// Safe interpreters may not access the filesystem, so never search.
+func (i *Interpreter) loadIndexes() error {
+
+	if i.safe {
+		return nil
+	}
+
+	dirs, err := i.GetListVariable("auto_path")
+	if err != nil {
+		return nil
+	}
+
+	var indexes []string
+	for _, dir := range dirs {
+		indexes = append(indexes, path.Join(dir, "pkgIndex.tcl"))
+
+		entries, _ := i.filesystem.ReadDir(dir)
+		for _, entry := range entries {
+			if entry.IsDir() {
+				indexes = append(indexes, path.Join(dir, entry.Name(), "pkgIndex.tcl"))
+			}
+		}
+	}
+
+	for _, index := range indexes {
+		if i.indexes[index] {
+			continue
+		}
+		if _, err := i.filesystem.Stat(index); err != nil {
+			continue
+		}
+		i.indexes[index] = true
+
+		old, hadOld := i.globals.Get("dir")
+		i.globals.SetLocal("dir", path.Dir(index))
+
+		_, err := i.sourceFile(index)
+
+		if hadOld {
+			i.globals.SetLocal("dir", old)
+		} else {
+			i.globals.Clear("dir")
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseVersion splits a version number, such as "1.2.3", into its
+// components.
+func parseVersion(version string) ([]int, error) {
+	var out []int
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || strings.HasPrefix(part, "+") {
+			return nil, fmt.Errorf("expected version number but got \"%s\"", version)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// compareVersions compares two version numbers, returning -1, 0, or 1 if
+// the first is less than, equal to, or greater than the second.
+//
+// Invalid versions are compared as strings.
+func compareVersions(a string, b string) int {
+	va, errA := parseVersion(a)
+	vb, errB := parseVersion(b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+
+	for n := 0; n < len(va) && n < len(vb); n++ {
+		if va[n] < vb[n] {
+			return -1
+		}
+		if va[n] > vb[n] {
+			return 1
+		}
+	}
+
+	switch {
+	case len(va) < len(vb):
+		return -1
+	case len(va) > len(vb):
+		return 1
+	}
+	return 0
+}
+
+// satisfiesVersion returns true if the version we have satisfies the
+// version which is required, which means it has the same major version,
+// and is at least as recent.
+func satisfiesVersion(have string, need string) bool {
+	vh, err := parseVersion(have)
+	if err != nil {
+		return false
+	}
+	vn, err := parseVersion(need)
+	if err != nil {
+		return false
+	}
+	return vh[0] == vn[0] && compareVersions(have, need) >= 0
+}
+
+// sortedKeys returns the keys of the given map, in sorted order.
+func sortedKeys(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
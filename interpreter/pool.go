@@ -0,0 +1,56 @@
+package interpreter
+
+import "sync"
+
+// Pool hands out interpreters which are copies of a template, allowing
+// the same script to be executed by many goroutines concurrently.
+//
+// The template is typically an interpreter which has already evaluated
+// the standard library, and any procedures the scripts need, so that the
+// interpreters returned by Get are ready to use:
+//
+//	template, _ := interpreter.New(stdlib + rules)
+//	template.Evaluate()
+//
+//	pool := interpreter.NewPool(template)
+//
+//	i := pool.Get()
+//	defer pool.Put(i)
+//	out, err := i.Eval("check $request")
+//
+// The template must not be modified once the pool has been created.
+type Pool struct {
+
+	// template is the interpreter we copy.
+	template *Interpreter
+
+	// pool holds the interpreters which are available for reuse.
+	pool sync.Pool
+}
+
+// NewPool creates a pool of interpreters, each of which is a copy of the
+// given template.
+func NewPool(template *Interpreter) *Pool {
+	p := &Pool{template: template}
+	p.pool.New = func() any {
+		return template.Clone()
+	}
+	return p
+}
+
+// Get returns an interpreter from the pool, which is a copy of the
+// template.
+func (p *Pool) Get() *Interpreter {
+	return p.pool.Get().(*Interpreter)
+}
+
+// Put returns an interpreter to the pool, once it is no longer required.
+//
+// The state of the interpreter is reset to that of the template, so any
+// variables or procedures which were defined by the script it executed
+// are discarded.  Any channels it opened are closed, as if via Close, so
+// buffered output isn't lost.
+func (p *Pool) Put(i *Interpreter) {
+	i.copyFrom(p.template)
+	p.pool.Put(i)
+}
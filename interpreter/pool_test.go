@@ -0,0 +1,116 @@
+package interpreter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+
+	template, err := New(`set greeting Hello; proc greet {name} { return "$greeting, $name" }`)
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	_, err = template.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	pool := NewPool(template)
+
+	// Modify an interpreter, then return it to the pool.
+	i := pool.Get()
+	out, err := i.Eval(`set greeting Bye; proc extra {} {}; greet Steve`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "Bye, Steve" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	pool.Put(i)
+
+	// Whichever interpreter we get next is reset.
+	i = pool.Get()
+	out, err = i.Eval(`greet Steve`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "Hello, Steve" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if _, ok := i.functions["extra"]; ok {
+		t.Fatalf("procedure leaked between uses")
+	}
+	pool.Put(i)
+}
+
+func TestPoolConcurrent(t *testing.T) {
+
+	template, err := New(`proc square {n} { expr $n * $n }`)
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+	_, err = template.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	pool := NewPool(template)
+
+	var wg sync.WaitGroup
+	for n := 0; n < 16; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+
+			for x := 0; x < 20; x++ {
+				i := pool.Get()
+
+				out, err := i.Eval(fmt.Sprintf(`set v %d; square $v`, n))
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				if out != fmt.Sprintf("%d", n*n) {
+					t.Errorf("unexpected output for %d: %s", n, out)
+				}
+
+				pool.Put(i)
+			}
+		}(n)
+	}
+	wg.Wait()
+}
+
+func TestPoolClosesChannels(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "out.txt")
+
+	template, err := New(``)
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	pool := NewPool(template)
+
+	// The script never closes the file it writes.
+	i := pool.Get()
+	_, err = i.Eval(`set f [open {` + path + `} w]; puts $f hello`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	pool.Put(i)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read file: %s", err)
+	}
+	if string(data) != "hello\n" {
+		t.Fatalf("unexpected contents: %q", data)
+	}
+	if len(i.channels) != 3 {
+		t.Fatalf("expected only the standard channels, got %d", len(i.channels))
+	}
+}
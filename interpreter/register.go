@@ -0,0 +1,334 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// contextType is the type of context.Context
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+	// errorType is the type of error
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+	// stringerType is the type of fmt.Stringer
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// RegisterFunc registers a golang function, of any supported signature,
+// which will be available to the TCL environment under the given name.
+//
+// The arguments the function is called with are converted from strings
+// automatically, and a suitable error is returned if they cannot be, or
+// if the wrong number of arguments are supplied.  Supported argument types
+// are strings, booleans, integers, floating-point numbers, slices (which
+// are read from TCL lists), and maps with string keys (which are read from
+// TCL dictionaries).  The function may be variadic, and it may accept a
+// context.Context as its first argument, which will be the context the
+// script is being evaluated with.
+//
+// The function may return a single value, of any of the supported types,
+// and may also return a trailing error.
+//
+// For example:
+//
+//	i.RegisterFunc("move", func(x, y float64) { ... })
+//	i.RegisterFunc("sum", func(nums ...int) int { ... })
+func (i *Interpreter) RegisterFunc(name string, fn any) error {
+
+	if fn == nil {
+		return fmt.Errorf("%s: expected a function, got nil", name)
+	}
+
+	val := reflect.ValueOf(fn)
+	typ := val.Type()
+
+	if typ.Kind() != reflect.Func {
+		return fmt.Errorf("%s: expected a function, got %s", name, typ)
+	}
+
+	// Does the function want a context?
+	wantContext := typ.NumIn() > 0 && typ.In(0) == contextType
+
+	// The types of the arguments we'll convert.
+	var params []reflect.Type
+	for n := 0; n < typ.NumIn(); n++ {
+		if n == 0 && wantContext {
+			continue
+		}
+
+		t := typ.In(n)
+		if typ.IsVariadic() && n == typ.NumIn()-1 {
+			t = t.Elem()
+		}
+		if !isConvertible(t) {
+			return fmt.Errorf("%s: unsupported argument type %s", name, t)
+		}
+		params = append(params, t)
+	}
+
+	// Does the function return an error?
+	outputs := typ.NumOut()
+	returnsError := outputs > 0 && typ.Out(outputs-1) == errorType
+	if returnsError {
+		outputs--
+	}
+	if outputs > 1 {
+		return fmt.Errorf("%s: functions may return only a single value, and an error", name)
+	}
+	if outputs == 1 && !isConvertible(typ.Out(0)) && !typ.Out(0).Implements(stringerType) {
+		return fmt.Errorf("%s: unsupported return type %s", name, typ.Out(0))
+	}
+
+	usage := funcUsage(name, params, typ.IsVariadic())
+
+	i.RegisterBuiltin(name, func(i *Interpreter, args []string) (string, error) {
+
+		// Test argument count
+		required := len(params)
+		if typ.IsVariadic() {
+			required--
+			if len(args) < required {
+				return "", fmt.Errorf("wrong # args: should be \"%s\"", usage)
+			}
+		} else if len(args) != required {
+			return "", fmt.Errorf("wrong # args: should be \"%s\"", usage)
+		}
+
+		// Convert the arguments
+		var in []reflect.Value
+		if wantContext {
+			in = append(in, reflect.ValueOf(i.ctx))
+		}
+		for n, arg := range args {
+			t := params[len(params)-1]
+			if n < len(params) {
+				t = params[n]
+			}
+
+			v, err := fromString(arg, t)
+			if err != nil {
+				return "", err
+			}
+			in = append(in, v)
+		}
+
+		// Call the function
+		out := val.Call(in)
+
+		// A nil pointer returned as an error is treated as success,
+		// since its Error method may not be callable.
+		if returnsError && !isNil(out[len(out)-1]) {
+			return "", out[len(out)-1].Interface().(error)
+		}
+
+		if outputs == 0 {
+			return "", nil
+		}
+		return toString(out[0])
+	})
+
+	return nil
+}
+
+// funcUsage returns a usage-string for a function, built from the types
+// of its arguments.
+func funcUsage(name string, params []reflect.Type, variadic bool) string {
+	out := []string{name}
+	for n, t := range params {
+		if variadic && n == len(params)-1 {
+			out = append(out, "?"+t.String()+" ...?")
+		} else {
+			out = append(out, t.String())
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// isConvertible returns true if we can convert values of the given type
+// to and from strings.
+func isConvertible(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return isConvertible(t.Elem())
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && isConvertible(t.Elem())
+	}
+	return false
+}
+
+// fromString converts the given string to a value of the given type.
+func fromString(str string, t reflect.Type) (reflect.Value, error) {
+
+	v := reflect.New(t).Elem()
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(str)
+
+	case reflect.Bool:
+		b, err := parseBool(str)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(str, 0, t.Bits())
+		if err != nil {
+			return v, fmt.Errorf("expected integer but got \"%s\"", str)
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(str, 0, t.Bits())
+		if err != nil {
+			return v, fmt.Errorf("expected unsigned integer but got \"%s\"", str)
+		}
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(str, t.Bits())
+		if err != nil {
+			return v, fmt.Errorf("expected floating-point number but got \"%s\"", str)
+		}
+		v.SetFloat(f)
+
+	case reflect.Slice:
+		elements, err := parseList(str)
+		if err != nil {
+			return v, err
+		}
+		v = reflect.MakeSlice(t, 0, len(elements))
+		for _, e := range elements {
+			ev, err := fromString(e, t.Elem())
+			if err != nil {
+				return v, err
+			}
+			v = reflect.Append(v, ev)
+		}
+
+	case reflect.Map:
+		elements, err := parseList(str)
+		if err != nil {
+			return v, err
+		}
+		if len(elements)%2 != 0 {
+			return v, fmt.Errorf("missing value to go with key")
+		}
+		v = reflect.MakeMapWithSize(t, len(elements)/2)
+		for n := 0; n < len(elements); n += 2 {
+			ev, err := fromString(elements[n+1], t.Elem())
+			if err != nil {
+				return v, err
+			}
+			v.SetMapIndex(reflect.ValueOf(elements[n]).Convert(t.Key()), ev)
+		}
+	}
+
+	return v, nil
+}
+
+// toString converts the given value to a string.
+//
+// Values which implement fmt.Stringer are converted via their String
+// method, which makes types such as time.Duration readable.  Nil pointers
+// become empty strings.
+func toString(v reflect.Value) (string, error) {
+
+	if isNil(v) {
+		return "", nil
+	}
+
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+
+	case reflect.Bool:
+		if v.Bool() {
+			return "1", nil
+		}
+		return "0", nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+
+	case reflect.Slice:
+		elements := make([]string, v.Len())
+		for n := 0; n < v.Len(); n++ {
+			str, err := toString(v.Index(n))
+			if err != nil {
+				return "", err
+			}
+			elements[n] = str
+		}
+		return formatList(elements), nil
+
+	case reflect.Map:
+		dict := make(map[string]string, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			str, err := toString(iter.Value())
+			if err != nil {
+				return "", err
+			}
+			dict[iter.Key().String()] = str
+		}
+		return formatDict(dict), nil
+	}
+
+	return "", fmt.Errorf("unsupported return type %s", v.Type())
+}
+
+// isNil returns true if the value is a nil pointer, interface, or map,
+// including a nil pointer held by an interface.
+func isNil(v reflect.Value) bool {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map:
+		return v.IsNil()
+	}
+	return false
+}
+
+// parseBool parses a TCL boolean value, which is one of "0", "1", "true",
+// "false", "yes", "no", "on", or "off", in any case, or another number,
+// which is true if it isn't zero.  Every command which accepts a boolean
+// uses it.
+func parseBool(str string) (bool, error) {
+	switch strings.ToLower(str) {
+	case "1", "true", "yes", "on":
+		return true, nil
+	case "0", "false", "no", "off":
+		return false, nil
+	}
+
+	// Any other number is true, if it is non-zero.
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return false, fmt.Errorf("expected boolean value but got \"%s\"", str)
+	}
+	return f != 0, nil
+}
@@ -0,0 +1,204 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// point is a fmt.Stringer whose String method can't be called upon a nil
+// pointer.
+type point struct {
+	x, y int
+}
+
+func (p *point) String() string {
+	return fmt.Sprintf("%d,%d", p.x, p.y)
+}
+
+// pointError is an error whose Error method can't be called upon a nil
+// pointer.
+type pointError struct {
+	msg string
+}
+
+func (p *pointError) Error() string {
+	return p.msg
+}
+
+func TestRegisterFunc(t *testing.T) {
+
+	e, er := New("")
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	// position which is updated by our function
+	x := 0.0
+	y := 0.0
+
+	funcs := map[string]any{
+		"move": func(a, b float64) {
+			x = a
+			y = b
+		},
+		"add":    func(a int, b int64) int64 { return int64(a) + b },
+		"neg":    func(a bool) bool { return !a },
+		"u8":     func(a uint8) uint8 { return a },
+		"concat": func(a ...string) string { return strings.Join(a, "") },
+		"sum": func(prefix string, nums ...int) string {
+			total := 0
+			for _, n := range nums {
+				total += n
+			}
+			return prefix + strconv.Itoa(total)
+		},
+		"double": func(nums []float64) []float64 {
+			for i := range nums {
+				nums[i] *= 2
+			}
+			return nums
+		},
+		"keys": func(m map[string]int) map[string]int {
+			for k := range m {
+				m[k]++
+			}
+			return m
+		},
+		"fail": func(msg string) (string, error) {
+			return "", errors.New(msg)
+		},
+		"ok": func() (string, error) {
+			return "fine", nil
+		},
+		"ctx": func(ctx context.Context, a string) string {
+			if ctx == nil {
+				return "missing"
+			}
+			return a
+		},
+		"duration": func(a int64) time.Duration { return time.Duration(a) * time.Second },
+		"words":    func(a string) []string { return strings.Split(a, ",") },
+		"point": func(set bool) *point {
+			if set {
+				return &point{x: 1, y: 2}
+			}
+			return nil
+		},
+		"stringer": func() fmt.Stringer {
+			var p *point
+			return p
+		},
+		"check": func() (int, error) {
+			var p *pointError
+			return 3, p
+		},
+	}
+
+	for name, fn := range funcs {
+		err := e.RegisterFunc(name, fn)
+		if err != nil {
+			t.Fatalf("unexpected error registering %s: %s", name, err)
+		}
+	}
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: `move 1.5 -3`, Output: ``},
+		{Input: `add 2 0x10`, Output: `18`},
+		{Input: `neg true`, Output: `0`},
+		{Input: `neg 0`, Output: `1`},
+		{Input: `neg off`, Output: `1`},
+		{Input: `u8 255`, Output: `255`},
+		{Input: `concat`, Output: ``},
+		{Input: `concat a b c`, Output: `abc`},
+		{Input: `sum total: 1 2 3`, Output: `total:6`},
+		{Input: `double {1 2.5 3}`, Output: `2 5 6`},
+		{Input: `keys {a 1 b 2}`, Output: `a 2 b 3`},
+		{Input: `ok`, Output: `fine`},
+		{Input: `ctx hello`, Output: `hello`},
+		{Input: `duration 90`, Output: `1m30s`},
+		{Input: `words "a b,c"`, Output: `{a b} c`},
+		{Input: `point 1`, Output: `1,2`},
+		{Input: `point 0`, Output: ``},
+		{Input: `stringer`, Output: ``},
+		{Input: `check`, Output: `3`},
+	}
+
+	for _, test := range tests {
+		out, err := e.Eval(test.Input)
+		if err != nil {
+			t.Fatalf("unexpected error running %s: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %s - got %s, but expected %s", test.Input, out, test.Output)
+		}
+	}
+
+	if x != 1.5 || y != -3 {
+		t.Fatalf("move didn't update the position")
+	}
+
+	// Errors
+	errs := map[string]string{
+		`move 1`:           `wrong # args: should be "move float64 float64"`,
+		`move 1 2 3`:       `wrong # args: should be "move float64 float64"`,
+		`move one 2`:       `expected floating-point number but got "one"`,
+		`add 1.5 2`:        `expected integer but got "1.5"`,
+		`u8 256`:           `expected unsigned integer but got "256"`,
+		`neg maybe`:        `expected boolean value but got "maybe"`,
+		`sum`:              `wrong # args: should be "sum string ?int ...?"`,
+		`sum total: 1 x`:   `expected integer but got "x"`,
+		`double {1 {2}x}`:  `followed by`,
+		`keys {a 1 b}`:     `missing value to go with key`,
+		`keys {a one}`:     `expected integer but got "one"`,
+		`fail {it broke}`:  `it broke`,
+		`ctx`:              `wrong # args: should be "ctx string"`,
+		`ok extra`:         `wrong # args: should be "ok"`,
+		`double {1 "2}`:    `unmatched open quote`,
+		`double {1 {2 3}}`: `expected floating-point number but got "2 3"`,
+	}
+	for input, expected := range errs {
+		_, err := e.Eval(input)
+		if err == nil {
+			t.Fatalf("expected an error running %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("wrong error running %s: %s", input, err)
+		}
+	}
+}
+
+func TestRegisterFuncInvalid(t *testing.T) {
+
+	e, er := New("")
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	invalid := map[string]any{
+		"nil":       nil,
+		"string":    "steve",
+		"channel":   func(c chan int) {},
+		"map":       func(m map[int]string) {},
+		"outputs":   func() (string, string) { return "", "" },
+		"struct":    func() struct{} { return struct{}{} },
+		"errors":    func() (error, error) { return nil, nil },
+		"slice-ptr": func(a []*int) {},
+	}
+
+	for name, fn := range invalid {
+		err := e.RegisterFunc(name, fn)
+		if err == nil {
+			t.Fatalf("expected error registering %s, got none", name)
+		}
+	}
+}
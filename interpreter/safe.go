@@ -0,0 +1,96 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrHidden is returned when a script attempts to call a command
+	// which has been hidden, for example within a safe interpreter.
+	ErrHidden = errors.New("command is hidden")
+)
+
+// unsafeCommands contains the names of the builtin commands which are
+// hidden in a safe interpreter, because they allow access to the host.
+var unsafeCommands = []string{
+	"env",
+	"exec",
+	"exit",
+	"file",
+	"glob",
+	"http::geturl",
+	"http::serve",
+	"open",
+	"socket",
+	"source",
+}
+
+// WithSafe creates a safe interpreter, which may be used to execute
+// untrusted scripts.
+//
+// A safe interpreter only contains commands which cannot affect the host,
+// for example there is no `env` command to read the environment, no `exit`
+// command to terminate the process, and no `open` command to access files.
+// These commands are hidden, rather than removed, so the host may make them
+// available again via Expose.
+//
+// A safe interpreter has no `stdin`, `stdout`, or `stderr` channels, unless
+// the host supplies them via WithStdin, WithStdout, or WithStderr.
+//
+// Packages which the host registered, via RegisterPackage or
+// RegisterPackageFS, may still be loaded via `package require`, but the
+// directories listed in `$auto_path` are never searched.
+func WithSafe() Option {
+	return func(i *Interpreter) {
+		i.safe = true
+	}
+}
+
+// IsSafe returns true if the interpreter was created with WithSafe.
+func (i *Interpreter) IsSafe() bool {
+	return i.safe
+}
+
+// hideUnsafe hides each of the builtins which aren't permitted within
+// a safe interpreter.
+func (i *Interpreter) hideUnsafe() {
+	for _, name := range unsafeCommands {
+		_ = i.Hide(name)
+	}
+}
+
+// Hide makes the named builtin command unavailable to scripts.  A script
+// which attempts to call it will receive an error matching ErrHidden.
+func (i *Interpreter) Hide(name string) error {
+	fn, ok := i.builtins[name]
+	if !ok {
+		return fmt.Errorf("unknown command %s", name)
+	}
+	i.hidden[name] = fn
+	delete(i.builtins, name)
+	return nil
+}
+
+// Expose makes a hidden command available to scripts again.
+func (i *Interpreter) Expose(name string) error {
+	fn, ok := i.hidden[name]
+	if !ok {
+		return fmt.Errorf("unknown hidden command %s", name)
+	}
+	i.builtins[name] = fn
+	delete(i.hidden, name)
+	return nil
+}
+
+// HiddenCommands returns the names of the commands which are hidden,
+// in sorted order.
+func (i *Interpreter) HiddenCommands() []string {
+	out := make([]string, 0, len(i.hidden))
+	for name := range i.hidden {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,146 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSafe(t *testing.T) {
+
+	// These commands are hidden.
+	for _, cmd := range unsafeCommands {
+
+		e, err := New(cmd+` 1`, WithSafe())
+		if err != nil {
+			t.Fatalf("unexpected error creating interpreter: %s", err)
+		}
+		if !e.IsSafe() {
+			t.Fatalf("interpreter isn't safe")
+		}
+
+		_, err = e.Evaluate()
+		if err == nil {
+			t.Fatalf("expected error calling %s, got none", cmd)
+		}
+		if !errors.Is(err, ErrHidden) {
+			t.Fatalf("expected hidden-error calling %s, got %s", cmd, err)
+		}
+	}
+
+	// These are fine.
+	e, err := New(`set a 3; incr a; proc p {x} { return [expr $x * 2] }; p $a`, WithSafe())
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter: %s", err)
+	}
+	out, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out != "8" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+
+	// A normal interpreter isn't safe.
+	e, err = New(`exit 3`)
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter: %s", err)
+	}
+	if e.IsSafe() {
+		t.Fatalf("interpreter is unexpectedly safe")
+	}
+	if len(e.HiddenCommands()) != 0 {
+		t.Fatalf("unexpected hidden commands: %v", e.HiddenCommands())
+	}
+	_, err = e.Evaluate()
+	if err != ErrExit {
+		t.Fatalf("expected exit, got %v", err)
+	}
+}
+
+// TestSafeChannels tests that a safe interpreter only has the standard
+// channels which the host supplies.
+func TestSafeChannels(t *testing.T) {
+
+	for _, input := range []string{`gets stdin`, `read stdin`, `puts hello`, `puts stderr hello`} {
+		e, err := New(input, WithSafe())
+		if err != nil {
+			t.Fatalf("unexpected error creating interpreter: %s", err)
+		}
+		_, err = e.Evaluate()
+		if err == nil || !strings.Contains(err.Error(), "can not find channel") {
+			t.Fatalf("expected error for %s, got %v", input, err)
+		}
+	}
+
+	stdout := &bytes.Buffer{}
+	e, err := New(`puts [gets stdin]`, WithSafe(), WithStdin(strings.NewReader("hello\n")), WithStdout(stdout))
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter: %s", err)
+	}
+	_, err = e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stdout.String() != "hello\n" {
+		t.Fatalf("unexpected output: %q", stdout.String())
+	}
+}
+
+func TestExposeHide(t *testing.T) {
+
+	e, err := New(`exit 3`, WithSafe())
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter: %s", err)
+	}
+
+	hidden := e.HiddenCommands()
+	if len(hidden) != len(unsafeCommands) {
+		t.Fatalf("unexpected hidden commands: %v", hidden)
+	}
+
+	// Exposing a command makes it available
+	err = e.Expose("exit")
+	if err != nil {
+		t.Fatalf("unexpected error exposing exit: %s", err)
+	}
+	out, err := e.Evaluate()
+	if err != ErrExit || out != "3" {
+		t.Fatalf("expected exit, got %s %v", out, err)
+	}
+
+	// It can't be exposed twice
+	err = e.Expose("exit")
+	if err == nil {
+		t.Fatalf("expected error exposing exit twice")
+	}
+
+	// Hide it again
+	err = e.Hide("exit")
+	if err != nil {
+		t.Fatalf("unexpected error hiding exit: %s", err)
+	}
+	_, err = e.Evaluate()
+	if !errors.Is(err, ErrHidden) {
+		t.Fatalf("expected hidden-error, got %v", err)
+	}
+
+	// Unknown commands can't be hidden
+	err = e.Hide("missing")
+	if err == nil {
+		t.Fatalf("expected error hiding a missing command")
+	}
+
+	// Registering a builtin replaces the hidden command
+	e.RegisterBuiltin("exit", func(i *Interpreter, args []string) (string, error) {
+		return "replaced", nil
+	})
+	out, err = e.Evaluate()
+	if err != nil || out != "replaced" {
+		t.Fatalf("expected replaced command, got %s %v", out, err)
+	}
+	if len(e.HiddenCommands()) != len(unsafeCommands)-1 {
+		t.Fatalf("unexpected hidden commands: %v", e.HiddenCommands())
+	}
+}
@@ -0,0 +1,49 @@
+package interpreter
+
+import "fmt"
+
+// SetVariable sets the value of a global variable, creating it if it
+// doesn't already exist.
+//
+// This allows a host application to configure a script before it is
+// executed.
+func (i *Interpreter) SetVariable(name string, value string) {
+	i.globals.SetLocal(name, value)
+	i.varChanged(name)
+}
+
+// GetVariable returns the value of a global variable, and whether it
+// was found.
+//
+// This allows a host application to retrieve results from a script
+// after it has been executed.
+func (i *Interpreter) GetVariable(name string) (string, bool) {
+	return i.globals.Get(name)
+}
+
+// UnsetVariable removes a global variable.
+func (i *Interpreter) UnsetVariable(name string) {
+	i.globals.Clear(name)
+}
+
+// Variables returns a copy of all the global variables, and their values.
+func (i *Interpreter) Variables() map[string]string {
+	return i.globals.Variables()
+}
+
+// SetListVariable sets the value of a global variable to a TCL list,
+// containing the given elements.
+func (i *Interpreter) SetListVariable(name string, elements []string) {
+	i.SetVariable(name, formatList(elements))
+}
+
+// GetListVariable returns the elements of the TCL list held in a global
+// variable.  An error is returned if the variable doesn't exist, or if
+// its value is not a valid list.
+func (i *Interpreter) GetListVariable(name string) ([]string, error) {
+	val, ok := i.GetVariable(name)
+	if !ok {
+		return nil, fmt.Errorf("can't read \"%s\": no such variable", name)
+	}
+	return parseList(val)
+}
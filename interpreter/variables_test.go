@@ -0,0 +1,118 @@
+package interpreter
+
+import "testing"
+
+func TestVariables(t *testing.T) {
+
+	e, er := New(`
+proc double {x} {
+    set local 1
+    expr $x * 2
+}
+set result [double $input]
+`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	// Seed the input before running
+	e.SetVariable("input", "21")
+
+	_, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error running script: %s", err)
+	}
+
+	out, ok := e.GetVariable("result")
+	if !ok {
+		t.Fatalf("result was not set")
+	}
+	if out != "42" {
+		t.Fatalf("unexpected result: %s", out)
+	}
+
+	// The local variable in the procedure is gone.
+	_, ok = e.GetVariable("local")
+	if ok {
+		t.Fatalf("local variable was visible")
+	}
+
+	vars := e.Variables()
+	if len(vars) != 2 || vars["input"] != "21" || vars["result"] != "42" {
+		t.Fatalf("unexpected variables: %v", vars)
+	}
+
+	// Remove the result
+	e.UnsetVariable("result")
+	_, ok = e.GetVariable("result")
+	if ok {
+		t.Fatalf("result was still present after unset")
+	}
+}
+
+// TestVariablesGlobal ensures that the variables are global, even when
+// used from within a procedure.
+func TestVariablesGlobal(t *testing.T) {
+
+	e, er := New("")
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	e.RegisterBuiltin("store", func(i *Interpreter, args []string) (string, error) {
+		i.SetVariable("stored", args[0])
+		return args[0], nil
+	})
+
+	_, err := e.Eval(`proc save {x} { store $x }; save 17`)
+	if err != nil {
+		t.Fatalf("unexpected error running script: %s", err)
+	}
+
+	out, err := e.Eval(`set stored`)
+	if err != nil {
+		t.Fatalf("unexpected error running script: %s", err)
+	}
+	if out != "17" {
+		t.Fatalf("unexpected result: %s", out)
+	}
+}
+
+func TestListVariables(t *testing.T) {
+
+	e, er := New(`set result "$args {[set args]}"`)
+	if er != nil {
+		t.Fatalf("unexpected error creating interpreter")
+	}
+
+	e.SetListVariable("args", []string{"one", "two words", ""})
+
+	val, ok := e.GetVariable("args")
+	if !ok || val != "one {two words} {}" {
+		t.Fatalf("unexpected value %q", val)
+	}
+
+	_, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error running script: %s", err)
+	}
+
+	list, err := e.GetListVariable("result")
+	if err != nil {
+		t.Fatalf("unexpected error reading list: %s", err)
+	}
+	if len(list) != 4 || list[1] != "two words" || list[3] != "one {two words} {}" {
+		t.Fatalf("unexpected list %q", list)
+	}
+
+	// Errors
+	_, err = e.GetListVariable("missing")
+	if err == nil {
+		t.Fatalf("expected error reading missing variable")
+	}
+	e.SetVariable("bad", "{unbalanced")
+	_, err = e.GetListVariable("bad")
+	if err == nil {
+		t.Fatalf("expected error reading invalid list")
+	}
+}
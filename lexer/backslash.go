@@ -0,0 +1,124 @@
+package lexer
+
+// Backslash decodes a single backslash-sequence, following the rules of
+// TCL's backslash-substitution.
+//
+// The input must begin with the "\" character.  The return values are the
+// text the sequence expands to, and the number of characters of input which
+// were consumed - including the leading backslash.
+//
+// The following sequences are recognized:
+//
+//	\a \b \f \n \r \t \v   - The usual control-characters.
+//	\<newline><whitespace> - Replaced by a single space.
+//	\ooo                   - One to three octal digits.
+//	\xhh                   - One or two hexadecimal digits.
+//	\uhhhh                 - One to four hexadecimal digits.
+//	\Uhhhhhhhh             - One to eight hexadecimal digits.
+//
+// Any other character following the backslash is returned literally,
+// which means `\\`, `\"`, `\$`, `\[` and `\{` are handled as expected.
+func Backslash(input []rune) (string, int) {
+
+	// A trailing backslash is returned as-is.
+	if len(input) < 2 {
+		return "\\", len(input)
+	}
+
+	switch input[1] {
+	case 'a':
+		return "\a", 2
+	case 'b':
+		return "\b", 2
+	case 'f':
+		return "\f", 2
+	case 'n':
+		return "\n", 2
+	case 'r':
+		return "\r", 2
+	case 't':
+		return "\t", 2
+	case 'v':
+		return "\v", 2
+	case '\n':
+		// Swallow the newline, and any leading whitespace
+		// upon the following line.
+		n := 2
+		for n < len(input) && isWhitespace(input[n]) {
+			n++
+		}
+		return " ", n
+	case 'x':
+		return readCodePoint(input, 2, 16, 2, "x")
+	case 'u':
+		return readCodePoint(input, 2, 16, 4, "u")
+	case 'U':
+		return readCodePoint(input, 2, 16, 8, "U")
+	}
+
+	if isOctal(input[1]) {
+		return readCodePoint(input, 1, 8, 3, "")
+	}
+
+	// Anything else is just the character itself.
+	return string(input[1]), 2
+}
+
+// readCodePoint reads up to max digits of the given base, starting at the
+// given offset, and returns the character they represent.
+//
+// If there are no digits present then the fallback value is returned
+// instead, which covers things like "\xyz" becoming "xyz".
+func readCodePoint(input []rune, offset int, base rune, max int, fallback string) (string, int) {
+
+	val := rune(0)
+	n := offset
+
+	// Octal escapes are limited to a single byte, everything else
+	// to the unicode range.
+	limit := rune(0x10FFFF)
+	if base == 8 {
+		limit = 0xFF
+	}
+
+	for n < len(input) && n-offset < max {
+
+		d := digitValue(input[n])
+		if d < 0 || d >= base {
+			break
+		}
+
+		// Don't allow the value to exceed the limit.
+		if val*base+d > limit {
+			break
+		}
+
+		val = val*base + d
+		n++
+	}
+
+	// No digits?  Then the character is taken literally.
+	if n == offset {
+		return fallback, offset
+	}
+
+	return string(val), n
+}
+
+// digitValue returns the value of the given hexadecimal digit, or -1.
+func digitValue(ch rune) rune {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return ch - '0'
+	case 'a' <= ch && ch <= 'f':
+		return ch - 'a' + 10
+	case 'A' <= ch && ch <= 'F':
+		return ch - 'A' + 10
+	}
+	return -1
+}
+
+// Is the given character an octal digit?
+func isOctal(ch rune) bool {
+	return rune('0') <= ch && ch <= rune('7')
+}
@@ -0,0 +1,35 @@
+package lexer
+
+import (
+	"strings"
+
+	"github.com/skx/critical/token"
+)
+
+// IsComplete returns true if the input contains only complete commands,
+// so that it could be executed.
+//
+// Input is incomplete if it contains an unterminated string, block, or
+// command-substitution, or if it ends with a backslash-newline.  This is
+// used to decide whether an interactive user should be prompted for more
+// input.
+func IsComplete(input string, opts ...Option) bool {
+
+	// A trailing backslash continues the command.
+	trimmed := strings.TrimSuffix(input, "\n")
+	slashes := len(trimmed) - len(strings.TrimRight(trimmed, "\\"))
+	if slashes%2 == 1 {
+		return false
+	}
+
+	l := New(input, opts...)
+	for {
+		tok := l.NextToken()
+		if tok.Type == token.EOF {
+			return true
+		}
+		if tok.Type == token.ILLEGAL && strings.HasPrefix(tok.Literal, "unterminated") {
+			return false
+		}
+	}
+}
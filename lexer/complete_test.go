@@ -0,0 +1,55 @@
+package lexer
+
+import "testing"
+
+func TestIsComplete(t *testing.T) {
+
+	complete := []string{
+		``,
+		`puts hello`,
+		"puts hello\n",
+		`puts "hello world"`,
+		`proc foo {} { puts [expr 1 + 2] }`,
+		"proc foo {} {\n\tputs hi\n}\n",
+		`puts \{`,
+		`puts "\""`,
+		`puts \\`,
+		"puts \\\\\n",
+		`puts "[set a "b"]"`,
+		"# comment with a {\nputs ok",
+
+		// Errors which more input won't fix are complete
+		`puts ]`,
+		`puts }`,
+	}
+
+	incomplete := []string{
+		`puts "hello`,
+		`proc foo {} {`,
+		"proc foo {} {\n\tputs hi\n",
+		`puts [expr 1 +`,
+		`puts "[expr 1 + 2"`,
+		"puts hello \\",
+		"puts hello \\\n",
+		`if { 1 } { puts {nested }`,
+	}
+
+	for _, input := range complete {
+		if !IsComplete(input) {
+			t.Fatalf("expected %q to be complete", input)
+		}
+	}
+	for _, input := range incomplete {
+		if IsComplete(input) {
+			t.Fatalf("expected %q to be incomplete", input)
+		}
+	}
+
+	// Comment styles
+	if IsComplete(`puts hi // {`) {
+		t.Fatalf("expected input to be incomplete with TCL comments")
+	}
+	if !IsComplete(`puts hi // {`, WithComments(CComments)) {
+		t.Fatalf("expected input to be complete with C comments")
+	}
+}
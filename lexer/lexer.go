@@ -22,6 +22,30 @@ import (
 	"github.com/skx/critical/token"
 )
 
+// CommentStyle controls which comments the lexer recognizes.
+type CommentStyle int
+
+const (
+	// TCLComments is the default style, and follows the TCL rules:
+	// "#" begins a comment only where a command may begin.
+	TCLComments CommentStyle = iota
+
+	// CComments treats both "#" and "//" as the start of a comment
+	// anywhere a token may begin.  This was our historical behaviour,
+	// and is retained for older scripts.
+	CComments
+)
+
+// Option is used to configure the lexer, when it is created.
+type Option func(l *Lexer)
+
+// WithComments sets the comment-style the lexer will use.
+func WithComments(style CommentStyle) Option {
+	return func(l *Lexer) {
+		l.comments = style
+	}
+}
+
 // Lexer is used as the lexer for our deployr "language".
 type Lexer struct {
 	debug        bool                 // dump tokens as they're read?
@@ -30,17 +54,24 @@ type Lexer struct {
 	ch           rune                 // current character
 	characters   []rune               // rune slice of input string
 	lookup       map[rune]token.Token // lookup map for simple tokens
+	comments     CommentStyle         // the comments we recognize
+	commandStart bool                 // could a command begin here?
 }
 
 // New a Lexer instance from string input.
-func New(input string) *Lexer {
+func New(input string, opts ...Option) *Lexer {
 	l := &Lexer{
-		characters: []rune(input),
-		debug:      false,
-		lookup:     make(map[rune]token.Token),
+		characters:   []rune(input),
+		debug:        false,
+		lookup:       make(map[rune]token.Token),
+		commandStart: true,
 	}
 	l.readChar()
 
+	for _, opt := range opts {
+		opt(l)
+	}
+
 	if os.Getenv("DEBUG_LEXER") == "true" {
 		l.debug = true
 	}
@@ -85,6 +116,9 @@ func (l *Lexer) NextToken() token.Token {
 		fmt.Printf("%v\n", tok)
 	}
 
+	// A new command may begin after the end of the previous one.
+	l.commandStart = tok.Type == token.NEWLINE || tok.Type == token.SEMICOLON
+
 	return tok
 }
 
@@ -92,19 +126,39 @@ func (l *Lexer) NextToken() token.Token {
 // token from our input string.
 func (l *Lexer) nextTokenReal() token.Token {
 
-	// Return value
-	var tok token.Token
-
 	// Skip whitespace
 	l.skipWhitespace()
 
 	// skip single-line comments
-	if l.ch == rune('#') ||
-		(l.ch == rune('/') && l.peekChar() == rune('/')) {
+	if l.isComment() {
 		l.skipComment()
 		return (l.NextToken())
 	}
 
+	// Read the token, and record where it came from.
+	start := l.offset(l.position)
+	tok := l.readToken()
+	tok.Position = start
+	tok.Raw = string(l.characters[start:l.offset(l.position)])
+
+	return tok
+}
+
+// offset clamps the given position to the length of our input, as our
+// position may move beyond the end of the input once we've reached it.
+func (l *Lexer) offset(pos int) int {
+	if pos > len(l.characters) {
+		return len(l.characters)
+	}
+	return pos
+}
+
+// readToken reads the token which begins at the current character.
+func (l *Lexer) readToken() token.Token {
+
+	// Return value
+	var tok token.Token
+
 	// Was this a simple token-type?
 	val, ok := l.lookup[l.ch]
 	if ok {
@@ -236,27 +290,71 @@ func (l *Lexer) readDecimal() token.Token {
 }
 
 // read Identifier
+//
+// Backslash-sequences are processed, so that `foo\ bar` is read as a
+// single identifier containing a space.
 func (l *Lexer) readIdentifier() string {
-	position := l.position
+	out := ""
+
 	for isIdentifier(l.ch) {
+
+		if l.ch == '\\' {
+
+			// A backslash-newline separates words, so it
+			// terminates the identifier.
+			if l.peekChar() == '\n' {
+				break
+			}
+
+			out += l.readBackslash()
+			l.readChar()
+			continue
+		}
+
+		out += string(l.ch)
 		l.readChar()
 	}
-	return string(l.characters[position:l.position])
+	return out
 }
 
 // skip white space
+//
+// A backslash followed by a newline is also regarded as whitespace, which
+// allows commands to be continued over multiple lines.
 func (l *Lexer) skipWhitespace() {
-	for isWhitespace(l.ch) {
+	for isWhitespace(l.ch) || (l.ch == '\\' && l.peekChar() == '\n') {
+		if l.ch == '\\' {
+			l.readChar()
+		}
 		l.readChar()
 	}
 }
 
+// isComment returns true if a comment begins at the current position.
+func (l *Lexer) isComment() bool {
+
+	if l.comments == CComments {
+		return l.ch == rune('#') ||
+			(l.ch == rune('/') && l.peekChar() == rune('/'))
+	}
+
+	return l.ch == rune('#') && l.commandStart
+}
+
 // skip comment (until the end of the line).
+//
+// With TCL-style comments a backslash-newline continues the comment
+// onto the following line.
 func (l *Lexer) skipComment() {
 
 	// Read forever, or until we've ended.
 	for l.ch != rune(0) {
 
+		// Continuation?  Then skip the newline.
+		if l.comments == TCLComments && l.ch == '\\' && l.peekChar() == '\n' {
+			l.readChar()
+		}
+
 		// Get the next char
 		l.readChar()
 
@@ -286,31 +384,21 @@ func (l *Lexer) readString() (string, error) {
 		// Handle \n, \r, \t, \", etc.
 		//
 		if l.ch == '\\' {
+			out += l.readBackslash()
+			continue
+		}
 
-			// Line ending with "\" + newline
-			if l.peekChar() == '\n' {
-				// consume the newline.
-				l.readChar()
-				continue
-			}
-
-			l.readChar()
-
-			if l.ch == rune('n') {
-				l.ch = '\n'
-			}
-			if l.ch == rune('r') {
-				l.ch = '\r'
-			}
-			if l.ch == rune('t') {
-				l.ch = '\t'
-			}
-			if l.ch == rune('"') {
-				l.ch = '"'
-			}
-			if l.ch == rune('\\') {
-				l.ch = '\\'
+		//
+		// Command-substitutions may contain quotes of their own,
+		// so they're read as a unit.
+		//
+		if l.ch == '[' {
+			str, err := l.readEval()
+			if err != nil {
+				return "", err
 			}
+			out += "[" + str + "]"
+			continue
 		}
 		out = out + string(l.ch)
 
@@ -319,9 +407,114 @@ func (l *Lexer) readString() (string, error) {
 	return out, nil
 }
 
+// readBackslash processes the backslash-sequence which begins at the
+// current character, and returns the text it expands to.
+//
+// Upon return the current character is the last one which was part of
+// the sequence.
+func (l *Lexer) readBackslash() string {
+	str, n := Backslash(l.characters[l.position:])
+
+	for n > 1 {
+		l.readChar()
+		n--
+	}
+	return str
+}
+
 // read "[ xxxx ]"
 func (l *Lexer) readEval() (string, error) {
-	return l.readNestedPair('[', ']')
+	start := l.position
+
+	end := MatchingBracket(l.characters, start)
+	if end < 0 {
+		rest := string(l.characters[start+1:])
+		for l.ch != rune(0) {
+			l.readChar()
+		}
+		return "", fmt.Errorf("unterminated pair [-] current:%s", rest)
+	}
+
+	for l.position < end {
+		l.readChar()
+	}
+	return string(l.characters[start+1 : end]), nil
+}
+
+// MatchingBracket returns the offset of the "]" which matches the "[" found
+// at the given offset, or -1 if there is none.
+//
+// Brackets which are escaped, or which are within braces or quotes at the
+// start of a word, don't count towards the nesting.
+func MatchingBracket(src []rune, start int) int {
+	depth := 0
+	wordStart := false
+
+	for i := start; i < len(src); i++ {
+		ch := src[i]
+
+		switch {
+		case ch == '\\':
+			// skip the escaped character
+			i++
+		case ch == '[':
+			depth++
+		case ch == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case ch == '{' && wordStart:
+			i = matchingBrace(src, i)
+		case ch == '"' && wordStart:
+			i = matchingQuote(src, i)
+		}
+		if i < 0 || i >= len(src) {
+			return -1
+		}
+
+		wordStart = ch == '[' || ch == ';' || ch == '\n' || isWhitespace(ch)
+	}
+	return -1
+}
+
+// matchingBrace returns the offset of the "}" which matches the "{" found
+// at the given offset, or -1 if there is none.
+func matchingBrace(src []rune, start int) int {
+	depth := 0
+	for i := start; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// matchingQuote returns the offset of the quote which closes the string
+// beginning at the given offset, or -1 if there is none.
+func matchingQuote(src []rune, start int) int {
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '[':
+			i = MatchingBracket(src, i)
+			if i < 0 {
+				return -1
+			}
+		case '"':
+			return i
+		}
+	}
+	return -1
 }
 
 // read "{ xxxx }"
@@ -366,6 +559,12 @@ func (l *Lexer) readNestedPair(open rune, close rune) (string, error) {
 			return "", fmt.Errorf("unterminated pair %c-%c depth:%d current:%s", open, close, depth, out)
 		}
 
+		// escaped characters don't count towards the nesting.
+		if l.ch == '\\' && l.peekChar() != rune(0) {
+			out = out + string(l.ch)
+			l.readChar()
+		}
+
 		out = out + string(l.ch)
 
 	}
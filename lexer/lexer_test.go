@@ -87,6 +87,129 @@ func TestStringEscape(t *testing.T) {
 	}
 }
 
+// TestBackslash tests the complete set of backslash-sequences.
+func TestBackslash(t *testing.T) {
+
+	type TestCase struct {
+		input    string
+		output   string
+		consumed int
+	}
+
+	tests := []TestCase{
+		{input: `\a`, output: "\a", consumed: 2},
+		{input: `\b`, output: "\b", consumed: 2},
+		{input: `\f`, output: "\f", consumed: 2},
+		{input: `\n`, output: "\n", consumed: 2},
+		{input: `\r`, output: "\r", consumed: 2},
+		{input: `\t`, output: "\t", consumed: 2},
+		{input: `\v`, output: "\v", consumed: 2},
+		{input: `\\`, output: "\\", consumed: 2},
+		{input: `\"`, output: "\"", consumed: 2},
+		{input: `\$`, output: "$", consumed: 2},
+		{input: `\[`, output: "[", consumed: 2},
+		{input: `\{`, output: "{", consumed: 2},
+		{input: `\ `, output: " ", consumed: 2},
+		{input: `\q`, output: "q", consumed: 2},
+		{input: `\`, output: "\\", consumed: 1},
+
+		// newline + whitespace collapses to a single space
+		{input: "\\\n", output: " ", consumed: 2},
+		{input: "\\\n  \tx", output: " ", consumed: 5},
+
+		// octal
+		{input: `\0`, output: "\x00", consumed: 2},
+		{input: `\101`, output: "A", consumed: 4},
+		{input: `\1011`, output: "A", consumed: 4},
+		{input: `\18`, output: "\x01", consumed: 2},
+		{input: `\777`, output: "?", consumed: 3},
+
+		// hex
+		{input: `\x41`, output: "A", consumed: 4},
+		{input: `\x4`, output: "\x04", consumed: 3},
+		{input: `\x414`, output: "A", consumed: 4},
+		{input: `\xz`, output: "x", consumed: 2},
+
+		// unicode
+		{input: `\u00e9`, output: "é", consumed: 6},
+		{input: `\u263a!`, output: "☺", consumed: 6},
+		{input: `\u41`, output: "A", consumed: 4},
+		{input: `\uxyz`, output: "u", consumed: 2},
+		{input: `\U0001F600`, output: "😀", consumed: 10},
+		{input: `\U41`, output: "A", consumed: 4},
+		{input: `\U`, output: "U", consumed: 2},
+	}
+
+	for _, tst := range tests {
+
+		out, n := Backslash([]rune(tst.input))
+
+		if out != tst.output {
+			t.Fatalf("error decoding %q - expected:%q got:%q", tst.input, tst.output, out)
+		}
+		if n != tst.consumed {
+			t.Fatalf("error decoding %q - expected to consume %d, consumed %d", tst.input, tst.consumed, n)
+		}
+	}
+}
+
+// TestStringEscapeComplete ensures the complete set of escapes work
+// within strings.
+func TestStringEscapeComplete(t *testing.T) {
+	input := `"\x41\u00e9\101 \a\b\f\v" "\$name \[x\]" "tab\
+	bed"`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.STRING, "AéA \a\b\f\v"},
+		{token.STRING, "$name [x]"},
+		{token.STRING, "tab bed"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong, expected=%q, got=%q: %v", i, tt.expectedType, tok.Type, tok)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - Literal wrong, expected=%q, got=%q: %v", i, tt.expectedLiteral, tok.Literal, tok)
+		}
+	}
+}
+
+// TestIdentifierEscape ensures that bare-words have escapes processed.
+func TestIdentifierEscape(t *testing.T) {
+	input := `puts Hello\ World \x41\u00e9 \$notvar a\
+b \`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.IDENT, "puts"},
+		{token.IDENT, "Hello World"},
+		{token.IDENT, "Aé"},
+		{token.IDENT, "$notvar"},
+		{token.IDENT, "a"},
+		{token.IDENT, "b"},
+		{token.IDENT, "\\"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong, expected=%q, got=%q: %v", i, tt.expectedType, tok.Type, tok)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - Literal wrong, expected=%q, got=%q: %v", i, tt.expectedLiteral, tok.Literal, tok)
+		}
+	}
+}
+
 // TestUnterminatedString ensures that an unclosed-string is an error
 func TestUnterminatedString(t *testing.T) {
 	input := `"Steve`
@@ -119,10 +242,10 @@ which continues" # With a comment at the end`
 		expectedType    token.Type
 		expectedLiteral string
 	}{
-		{token.STRING, "This is a test which continues"},
+		{token.STRING, "This is a test  which continues"},
 		{token.EOF, ""},
 	}
-	l := New(input)
+	l := New(input, WithComments(CComments))
 	for i, tt := range tests {
 		tok := l.NextToken()
 		if tok.Type != tt.expectedType {
@@ -321,6 +444,53 @@ if { $i < 10 } { puts "OK" } else { puts "FAILED"}
 		{token.NEWLINE, "\\n"},
 		{token.EOF, ""},
 	}
+	l := New(input, WithComments(CComments))
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong, expected=%q, got=%q: %v", i, tt.expectedType, tok.Type, tok)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - Literal wrong, expected=%q, got=%q: %v", i, tt.expectedLiteral, tok.Literal, tok)
+		}
+	}
+
+}
+
+// TestTCLComments ensures that "#" is only a comment at the start of a
+// command, by default.
+func TestTCLComments(t *testing.T) {
+
+	input := `# This is a comment
+puts #hashtag
+set a 1 ;# trailing comment
+puts //not-a-comment http://example.com
+  # indented comment \
+    which continues
+puts x#y`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.NEWLINE, "\\n"},
+		{token.IDENT, "puts"},
+		{token.IDENT, "#hashtag"},
+		{token.NEWLINE, "\\n"},
+		{token.IDENT, "set"},
+		{token.IDENT, "a"},
+		{token.NUMBER, "1"},
+		{token.SEMICOLON, ";"},
+		{token.NEWLINE, "\\n"},
+		{token.IDENT, "puts"},
+		{token.IDENT, "//not-a-comment"},
+		{token.IDENT, "http://example.com"},
+		{token.NEWLINE, "\\n"},
+		{token.NEWLINE, "\\n"},
+		{token.IDENT, "puts"},
+		{token.IDENT, "x#y"},
+		{token.EOF, ""},
+	}
 	l := New(input)
 	for i, tt := range tests {
 		tok := l.NextToken()
@@ -332,4 +502,39 @@ if { $i < 10 } { puts "OK" } else { puts "FAILED"}
 		}
 	}
 
+	// The same input with C-style comments is very different.
+	l = New(input, WithComments(CComments))
+	for _, expected := range []string{"\\n", "puts", "\\n", "set", "a", "1", ";", "\\n", "puts", "\\n", "\\n", "which", "continues", "\\n", "puts", "x#y"} {
+		tok := l.NextToken()
+		if tok.Literal != expected {
+			t.Fatalf("C-comments - Literal wrong, expected=%q, got=%q: %v", expected, tok.Literal, tok)
+		}
+	}
+}
+
+// TestRaw ensures that the source and position of tokens is recorded.
+func TestRaw(t *testing.T) {
+	input := `puts  "a\tb"$c {d} [e]`
+
+	tests := []struct {
+		expectedRaw      string
+		expectedPosition int
+	}{
+		{"puts", 0},
+		{`"a\tb"`, 6},
+		{"$c", 12},
+		{"{d}", 15},
+		{"[e]", 19},
+		{"", 22},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Raw != tt.expectedRaw {
+			t.Fatalf("tests[%d] - Raw wrong, expected=%q, got=%q: %v", i, tt.expectedRaw, tok.Raw, tok)
+		}
+		if tok.Position != tt.expectedPosition {
+			t.Fatalf("tests[%d] - Position wrong, expected=%d, got=%d: %v", i, tt.expectedPosition, tok.Position, tok)
+		}
+	}
 }
@@ -1,45 +1,110 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"github.com/skx/critical/interpreter"
+	"github.com/skx/critical/lexer"
+	"github.com/skx/critical/repl"
 	"github.com/skx/critical/stdlib"
 )
 
 var version = "unreleased"
 
 func main() {
+	os.Exit(run())
+}
+
+// run executes the program the user specified, and returns the code
+// the process should exit with.
+func run() int {
 
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: critical [flags] [file.tcl | -] [args ...]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "       critical [flags] serve [-listen address] file.tcl\n\n")
+		flag.PrintDefaults()
+	}
+
+	autoPath := flag.String("auto-path", "", "Directories to search for packages, separated by '"+string(os.PathListSeparator)+"', in addition to those in $CRITICAL_PATH.")
+	comments := flag.String("comments", "tcl", "The style of comments to recognize, either 'tcl' or 'c'.")
+	execute := flag.String("e", "", "Execute the given script, rather than reading one from a file.")
 	noStdlib := flag.Bool("no-stdlib", false, "Disable the (embedded) standard library.")
+	printResult := flag.Bool("print-result", false, "Show the result of the program, once it has finished.")
+	timeout := flag.Duration("timeout", 0, "Abort execution if the program runs for longer than this, for example '10s'.")
 	versionFlag := flag.Bool("version", false, "Show our version, and exit.")
 	flag.Parse()
 
 	if *versionFlag {
 		fmt.Printf("critical %s\n", version)
-		return
+		return 0
 	}
 
-	// Ensure we have a file to execute.
-	if len(flag.Args()) < 1 {
-		fmt.Printf("Usage: critical file.tcl\n")
-		return
+	// Which comments do we recognize?
+	var style lexer.CommentStyle
+	switch *comments {
+	case "tcl":
+		style = lexer.TCLComments
+	case "c":
+		style = lexer.CComments
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown comment-style '%s', valid choices are 'tcl' and 'c'\n", *comments)
+		return 1
 	}
 
-	// Read our standard library
-	stdlib := stdlib.Contents()
+	// Serving HTTP requests is a sub-command, so a script which has
+	// that name must be given as "./serve".
+	if *execute == "" && flag.Arg(0) == "serve" {
+		return serve(flag.Args()[1:], style, *noStdlib, packagePath(*autoPath))
+	}
+
+	// The script to execute, the name it is known by, and any
+	// arguments it should receive.
+	var data []byte
+	var argv0 string
+	var args []string
+	var err error
+
+	switch {
+	case *execute != "":
+		data = []byte(*execute)
+		argv0 = os.Args[0]
+		args = flag.Args()
+
+	case len(flag.Args()) < 1:
+		// Without a file to execute we run interactively.
+		return interactive(style, *noStdlib, packagePath(*autoPath))
+
+	case flag.Arg(0) == "-":
+		data, err = io.ReadAll(os.Stdin)
+		argv0 = "-"
+		args = flag.Args()[1:]
+
+	default:
+		argv0 = flag.Arg(0)
+		args = flag.Args()[1:]
+		data, err = os.ReadFile(argv0)
+	}
 
-	// Read the file the user wanted
-	data, err := os.ReadFile(flag.Args()[0])
 	if err != nil {
-		fmt.Printf("error reading file %s:%s\n", os.Args[0], err)
-		return
+		fmt.Fprintf(os.Stderr, "error reading file %s:%s\n", argv0, err)
+		return 1
 	}
 
+	// Read our standard library
+	stdlib := stdlib.Contents()
+
 	// Join the two inputs, unless we shouldn't.
-	input := string(data)
+	input := stripShebang(string(data))
 	if !*noStdlib {
 		input = string(stdlib) + "\n" + input
 	}
@@ -48,20 +113,216 @@ func main() {
 	var out string
 	var i *interpreter.Interpreter
 
-	i, err = interpreter.New(input)
+	opts := []interpreter.Option{interpreter.WithComments(style)}
+	if *execute == "" && argv0 != "-" {
+		opts = append(opts, interpreter.WithScriptPath(argv0))
+	}
+
+	i, err = interpreter.New(input, opts...)
 	if err != nil {
-		fmt.Printf("Error creating interpreter %s\n", err)
-		return
+		fmt.Fprintf(os.Stderr, "Error creating interpreter %s\n", err)
+		return 1
+	}
+	defer i.Close()
+	setArguments(i, argv0, args)
+	i.SetListVariable("auto_path", packagePath(*autoPath))
+
+	// Setup a timeout, if we should.
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
 	}
 
 	// Evaluate the input
-	out, err = i.Evaluate()
+	out, err = i.EvaluateContext(ctx)
+
+	// The script called `exit`, with the code to exit with.
+	if err == interpreter.ErrExit {
+		return exitCode(out)
+	}
 
 	if err != nil && err != interpreter.ErrReturn {
-		fmt.Printf("Error running program:%s\n", err)
-		return
+		fmt.Fprintf(os.Stderr, "Error running program: %s\n", err)
+		return 1
+	}
+
+	// Show the result, if we should.
+	if *printResult {
+		fmt.Printf("\nResult:%s\n", out)
+	}
+	return 0
+}
+
+// serve runs a script which registers procedures to handle HTTP requests,
+// via `http::route`, and then serves requests until we're interrupted.
+//
+// The global flags have already been parsed, and the comment-style,
+// standard library, and package search path they selected are used.
+func serve(args []string, style lexer.CommentStyle, noStdlib bool, autoPath []string) int {
+
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: critical [flags] serve [flags] file.tcl [flags]\n\n")
+		flags.PrintDefaults()
+	}
+	listen := flags.String("listen", interpreter.DefaultListenAddress, "The address to listen upon, for example ':8080' or 'localhost:9000'.")
+
+	// Flags may be given before, or after, the script.
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return 2
+	}
+	path := flags.Arg(0)
+	if err := flags.Parse(flags.Args()[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Unexpected arguments: %s\n", strings.Join(flags.Args(), " "))
+		return 2
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading file %s:%s\n", path, err)
+		return 1
+	}
+
+	input := stripShebang(string(data))
+	if !noStdlib {
+		input = string(stdlib.Contents()) + "\n" + input
+	}
+
+	i, err := interpreter.New(input,
+		interpreter.WithComments(style),
+		interpreter.WithScriptPath(path),
+		interpreter.WithListenAddress(*listen),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating interpreter %s\n", err)
+		return 1
+	}
+	defer i.Close()
+	setArguments(i, path, nil)
+	i.SetListVariable("auto_path", autoPath)
+
+	// Serve until we're interrupted.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Evaluate the script, which registers the routes, and then serve
+	// them, unless the script already did so.
+	out, err := i.EvaluateContext(ctx)
+	if err == nil || err == interpreter.ErrReturn {
+		fmt.Fprintf(os.Stderr, "Listening on %s\n", *listen)
+		out, err = i.EvalContext(ctx, "http::serve")
+	}
+
+	switch {
+	case err == interpreter.ErrExit:
+		return exitCode(out)
+	case errors.Is(err, interpreter.ErrCancelled) && ctx.Err() != nil:
+		return 0
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Error running program: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// exitCode converts the code a script gave to `exit` into the code the
+// process should exit with.
+//
+// The code is checked, even though `exit` checks it too, since codes
+// outside the range 0-255 would be truncated, and might become zero.
+func exitCode(out string) int {
+	code, err := strconv.Atoi(out)
+	if err != nil || code < 0 || code > 255 {
+		fmt.Fprintf(os.Stderr, "Error running program: invalid exit code \"%s\"\n", out)
+		return 1
+	}
+	return code
+}
+
+// interactive runs a REPL, and returns the code the process should exit
+// with.
+func interactive(style lexer.CommentStyle, noStdlib bool, autoPath []string) int {
+
+	// Load the standard library, unless we shouldn't.
+	input := ""
+	if !noStdlib {
+		input = string(stdlib.Contents())
+	}
+
+	i, err := interpreter.New(input, interpreter.WithComments(style))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating interpreter %s\n", err)
+		return 1
 	}
+	defer i.Close()
+	setArguments(i, os.Args[0], nil)
+	i.SetListVariable("auto_path", autoPath)
 
-	// Show the result
-	fmt.Printf("\nResult:%s\n", out)
+	_, err = i.Evaluate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading standard library:%s\n", err)
+		return 1
+	}
+
+	opts := []repl.Option{repl.WithComments(style)}
+	if home, err := os.UserHomeDir(); err == nil {
+		opts = append(opts, repl.WithHistoryFile(filepath.Join(home, ".critical_history")))
+	}
+
+	err = repl.New(i, opts...).Run(os.Stdin, os.Stdout)
+
+	var exit *repl.ExitError
+	if errors.As(err, &exit) {
+		return exit.Code
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error:%s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// stripShebang removes any "#!" line from the start of the script, so
+// that scripts may be executable.  The newline is retained, so that the
+// lines of the script keep their numbers.
+func stripShebang(src string) string {
+	if !strings.HasPrefix(src, "#!") {
+		return src
+	}
+	if idx := strings.Index(src, "\n"); idx >= 0 {
+		return src[idx:]
+	}
+	return ""
+}
+
+// setArguments sets the global variables which describe the arguments
+// the script was invoked with.
+func setArguments(i *interpreter.Interpreter, argv0 string, args []string) {
+	i.SetVariable("argv0", argv0)
+	i.SetListVariable("argv", args)
+	i.SetVariable("argc", strconv.Itoa(len(args)))
+}
+
+// packagePath returns the directories which should be searched for
+// packages, which are those given upon the command-line followed by
+// those in $CRITICAL_PATH.
+func packagePath(flagValue string) []string {
+	var dirs []string
+	for _, val := range []string{flagValue, os.Getenv("CRITICAL_PATH")} {
+		for _, dir := range filepath.SplitList(val) {
+			if dir != "" {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
 }
@@ -0,0 +1,181 @@
+package parser
+
+import "strings"
+
+// Script is a parsed program, which consists of a series of commands.
+type Script struct {
+
+	// Commands contains the commands in the order they appeared.
+	Commands []*Command
+}
+
+// String returns the source of the script, with each command upon
+// a line of its own.
+func (s *Script) String() string {
+	out := make([]string, len(s.Commands))
+	for i, c := range s.Commands {
+		out[i] = c.String()
+	}
+	return strings.Join(out, "\n")
+}
+
+// Command is a single command, or statement, which should be executed.
+//
+// The first word is the name of the command, and any further words are
+// the arguments it will be given.
+type Command struct {
+
+	// Words contains the words which make up the command.
+	Words []*Word
+}
+
+// Name returns the word which names the command to be executed.
+func (c *Command) Name() *Word {
+	return c.Words[0]
+}
+
+// Args returns the words which are arguments to the command.
+func (c *Command) Args() []*Word {
+	return c.Words[1:]
+}
+
+// String returns the source of the command.
+func (c *Command) String() string {
+	out := make([]string, len(c.Words))
+	for i, w := range c.Words {
+		out[i] = w.String()
+	}
+	return strings.Join(out, " ")
+}
+
+// WordKind describes how a word was written.
+type WordKind int
+
+const (
+	// BareWord is a word which had no surrounding quotes or braces.
+	BareWord WordKind = iota
+
+	// QuotedWord is a word surrounded by double-quotes.
+	QuotedWord
+
+	// BracedWord is a word surrounded by braces, which receives
+	// no substitutions.
+	BracedWord
+)
+
+// Word is a single word of a command.
+//
+// The value of a word is formed by joining the values of each of its parts,
+// so the word `"Hello $name"` consists of the text "Hello " followed by a
+// reference to the variable "name".
+type Word struct {
+
+	// Kind records how the word was written.
+	Kind WordKind
+
+	// Parts contains the pieces the word is built from.
+	Parts []Part
+}
+
+// Literal returns the value of the word, if it requires no substitutions
+// to be performed.
+func (w *Word) Literal() (string, bool) {
+	out := ""
+	for _, p := range w.Parts {
+		switch p := p.(type) {
+		case *TextPart:
+			out += p.Text
+		case *BackslashPart:
+			out += p.Value
+		default:
+			return "", false
+		}
+	}
+	return out, true
+}
+
+// String returns the source of the word.
+func (w *Word) String() string {
+	out := ""
+	for _, p := range w.Parts {
+		out += p.String()
+	}
+
+	switch w.Kind {
+	case QuotedWord:
+		return "\"" + out + "\""
+	case BracedWord:
+		return "{" + out + "}"
+	}
+	return out
+}
+
+// Part is a single piece of a word.
+type Part interface {
+
+	// String returns the source of the part.
+	String() string
+}
+
+// TextPart is literal text.
+type TextPart struct {
+
+	// Text holds the text.
+	Text string
+}
+
+// String returns the source of the part.
+func (t *TextPart) String() string {
+	return t.Text
+}
+
+// BackslashPart is a backslash-sequence, such as "\n".
+//
+// The escape "$$", which is used to insert a literal dollar-sign, is also
+// represented as a backslash-part.
+type BackslashPart struct {
+
+	// Source holds the sequence, as it was written.
+	Source string
+
+	// Value holds the value the sequence represents.
+	Value string
+}
+
+// String returns the source of the part.
+func (b *BackslashPart) String() string {
+	return b.Source
+}
+
+// VariablePart is a reference to a variable, for example "$name".
+type VariablePart struct {
+
+	// Name is the name of the variable.
+	Name string
+
+	// Braced is true if the name was written as "${name}".
+	Braced bool
+}
+
+// String returns the source of the part.
+func (v *VariablePart) String() string {
+	if v.Braced {
+		return "${" + v.Name + "}"
+	}
+	return "$" + v.Name
+}
+
+// CommandPart is a command-substitution, for example "[expr 1 + 2]".
+type CommandPart struct {
+
+	// Script is the parsed script which should be evaluated.
+	Script *Script
+
+	// Source holds the text between the brackets.
+	Source string
+}
+
+// String returns the source of the part.
+func (c *CommandPart) String() string {
+	return "[" + c.Source + "]"
+}
@@ -31,6 +31,11 @@ $a$b "Hello"`))
 	// Some comments
 	f.Add([]byte(`set a pu // comment`))
 	f.Add([]byte(`set a pu// comment`))
+	f.Add([]byte(`set a 3 ;# comment`))
+
+	// Substitutions
+	f.Add([]byte(`puts "${name} is [expr 1 + [expr 2 + 3]]\t\x41"`))
+	f.Add([]byte(`puts a\ b$c[d]{e}`))
 
 	// Known errors are listed here.
 	//
@@ -45,12 +50,24 @@ $a$b "Hello"`))
 		"unterminated pair",
 		"unterminated string",
 		"'-' may only occur at the start of the number",
+		"extra characters after close-brace",
+		"extra characters after close-quote",
+		"missing close-brace",
+		"missing close-bracket",
 	}
 
 	f.Fuzz(func(t *testing.T, input []byte) {
 
 		p := New(string(input))
-		_, err := p.Parse()
+		script, err := p.Parse()
+		if err == nil {
+
+			// The source we generate should be parseable too.
+			_, err = New(script.String()).Parse()
+			if err != nil {
+				t.Fatalf("error parsing generated source %s:%s", script, err)
+			}
+		}
 		if err != nil {
 
 			// not found this as a false-positive
@@ -6,27 +6,28 @@
 //
 // Commands are separated from each other by either ";" or "newlines".
 //
-// Arguments consist of strings, numbers, evaluated-objects or blocks.
+// Each command is made up of words, and each word is built from a number
+// of parts:
 //
-// We consider anything surrounded by [ & ] to be an evaluated block, and
-// anything surrounded by { & } to be a block.  The latter are returned
-// as-is, without any interpolation.
+//   - Literal text.
+//   - Backslash-sequences, such as "\n".
+//   - References to variables, such as "$name" or "${name}".
+//   - Command-substitutions, such as "[expr 1 + 2]", which are parsed
+//     into scripts of their own.
 //
-// This is a little naive, and probably doesn't handle the full scope of
-// input, but it should be reasonable regardless.
+// Words surrounded by { & } are returned as-is, without any substitutions
+// taking place, but words surrounded by double-quotes, or bare-words, may
+// contain all of the parts above.  This means that the following:
 //
-// FIXME:
+//	set a pu
+//	set b ts
+//	$a$b "Hello, world"
 //
-// -  We probably want a "variable" type.
-// -  We probably parse "$foo" as a variable, and also "$foo$bar".
-//    If we handle the latter as two variables we'll have problems with:
-//
-//       set a pu
-//       set b ts
-//       $a$b "Hello, world"
-//
-//    In practice that's probably OK, but ..
+// Is a command whose name is a single word containing two variable
+// references.
 //
+// The result of parsing is an abstract syntax tree, which may be converted
+// back into source via the String methods of the nodes it contains.
 package parser
 
 import (
@@ -36,39 +37,34 @@ import (
 	"github.com/skx/critical/token"
 )
 
-// Command is a single command, or statement, which should be executed.
-type Command struct {
-	// Command is the command to be executed.
-	Command token.Token
-
-	// Arguments contains the arguments to be used for the command.
-	Arguments []token.Token
-}
-
 // Parser holds the objects' state
 type Parser struct {
 
 	// The lexer we work with for parsing the program.
 	lexer *lexer.Lexer
+
+	// The options given to our lexer, which we'll reuse when parsing
+	// any command-substitutions.
+	options []lexer.Option
 }
 
 // New creates a new parser.
-func New(input string) *Parser {
-	return &Parser{lexer: lexer.New(input)}
+//
+// Any options supplied are passed to the lexer, for example to control
+// the style of comments which are recognized.
+func New(input string, opts ...lexer.Option) *Parser {
+	return &Parser{lexer: lexer.New(input, opts...), options: opts}
 }
 
 // Parse parses the input into a series of commands.
-func (p *Parser) Parse() ([]Command, error) {
+func (p *Parser) Parse() (*Script, error) {
 
 	// Return value, the parsed set of commands
-	ret := []Command{}
+	ret := &Script{}
 
 	// Forever
 	for {
 
-		// The command we're processing at the moment.
-		var c Command
-
 		// Read a token
 		tok := p.lexer.NextToken()
 
@@ -77,20 +73,12 @@ func (p *Parser) Parse() ([]Command, error) {
 			break
 		}
 
-		if tok.Type == token.NEWLINE {
+		if tok.Type == token.NEWLINE || tok.Type == token.SEMICOLON {
 			continue
 		}
 
-		// Some kind of error?
-		if tok.Type == token.ILLEGAL {
-			return ret, fmt.Errorf("illegal token:%s", tok)
-		}
-
-		// Save the token as the command to be executed.
-		c.Command = tok
-
-		// Now look for arguments to the command
-		tok = p.lexer.NextToken()
+		// The tokens which make up each word of the command.
+		var words [][]token.Token
 
 		// Commands are terminated by either:
 		//
@@ -101,20 +89,78 @@ func (p *Parser) Parse() ([]Command, error) {
 			tok.Type != token.NEWLINE &&
 			tok.Type != token.EOF {
 
-			// Add the token as an argument
-			c.Arguments = append(c.Arguments, tok)
+			// Some kind of error?
+			if tok.Type == token.ILLEGAL {
+				return ret, fmt.Errorf("illegal token:%s", tok)
+			}
+
+			// Tokens which are adjacent, without any whitespace
+			// between them, are part of the same word.
+			last := len(words) - 1
+			if last >= 0 && words[last][len(words[last])-1].End() == tok.Position {
+				words[last] = append(words[last], tok)
+			} else {
+				words = append(words, []token.Token{tok})
+			}
 
 			// Read the next token
 			tok = p.lexer.NextToken()
 		}
 
+		// Now build up the command from the words
+		c := &Command{}
+		for _, toks := range words {
+			w, err := p.word(toks)
+			if err != nil {
+				return ret, err
+			}
+			c.Words = append(c.Words, w)
+		}
+
 		// Append the parsed command to our list,
 		// and start again processing the next command.
-		//
-		// When we hit EOF/error we'll break out of this loop
-		ret = append(ret, c)
+		ret.Commands = append(ret.Commands, c)
 	}
 
 	// We hit EOF, so return the list of processed commands.
 	return ret, nil
 }
+
+// word converts the tokens which make up a single word into a Word.
+func (p *Parser) word(toks []token.Token) (*Word, error) {
+
+	first := toks[0]
+
+	switch first.Type {
+	case token.BLOCK:
+		if len(toks) > 1 {
+			return nil, fmt.Errorf("extra characters after close-brace: %s", toks[1].Raw)
+		}
+		return &Word{Kind: BracedWord, Parts: []Part{&TextPart{Text: first.Literal}}}, nil
+
+	case token.STRING:
+		if len(toks) > 1 {
+			return nil, fmt.Errorf("extra characters after close-quote: %s", toks[1].Raw)
+		}
+
+		// Remove the quotes, and parse the contents.
+		raw := []rune(first.Raw)
+		parts, err := p.parts(raw[1 : len(raw)-1])
+		if err != nil {
+			return nil, err
+		}
+		return &Word{Kind: QuotedWord, Parts: parts}, nil
+	}
+
+	// A bare-word is built from the source of all the tokens.
+	raw := ""
+	for _, tok := range toks {
+		raw += tok.Raw
+	}
+
+	parts, err := p.parts([]rune(raw))
+	if err != nil {
+		return nil, err
+	}
+	return &Word{Kind: BareWord, Parts: parts}, nil
+}
@@ -1,6 +1,9 @@
 package parser
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestPuts(t *testing.T) {
 	input := `puts "OK"`
@@ -11,7 +14,7 @@ func TestPuts(t *testing.T) {
 		t.Fatalf("error parsing %s:%s", input, err)
 	}
 
-	if len(out) != 1 {
+	if len(out.Commands) != 1 {
 		t.Fatalf("wrong number of statements")
 	}
 }
@@ -27,7 +30,181 @@ puts "World"`
 		t.Fatalf("error parsing %s:%s", input, err)
 	}
 
-	if len(out) != 2 {
+	if len(out.Commands) != 2 {
 		t.Fatalf("wrong number of statements")
 	}
 }
+
+// TestWords ensures that words are split into the expected parts.
+func TestWords(t *testing.T) {
+	input := `$a$b "Hello, ${name}!\n" {$literal [x]} pre[expr 1 + 2]post $$5 $ ok`
+
+	p := New(input)
+	out, err := p.Parse()
+	if err != nil {
+		t.Fatalf("error parsing %s:%s", input, err)
+	}
+	if len(out.Commands) != 1 {
+		t.Fatalf("wrong number of statements")
+	}
+
+	words := out.Commands[0].Words
+	if len(words) != 7 {
+		t.Fatalf("wrong number of words, got %d", len(words))
+	}
+
+	// $a$b
+	if words[0].Kind != BareWord || len(words[0].Parts) != 2 {
+		t.Fatalf("first word is wrong: %#v", words[0])
+	}
+	v, ok := words[0].Parts[1].(*VariablePart)
+	if !ok || v.Name != "b" {
+		t.Fatalf("expected variable b, got %#v", words[0].Parts[1])
+	}
+
+	// "Hello, ${name}!\n"
+	if words[1].Kind != QuotedWord || len(words[1].Parts) != 4 {
+		t.Fatalf("second word is wrong: %#v", words[1])
+	}
+	v, ok = words[1].Parts[1].(*VariablePart)
+	if !ok || v.Name != "name" || !v.Braced {
+		t.Fatalf("expected variable name, got %#v", words[1].Parts[1])
+	}
+	b, ok := words[1].Parts[3].(*BackslashPart)
+	if !ok || b.Value != "\n" {
+		t.Fatalf("expected newline, got %#v", words[1].Parts[3])
+	}
+
+	// {$literal [x]}
+	lit, ok := words[2].Literal()
+	if words[2].Kind != BracedWord || !ok || lit != "$literal [x]" {
+		t.Fatalf("third word is wrong: %#v", words[2])
+	}
+
+	// pre[expr 1 + 2]post
+	if len(words[3].Parts) != 3 {
+		t.Fatalf("fourth word is wrong: %#v", words[3])
+	}
+	c, ok := words[3].Parts[1].(*CommandPart)
+	if !ok || len(c.Script.Commands) != 1 || len(c.Script.Commands[0].Args()) != 3 {
+		t.Fatalf("expected a command-substitution, got %#v", words[3].Parts[1])
+	}
+
+	// $$5 is an escaped dollar, followed by text
+	lit, ok = words[4].Literal()
+	if !ok || lit != "$5" {
+		t.Fatalf("fifth word is wrong: %#v", words[4])
+	}
+
+	// A lone dollar is literal
+	lit, ok = words[5].Literal()
+	if !ok || lit != "$" {
+		t.Fatalf("sixth word is wrong: %#v", words[5])
+	}
+
+	// Finally a simple word
+	lit, ok = words[6].Literal()
+	if !ok || lit != "ok" {
+		t.Fatalf("seventh word is wrong: %#v", words[6])
+	}
+}
+
+// TestBracketsInSubstitution ensures that brackets within braces, or
+// quotes, don't end a command-substitution.
+func TestBracketsInSubstitution(t *testing.T) {
+
+	tests := []string{
+		`puts [puts {]}]`,
+		`puts [puts "]"]`,
+		`puts "[puts "]"]"`,
+		`puts [puts \]]`,
+	}
+
+	for _, input := range tests {
+
+		out, err := New(input).Parse()
+		if err != nil {
+			t.Fatalf("error parsing %s:%s", input, err)
+		}
+		if len(out.Commands) != 1 || len(out.Commands[0].Words) != 2 {
+			t.Fatalf("wrong number of words for %s", input)
+		}
+
+		word := out.Commands[0].Words[1]
+		if len(word.Parts) != 1 {
+			t.Fatalf("wrong number of parts for %s: %#v", input, word.Parts)
+		}
+		c, ok := word.Parts[0].(*CommandPart)
+		if !ok || len(c.Script.Commands) != 1 {
+			t.Fatalf("expected a command-substitution for %s, got %#v", input, word.Parts[0])
+		}
+		words := c.Script.Commands[0].Words
+		if len(words) != 2 {
+			t.Fatalf("wrong number of words in the substitution for %s", input)
+		}
+		lit, ok := words[1].Literal()
+		if !ok || lit != "]" {
+			t.Fatalf("unexpected argument for %s: %q", input, lit)
+		}
+	}
+}
+
+// TestString ensures that we can reproduce the source of a script.
+func TestString(t *testing.T) {
+
+	tests := []string{
+		`puts "Hello, world"`,
+		`set a pu` + "\n" + `set b ts` + "\n" + `$a$b "Hello"`,
+		`puts "$$name is ${name}\t[expr 1 + [expr 2 + 3]]"`,
+		`proc square {x} { expr $x * $x }`,
+		`puts a\ b\x41 c[set d]e`,
+		`puts "nested [puts "quotes"] work"`,
+		`puts #hashtag`,
+	}
+
+	for _, test := range tests {
+		p := New(test)
+		out, err := p.Parse()
+		if err != nil {
+			t.Fatalf("error parsing %s:%s", test, err)
+		}
+		if out.String() != test {
+			t.Fatalf("source was not reproduced, expected:%s got:%s", test, out.String())
+		}
+	}
+
+	// Separators and comments are normalized.
+	p := New("# comment\nputs a ;  puts   b;\n\n")
+	out, err := p.Parse()
+	if err != nil {
+		t.Fatalf("error parsing:%s", err)
+	}
+	if out.String() != "puts a\nputs b" {
+		t.Fatalf("unexpected source: %s", out.String())
+	}
+}
+
+// TestErrors tests some malformed input.
+func TestErrors(t *testing.T) {
+
+	tests := map[string]string{
+		`puts "foo"bar`:       "extra characters after close-quote",
+		`puts {foo}bar`:       "extra characters after close-brace",
+		`puts ${name`:         "unterminated pair",
+		`puts "[expr 1 + 2"`:  "unterminated pair",
+		`puts [expr 1 + 2`:    "unterminated pair",
+		`puts "steve`:         "unterminated string",
+		`puts "[puts {"]`:     "unterminated",
+		`puts "x ${unclosed"`: "missing close-brace",
+	}
+
+	for input, expected := range tests {
+		_, err := New(input).Parse()
+		if err == nil {
+			t.Fatalf("expected error parsing %s, got none", input)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("got error parsing %s, but the wrong one: %s", input, err)
+		}
+	}
+}
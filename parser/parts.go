@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/skx/critical/lexer"
+)
+
+// parts splits the source of a bare, or quoted, word into the parts
+// which it is made from.
+func (p *Parser) parts(src []rune) ([]Part, error) {
+
+	var parts []Part
+
+	// Literal text we've accumulated.
+	text := ""
+
+	// Add a part, after flushing any pending text.
+	add := func(part Part) {
+		if text != "" {
+			parts = append(parts, &TextPart{Text: text})
+			text = ""
+		}
+		parts = append(parts, part)
+	}
+
+	i := 0
+	for i < len(src) {
+
+		switch src[i] {
+
+		case '\\':
+			val, n := lexer.Backslash(src[i:])
+			add(&BackslashPart{Source: string(src[i : i+n]), Value: val})
+			i += n
+
+		case '$':
+			part, n, err := variable(src[i:])
+			if err != nil {
+				return nil, err
+			}
+			if part == nil {
+				text += "$"
+			} else {
+				add(part)
+			}
+			i += n
+
+		case '[':
+			end, err := closingBracket(src, i)
+			if err != nil {
+				return nil, err
+			}
+
+			source := string(src[i+1 : end])
+			script, err := New(source, p.options...).Parse()
+			if err != nil {
+				return nil, err
+			}
+			add(&CommandPart{Script: script, Source: source})
+			i = end + 1
+
+		default:
+			text += string(src[i])
+			i++
+		}
+	}
+
+	if text != "" || len(parts) == 0 {
+		parts = append(parts, &TextPart{Text: text})
+	}
+	return parts, nil
+}
+
+// variable parses the variable-reference at the start of the given input,
+// which must begin with "$".
+//
+// It returns the part, and the number of characters consumed.  If the
+// dollar is not followed by a variable name then the returned part is nil,
+// and the dollar should be treated as literal text.
+func variable(src []rune) (Part, int, error) {
+
+	if len(src) < 2 {
+		return nil, 1, nil
+	}
+
+	// "$$" is an escaped dollar.
+	if src[1] == '$' {
+		return &BackslashPart{Source: "$$", Value: "$"}, 2, nil
+	}
+
+	// "${name}"
+	if src[1] == '{' {
+		for n := 2; n < len(src); n++ {
+			if src[n] == '}' {
+				return &VariablePart{Name: string(src[2:n]), Braced: true}, n + 1, nil
+			}
+		}
+		return nil, 0, fmt.Errorf("missing close-brace for variable name")
+	}
+
+	// "$name", which may contain "::" for namespaces.
+	n := 1
+	for n < len(src) {
+		if isVariable(src[n]) {
+			n++
+			continue
+		}
+		if src[n] == ':' && n+1 < len(src) && src[n+1] == ':' {
+			n += 2
+			continue
+		}
+		break
+	}
+
+	if n == 1 {
+		return nil, 1, nil
+	}
+	return &VariablePart{Name: string(src[1:n])}, n, nil
+}
+
+// closingBracket returns the offset of the "]" which matches the "[" found
+// at the given offset.
+func closingBracket(src []rune, start int) (int, error) {
+	end := lexer.MatchingBracket(src, start)
+	if end < 0 {
+		return 0, fmt.Errorf("missing close-bracket")
+	}
+	return end, nil
+}
+
+// isVariable returns true if the character is valid within a variable name.
+func isVariable(ch rune) bool {
+	return ch == '_' ||
+		('a' <= ch && ch <= 'z') ||
+		('A' <= ch && ch <= 'Z') ||
+		('0' <= ch && ch <= '9')
+}
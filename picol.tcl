@@ -1,4 +1,4 @@
-// Procedure (i.e. function) which squares the given number.
+# Procedure (i.e. function) which squares the given number.
 proc square {x} {
     expr $x * $x
 }
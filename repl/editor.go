@@ -0,0 +1,180 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// errInterrupted is returned by the editor if the user presses Ctrl-C.
+var errInterrupted = errors.New("interrupted")
+
+// editor is a minimal line-editor, for use with a terminal in raw mode.
+//
+// It supports backspace, history navigation via the up and down cursor
+// keys, and completion via TAB.  Ctrl-C abandons the current line, Ctrl-U
+// erases it, and Ctrl-D on an empty line signals the end of input.
+type editor struct {
+
+	// in is the input we read keys from.
+	in *bufio.Reader
+
+	// out is the output we echo to.
+	out io.Writer
+
+	// history contains the previous lines which may be recalled.
+	history []string
+
+	// complete returns the possible completions of a word.
+	complete func(word string) []string
+}
+
+// readLine reads a single line of input, after showing the prompt.
+func (e *editor) readLine(prompt string) (string, error) {
+
+	buf := []rune{}
+
+	// Position within the history, and the line which was being
+	// edited before the history was navigated.
+	pos := len(e.history)
+	current := ""
+
+	redraw := func() {
+		fmt.Fprintf(e.out, "\r\x1b[K%s%s", prompt, string(buf))
+	}
+	redraw()
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprintf(e.out, "\n")
+			return string(buf), nil
+
+		case 0x03: // Ctrl-C
+			fmt.Fprintf(e.out, "^C\n")
+			return "", errInterrupted
+
+		case 0x04: // Ctrl-D
+			if len(buf) == 0 {
+				fmt.Fprintf(e.out, "\n")
+				return "", io.EOF
+			}
+
+		case 0x15: // Ctrl-U
+			buf = buf[:0]
+			redraw()
+
+		case 0x7f, 0x08: // Backspace
+			if len(buf) > 0 {
+				buf = buf[:len(buf)-1]
+				redraw()
+			}
+
+		case '\t':
+			buf = e.completeLine(buf, prompt)
+			redraw()
+
+		case 0x1b: // Escape sequences
+			seq := e.readEscape()
+			switch seq {
+			case "[A": // Up
+				if pos > 0 {
+					if pos == len(e.history) {
+						current = string(buf)
+					}
+					pos--
+					buf = []rune(e.history[pos])
+					redraw()
+				}
+			case "[B": // Down
+				if pos < len(e.history) {
+					pos++
+					if pos == len(e.history) {
+						buf = []rune(current)
+					} else {
+						buf = []rune(e.history[pos])
+					}
+					redraw()
+				}
+			}
+
+		default:
+			if unicode.IsPrint(r) {
+				buf = append(buf, r)
+				fmt.Fprintf(e.out, "%c", r)
+			}
+		}
+	}
+}
+
+// readEscape reads the remainder of an escape-sequence, such as "[A",
+// which is sent when the user presses a cursor key.
+func (e *editor) readEscape() string {
+	seq := ""
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return seq
+		}
+		seq += string(r)
+
+		// Sequences end with a letter, or a tilde.
+		if len(seq) > 1 && (unicode.IsLetter(r) || r == '~') {
+			return seq
+		}
+		if len(seq) == 1 && r != '[' && r != 'O' {
+			return seq
+		}
+	}
+}
+
+// completeLine completes the word at the end of the buffer.
+//
+// If there is a single possible completion it is inserted, otherwise the
+// word is extended to the longest common prefix of the completions, and
+// if that doesn't extend it the choices are shown.
+func (e *editor) completeLine(buf []rune, prompt string) []rune {
+
+	if e.complete == nil {
+		return buf
+	}
+
+	line := string(buf)
+	start := strings.LastIndexAny(line, " \t[{;\"") + 1
+	word := line[start:]
+
+	choices := e.complete(word)
+	switch len(choices) {
+	case 0:
+		return buf
+	case 1:
+		return []rune(line[:start] + choices[0] + " ")
+	}
+
+	prefix := commonPrefix(choices)
+	if len(prefix) > len(word) {
+		return []rune(line[:start] + prefix)
+	}
+
+	fmt.Fprintf(e.out, "\n%s\n", strings.Join(choices, "  "))
+	return buf
+}
+
+// commonPrefix returns the longest prefix which all the strings share.
+func commonPrefix(strs []string) string {
+	prefix := []rune(strs[0])
+	for _, s := range strs[1:] {
+		for !strings.HasPrefix(s, string(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return string(prefix)
+}
@@ -0,0 +1,111 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// newEditor returns an editor which reads the given keys.
+func newEditor(keys string, history []string) (*editor, *bytes.Buffer) {
+	var out bytes.Buffer
+	e := &editor{
+		in:      bufio.NewReader(strings.NewReader(keys)),
+		out:     &out,
+		history: history,
+		complete: func(word string) []string {
+			var res []string
+			for _, c := range []string{"puts", "proc", "set"} {
+				if strings.HasPrefix(c, word) {
+					res = append(res, c)
+				}
+			}
+			return res
+		},
+	}
+	return e, &out
+}
+
+func TestEditor(t *testing.T) {
+
+	type TestCase struct {
+		Keys    string
+		History []string
+		Line    string
+	}
+
+	tests := []TestCase{
+		{Keys: "puts hi\r", Line: "puts hi"},
+		{Keys: "puts hi\n", Line: "puts hi"},
+		{Keys: "puts hix\x7f\r", Line: "puts hi"},
+		{Keys: "\x7f\x7fok\r", Line: "ok"},
+		{Keys: "junk\x15set a 1\r", Line: "set a 1"},
+		{Keys: "s\t\r", Line: "set "},
+		{Keys: "[s\t\r", Line: "[set "},
+		{Keys: "pu\thi\r", Line: "puts hi"},
+		{Keys: "p\t\r", Line: "p"},
+		{Keys: "x\t\r", Line: "x"},
+		{Keys: "\x1b[A\r", History: []string{"one", "two"}, Line: "two"},
+		{Keys: "\x1b[A\x1b[A\r", History: []string{"one", "two"}, Line: "one"},
+		{Keys: "\x1b[A\x1b[A\x1b[A\r", History: []string{"one", "two"}, Line: "one"},
+		{Keys: "abc\x1b[A\x1b[B\r", History: []string{"one", "two"}, Line: "abc"},
+		{Keys: "\x1b[A\x1b[A\x1b[B\r", History: []string{"one", "two"}, Line: "two"},
+		{Keys: "\x1b[B\r", History: []string{"one"}, Line: ""},
+		{Keys: "a\x1b[C\x1b[3~b\r", Line: "ab"},
+		{Keys: "a\x04b\r", Line: "ab"},
+		{Keys: "a\x01b\r", Line: "ab"},
+	}
+
+	for _, test := range tests {
+		e, _ := newEditor(test.Keys, test.History)
+
+		line, err := e.readLine("% ")
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %s", test.Keys, err)
+		}
+		if line != test.Line {
+			t.Fatalf("unexpected line for %q: got %q expected %q", test.Keys, line, test.Line)
+		}
+	}
+}
+
+func TestEditorKeys(t *testing.T) {
+
+	// Ctrl-C
+	e, _ := newEditor("abc\x03", nil)
+	_, err := e.readLine("% ")
+	if err != errInterrupted {
+		t.Fatalf("expected interruption, got %v", err)
+	}
+
+	// Ctrl-D
+	e, _ = newEditor("\x04", nil)
+	_, err = e.readLine("% ")
+	if err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+
+	// End of input
+	e, _ = newEditor("abc", nil)
+	_, err = e.readLine("% ")
+	if err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+
+	// Multiple completions are shown.
+	e, out := newEditor("p\t\r", nil)
+	_, err = e.readLine("% ")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(out.String(), "puts  proc") {
+		t.Fatalf("completions weren't shown: %q", out.String())
+	}
+
+	// The prompt is shown.
+	if !strings.HasPrefix(out.String(), "\r\x1b[K% ") {
+		t.Fatalf("prompt wasn't shown: %q", out.String())
+	}
+}
@@ -0,0 +1,301 @@
+// Package repl implements an interactive read-eval-print loop, which
+// executes TCL commands as they are entered.
+//
+// A single interpreter is used for the whole session, so variables and
+// procedures persist from one command to the next.  Commands which are
+// incomplete, because they contain an unterminated block, string, or
+// command-substitution, are continued over as many lines as necessary.
+//
+// When the input is a terminal the line may be edited, the previous
+// commands recalled via the cursor keys, and the names of commands and
+// variables completed via TAB.
+package repl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/skx/critical/interpreter"
+	"github.com/skx/critical/lexer"
+)
+
+// maxHistory is the number of entries of history which are retained.
+const maxHistory = 1000
+
+// ExitError is returned by Run if the user executes the `exit` command.
+type ExitError struct {
+
+	// Code is the exit-code the user supplied.
+	Code int
+}
+
+// Error returns the error message.
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit %d", e.Code)
+}
+
+// Option is used to configure the REPL, when it is created.
+type Option func(r *REPL)
+
+// WithHistoryFile sets the file which history is read from, and written
+// to.  By default history is not saved.
+func WithHistoryFile(path string) Option {
+	return func(r *REPL) {
+		r.historyFile = path
+	}
+}
+
+// WithComments sets the style of comments which are recognized, which
+// should match that of the interpreter.
+func WithComments(style lexer.CommentStyle) Option {
+	return func(r *REPL) {
+		r.comments = style
+	}
+}
+
+// REPL holds our state.
+type REPL struct {
+
+	// interp is the interpreter which executes the commands.
+	interp *interpreter.Interpreter
+
+	// historyFile is the path history is saved to, if any.
+	historyFile string
+
+	// comments is the style of comments which are recognized.
+	comments lexer.CommentStyle
+
+	// history contains the commands which have been entered.
+	history []string
+}
+
+// New creates a REPL which executes commands with the given interpreter.
+func New(i *interpreter.Interpreter, opts ...Option) *REPL {
+	r := &REPL{interp: i}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run reads commands from the input, executing each in turn and writing
+// the results, or errors, to the output.
+//
+// Run returns nil when the input is exhausted, or an ExitError if the
+// user executes the `exit` command.
+func (r *REPL) Run(in io.Reader, out io.Writer) error {
+
+	r.loadHistory()
+
+	read := r.lineReader(in, out)
+
+	// The input of the current command, which may span several lines.
+	input := ""
+
+	for {
+		prompt := "% "
+		if input != "" {
+			prompt = "> "
+		}
+
+		line, err := read(prompt)
+		if err == errInterrupted {
+			input = ""
+			continue
+		}
+		if err == io.EOF {
+			if strings.TrimSpace(input) != "" {
+				return r.eval(input, out)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		input += line + "\n"
+		if !lexer.IsComplete(input, lexer.WithComments(r.comments)) {
+			continue
+		}
+
+		src := strings.TrimSuffix(input, "\n")
+		input = ""
+		if strings.TrimSpace(src) == "" {
+			continue
+		}
+
+		r.addHistory(src)
+
+		err = r.eval(src, out)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// lineReader returns a function to read lines from the input.
+//
+// If the input is a terminal we use our line-editor, and show a prompt,
+// otherwise lines are read as-is.
+func (r *REPL) lineReader(in io.Reader, out io.Writer) func(prompt string) (string, error) {
+
+	reader := bufio.NewReader(in)
+
+	if f, ok := in.(*os.File); ok && isTerminal(int(f.Fd())) {
+		ed := &editor{in: reader, out: out, complete: r.complete}
+
+		return func(prompt string) (string, error) {
+			restore, err := makeRaw(int(f.Fd()))
+			if err != nil {
+				return "", err
+			}
+			defer restore()
+
+			ed.history = r.history
+			return ed.readLine(prompt)
+		}
+	}
+
+	return func(prompt string) (string, error) {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		line = strings.TrimSuffix(line, "\n")
+		line = strings.TrimSuffix(line, "\r")
+		return line, err
+	}
+}
+
+// eval executes the given command, and shows the result.
+//
+// Pressing Ctrl-C will interrupt the command, rather than terminating
+// the process.
+func (r *REPL) eval(src string, out io.Writer) error {
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	res, err := r.interp.EvalContext(ctx, src)
+
+	if err == interpreter.ErrExit {
+		code, err := strconv.Atoi(res)
+		if err != nil {
+			fmt.Fprintf(out, "error: expected integer exit code but got \"%s\"\n", res)
+			return nil
+		}
+		return &ExitError{Code: code}
+	}
+	if err != nil && !errors.Is(err, interpreter.ErrReturn) {
+		fmt.Fprintf(out, "error: %s\n", err)
+		return nil
+	}
+
+	if res != "" {
+		fmt.Fprintf(out, "%s\n", res)
+	}
+	return nil
+}
+
+// complete returns the possible completions for the given word, which are
+// the names of variables if it begins with "$", and the names of commands
+// otherwise.
+func (r *REPL) complete(word string) []string {
+
+	var out []string
+
+	if strings.HasPrefix(word, "$") {
+		for name := range r.interp.Variables() {
+			if strings.HasPrefix(name, word[1:]) {
+				out = append(out, "$"+name)
+			}
+		}
+	} else {
+		for _, name := range r.interp.Commands() {
+			if strings.HasPrefix(name, word) {
+				out = append(out, name)
+			}
+		}
+	}
+
+	sort.Strings(out)
+	return out
+}
+
+// loadHistory reads any previous history from our history file.
+func (r *REPL) loadHistory() {
+	if r.historyFile == "" {
+		return
+	}
+
+	// A missing history file is not an error.
+	data, err := os.ReadFile(r.historyFile)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			r.history = append(r.history, unescapeHistory(line))
+		}
+	}
+	if len(r.history) > maxHistory {
+		r.history = r.history[len(r.history)-maxHistory:]
+	}
+}
+
+// addHistory adds the command to our history, and saves it to our
+// history file.
+func (r *REPL) addHistory(src string) {
+
+	if len(r.history) > 0 && r.history[len(r.history)-1] == src {
+		return
+	}
+	r.history = append(r.history, src)
+	if len(r.history) > maxHistory {
+		r.history = r.history[1:]
+	}
+
+	if r.historyFile == "" {
+		return
+	}
+
+	// Failing to save history isn't fatal.
+	f, err := os.OpenFile(r.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "%s\n", escapeHistory(src))
+}
+
+// escapeHistory escapes a command, which might span several lines, such
+// that it may be stored upon a single line of our history file.
+func escapeHistory(src string) string {
+	src = strings.ReplaceAll(src, "\\", "\\\\")
+	return strings.ReplaceAll(src, "\n", "\\n")
+}
+
+// unescapeHistory reverses escapeHistory.
+func unescapeHistory(line string) string {
+	out := ""
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) {
+			i++
+			if line[i] == 'n' {
+				out += "\n"
+				continue
+			}
+		}
+		out += string(line[i])
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package repl
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/skx/critical/interpreter"
+	"github.com/skx/critical/lexer"
+)
+
+// run executes the given input in a new REPL, and returns the output.
+func run(t *testing.T, input string, opts ...Option) (string, error) {
+	var out bytes.Buffer
+
+	i, err := interpreter.New("", interpreter.WithStdout(&out))
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter: %s", err)
+	}
+
+	err = New(i, opts...).Run(strings.NewReader(input), &out)
+	return out.String(), err
+}
+
+func TestRun(t *testing.T) {
+
+	type TestCase struct {
+		Input  string
+		Output string
+	}
+
+	tests := []TestCase{
+		{Input: "set a 3\n", Output: "3\n"},
+		{Input: "set a 3", Output: "3\n"},
+		{Input: "set a 3\r\n", Output: "3\n"},
+		{Input: "set a 3\nincr a\n", Output: "3\n4\n"},
+		{Input: "\n\nset a 3\n\n", Output: "3\n"},
+		{Input: "proc inc {x} {\n  expr $x + 1\n}\ninc 4\n", Output: "5\n"},
+		{Input: "set a \"multi\nline\"\n", Output: "multi\nline\n"},
+		{Input: "set a {x\ny}\n", Output: "x\ny\n"},
+		{Input: "set a {\n{\n}\n}\n", Output: "\n{\n}\n\n"},
+		{Input: "set a 1 \\\n\n", Output: "1\n"},
+		{Input: "return 7\n", Output: "7\n"},
+		{Input: "unknown\nset a 2\n", Output: "error: unknown command 'unknown':unknown\n2\n"},
+		{Input: "proc foo {} {\n", Output: "error: illegal token:token{Type:ILLEGAL Literal:unterminated pair {-} depth:1 current:\n}\n"},
+	}
+
+	for _, test := range tests {
+		out, err := run(t, test.Input)
+		if err != nil {
+			t.Fatalf("unexpected error running %q: %s", test.Input, err)
+		}
+		if out != test.Output {
+			t.Fatalf("unexpected output for %q: got %q expected %q", test.Input, out, test.Output)
+		}
+	}
+
+	// Comments are recognized in the style the REPL is configured with.
+	var out bytes.Buffer
+	i, err := interpreter.New("", interpreter.WithComments(lexer.CComments))
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter: %s", err)
+	}
+	err = New(i, WithComments(lexer.CComments)).Run(strings.NewReader("set a 1 // {\n"), &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out.String() != "1\n" {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestExit(t *testing.T) {
+
+	out, err := run(t, "set a before\nexit 3\nset a after\n")
+
+	var exit *ExitError
+	if !errors.As(err, &exit) {
+		t.Fatalf("expected exit, got %v", err)
+	}
+	if exit.Code != 3 {
+		t.Fatalf("unexpected exit code %d", exit.Code)
+	}
+	if exit.Error() != "exit 3" {
+		t.Fatalf("unexpected error message %s", exit.Error())
+	}
+	if out != "before\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+
+	out, err = run(t, "exit foo\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(out, "expected integer") {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestHistory(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "history")
+
+	_, err := run(t, "set a 1\nset a 1\nproc p {} {\n  return \\\\\n}\n", WithHistoryFile(path))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read history: %s", err)
+	}
+	if string(data) != "set a 1\nproc p {} {\\n  return \\\\\\\\\\n}\n" {
+		t.Fatalf("unexpected history %q", string(data))
+	}
+
+	// Loading the history restores the entries.
+	i, err := interpreter.New("")
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter: %s", err)
+	}
+	r := New(i, WithHistoryFile(path))
+	r.loadHistory()
+	if len(r.history) != 2 {
+		t.Fatalf("unexpected history %v", r.history)
+	}
+	if r.history[1] != "proc p {} {\n  return \\\\\n}" {
+		t.Fatalf("unexpected history entry %q", r.history[1])
+	}
+
+	// A missing history file is fine.
+	r = New(i, WithHistoryFile(filepath.Join(t.TempDir(), "missing")))
+	r.loadHistory()
+	if len(r.history) != 0 {
+		t.Fatalf("unexpected history %v", r.history)
+	}
+}
+
+func TestComplete(t *testing.T) {
+
+	i, err := interpreter.New("")
+	if err != nil {
+		t.Fatalf("unexpected error creating interpreter: %s", err)
+	}
+	_, err = i.Eval(`set apple 1; set apricot 2; set banana 3; proc prime {} {}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	r := New(i)
+
+	type TestCase struct {
+		Word   string
+		Result string
+	}
+
+	tests := []TestCase{
+		{Word: "$ap", Result: "$apple $apricot"},
+		{Word: "$b", Result: "$banana"},
+		{Word: "$z", Result: ""},
+		{Word: "pr", Result: "prime proc"},
+		{Word: "put", Result: "puts"},
+		{Word: "zzz", Result: ""},
+	}
+
+	for _, test := range tests {
+		res := strings.Join(r.complete(test.Word), " ")
+		if res != test.Result {
+			t.Fatalf("unexpected completions for %s: got %q expected %q", test.Word, res, test.Result)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package repl
+
+import "errors"
+
+// isTerminal returns false, as line-editing is only supported upon Linux.
+func isTerminal(fd int) bool {
+	return false
+}
+
+// makeRaw is not supported upon this platform.
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("raw mode is not supported")
+}
@@ -1,22 +1,22 @@
-//
-// This is the "standard library".
-//
-// We define some functions here which are available to all users
-// of our application/scripting language.
-//
-
-
-//
-// Maths functions should be easier to use.
-//
-// So we can write:
-//
-//    while { <= $a 5 } { .. }
-//
-// instead of
-//
-//    while { expr $a <= 5 } { ... }
-//
+#
+# This is the "standard library".
+#
+# We define some functions here which are available to all users
+# of our application/scripting language.
+#
+
+
+#
+# Maths functions should be easier to use.
+#
+# So we can write:
+#
+#    while { <= $a 5 } { .. }
+#
+# instead of
+#
+#    while { expr $a <= 5 } { ... }
+#
 
 proc + {a b} {
     expr $a + $b
@@ -37,9 +37,9 @@ proc ** {a b} {
     expr $a ** $b
 }
 
-//
-// Comparison functions
-//
+#
+# Comparison functions
+#
 proc < {a b} {
     expr $a < $b
 }
@@ -53,9 +53,9 @@ proc >= {a b} {
     expr $a >= $b
 }
 
-//
-// Equality
-//
+#
+# Equality
+#
 proc == {a b} {
     expr $a == $b
 }
@@ -66,9 +66,9 @@ proc ne {a b} {
     expr $a ne $b
 }
 
-//
-// Min / Max
-//
+#
+# Min / Max
+#
 proc min {a b} {
     if {< $a $b } {
         return $a
@@ -85,7 +85,7 @@ proc max {a b} {
     }
 }
 
-// Assert a condition is true.
+# Assert a condition is true.
 proc assert {a b c} {
     if { expr $a $b $c } {
         puts "OK : $a $b $c"
@@ -95,79 +95,77 @@ proc assert {a b c} {
     }
 }
 
-// Assert two strings/numbers are equal.
+# Assert two strings/numbers are equal.
 proc assert_equal {a b} {
 
-    // Is the first argument a number?
+    # Is the first argument a number?
     if { regexp {^([0-9\.]+)$$} $a } {
 
-        // Is the second argument a number?
+        # Is the second argument a number?
         if { regexp {^([0-9\.]+)$$} $b } {
 
-            // both numbers: numeric comparison
+            # both numbers: numeric comparison
             return [ assert $a == $b ]
         }
     }
 
-    // string compare
+    # string compare
     assert $a eq $b
 }
 
-//
-// Utility functions
-//
+#
+# Utility functions
+#
 proc repeat {n body} {
     set res ""
     while {> $n 0} {
         decr n
-        set res [$body]
+        set res [eval $body]
     }
     "$res"
 }
 
 
-//
-// Now that we have a "repeat" function defined we could use it like so:
-//
-//   repeat 5 {
-//        puts "Hello I'm alive";
-//   }
-//
-// This would also work:
-//
-//   set foo 12
-//   repeat 5 { incr foo }
-//   => foo is now 17 (i.e. 12 + 5)
-//
-
-
-
-//
-// Run a body multiple times, using an named variable for the index.
-//
-// You could use this like so:
-//
-//    loop cur 0 10 { puts "current iteration $cur ($min->$max)" }
-//    => current iteration 0 (0-10)
-//    => current iteration 1 (0-10)
-//    ..
-//    => current iteration 10 (0-10)
-//
+#
+# Now that we have a "repeat" function defined we could use it like so:
+#
+#   repeat 5 {
+#        puts "Hello I'm alive";
+#   }
+#
+# This would also work:
+#
+#   set foo 12
+#   repeat 5 { incr foo }
+#   => foo is now 17 (i.e. 12 + 5)
+#
+
+
+
+#
+# Run a body multiple times, using an named variable for the index.
+#
+# You could use this like so:
+#
+#    loop cur 0 10 { puts "current iteration $cur ($min->$max)" }
+#    => current iteration 0 (0-10)
+#    => current iteration 1 (0-10)
+#    ..
+#    => current iteration 10 (0-10)
+#
 proc loop {var min max bdy} {
-    // result
+    # result
     set res ""
 
-    // set the variable
-    eval "set $var [set min]"
+    # set the variable
+    set $var $min
 
-    // Run the test
-    while {<= [set "$$var"] $max } {
-        set res [$bdy]
-
-        // This is a bit horrid
-        eval {incr "$var"}
+    # Run the test
+    while {<= [set $var] $max } {
+        set res [eval $bdy]
+        incr $var
     }
 
-    // return the last result
+    # return the last result
     "$res"
 }
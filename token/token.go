@@ -11,6 +11,20 @@ type Type string
 type Token struct {
 	Type    Type
 	Literal string
+
+	// Raw contains the source of the token, exactly as it appeared
+	// in the input.  For example a STRING token will include the
+	// surrounding quotes, and any escape-sequences unprocessed.
+	Raw string
+
+	// Position is the offset, in characters, of the start of the
+	// token within the input.
+	Position int
+}
+
+// End returns the offset of the character which follows the token.
+func (t Token) End() int {
+	return t.Position + len([]rune(t.Raw))
 }
 
 // pre-defined TokenTypes